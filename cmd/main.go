@@ -1,9 +1,14 @@
 package main
 
 import (
+	"log"
+	"os"
+
 	"github.com/dvvnFrtn/capstone-backend/internal/app"
 )
 
 func main() {
-	app.Run()
+	if err := app.Execute(os.Args[1:]); err != nil {
+		log.Fatal(err)
+	}
 }
@@ -1,29 +1,358 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
 	"os"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
 )
 
+type App struct {
+	HTTP        HTTP        `yaml:"http"`
+	DB          DB          `yaml:"db"`
+	Outbox      Outbox      `yaml:"outbox"`
+	Auth        Auth        `yaml:"auth"`
+	Log         Log         `yaml:"log"`
+	Tracing     Tracing     `yaml:"tracing"`
+	Metrics     Metrics     `yaml:"metrics"`
+	Health      Health      `yaml:"health"`
+	RateLimit   RateLimit   `yaml:"rate_limit"`
+	Retention   Retention   `yaml:"retention"`
+	Idempotency Idempotency `yaml:"idempotency"`
+	Features    Features    `yaml:"features"`
+}
+
+type HTTP struct {
+	Host            string        `yaml:"host"`
+	ReadTimeout     time.Duration `yaml:"read_timeout"`
+	WriteTimeout    time.Duration `yaml:"write_timeout"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+
+	// TrustedProxies lists the proxies whose X-Forwarded-For is believed
+	// when resolving the client IP. None are trusted by default.
+	TrustedProxies []string `yaml:"trusted_proxies"`
+}
+
 type DB struct {
-	Host string
-	Port string
-	Pass string
-	User string
-	Name string
+	Host        string `yaml:"host"`
+	Port        string `yaml:"port"`
+	Pass        string `yaml:"password"`
+	User        string `yaml:"user"`
+	Name        string `yaml:"name"`
+	SSLMode     string `yaml:"sslmode"`
+	SSLRootCert string `yaml:"sslrootcert"`
+
+	MaxConns          int32         `yaml:"max_conns"`
+	MinConns          int32         `yaml:"min_conns"`
+	MaxConnLifetime   time.Duration `yaml:"max_conn_lifetime"`
+	MaxConnIdleTime   time.Duration `yaml:"max_conn_idle_time"`
+	HealthCheckPeriod time.Duration `yaml:"health_check_period"`
+	StatementTimeout  time.Duration `yaml:"statement_timeout"`
 }
 
 func (cfg *DB) DSN() string {
-	return fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=%s",
-		cfg.User, cfg.Pass, cfg.Host, cfg.Port, cfg.Name, "disable")
+	query := url.Values{}
+	query.Set("sslmode", cfg.SSLMode)
+	if cfg.SSLMode == "" {
+		query.Set("sslmode", "disable")
+	}
+	if cfg.SSLRootCert != "" {
+		query.Set("sslrootcert", cfg.SSLRootCert)
+	}
+
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(cfg.User, cfg.Pass),
+		Host:     cfg.Host + ":" + cfg.Port,
+		Path:     cfg.Name,
+		RawQuery: query.Encode(),
+	}
+	return dsn.String()
+}
+
+type Outbox struct {
+	PollInterval time.Duration `yaml:"poll_interval"`
+	Lease        time.Duration `yaml:"lease"`
+	BatchSize    int32         `yaml:"batch_size"`
+	MaxAttempts  int32         `yaml:"max_attempts"`
+	BaseBackoff  time.Duration `yaml:"base_backoff"`
+	MaxBackoff   time.Duration `yaml:"max_backoff"`
+
+	// PayloadSecret encrypts the passwords carried by queued account events.
+	PayloadSecret string `yaml:"payload_secret"`
+}
+
+type Auth struct {
+	Provider        string        `yaml:"provider"`
+	TokenVerifier   string        `yaml:"token_verifier"`
+	FirebaseKeyPath string        `yaml:"firebase_key_path"`
+	LocalStore      string        `yaml:"local_store"`
+	LocalSecret     string        `yaml:"local_secret"`
+	LocalTokenTTL   time.Duration `yaml:"local_token_ttl"`
+
+	SupabaseProjectReference string `yaml:"supabase_project_reference"`
+	SupabaseAnonKey          string `yaml:"supabase_anon_key"`
+	SupabaseServiceRoleKey   string `yaml:"supabase_service_role_key"`
+	SupabaseAuthURL          string `yaml:"supabase_auth_url"`
+
+	OIDCIssuer         string `yaml:"oidc_issuer"`
+	OIDCJWKSURL        string `yaml:"oidc_jwks_url"`
+	OIDCAudience       string `yaml:"oidc_audience"`
+	OIDCRoleClaim      string `yaml:"oidc_role_claim"`
+	OIDCCommunityClaim string `yaml:"oidc_community_claim"`
+}
+
+type Log struct {
+	Level  string `yaml:"level"`
+	Format string `yaml:"format"`
+
+	// Levels overrides Level for the loggers of single components, such as
+	// "db" or "outbox".
+	Levels map[string]string `yaml:"levels"`
+}
+
+type Tracing struct {
+	// Exporter is one of none, stdout or otlp. The OTLP exporter also honours
+	// the standard OTEL_EXPORTER_OTLP_* variables.
+	Exporter     string  `yaml:"exporter"`
+	OTLPEndpoint string  `yaml:"otlp_endpoint"`
+	ServiceName  string  `yaml:"service_name"`
+	SampleRatio  float64 `yaml:"sample_ratio"`
+}
+
+// Metrics is served on its own listener, bound to loopback by default so it
+// stays off the public network. An empty Host disables it.
+type Metrics struct {
+	Host string `yaml:"host"`
+}
+
+// Health.DrainDelay is how long the server keeps serving after readiness
+// starts failing on shutdown, so load balancers can stop routing to it.
+type Health struct {
+	CacheTTL   time.Duration `yaml:"cache_ttl"`
+	Timeout    time.Duration `yaml:"timeout"`
+	DrainDelay time.Duration `yaml:"drain_delay"`
+}
+
+type RateLimit struct {
+	Enabled bool                `yaml:"enabled"`
+	Store   string              `yaml:"store"`
+	Rules   map[string]RateRule `yaml:"rules"`
+}
+
+// RateRule allows Requests every Per for each key, with bursts up to Burst
+// (Requests when zero). Key is one of ip, uid or community.
+type RateRule struct {
+	Requests int           `yaml:"requests"`
+	Per      time.Duration `yaml:"per"`
+	Burst    int           `yaml:"burst"`
+	Key      string        `yaml:"key"`
+}
+
+// Retention controls how long soft deleted users are kept, and their
+// identity accounts disabled, before both are removed for good.
+type Retention struct {
+	UserPurgeAfter time.Duration `yaml:"user_purge_after"`
+	PurgeInterval  time.Duration `yaml:"purge_interval"`
+	PurgeBatchSize int32         `yaml:"purge_batch_size"`
 }
 
+// Idempotency controls how long Idempotency-Key responses are replayed.
+// Lease is how long a request may hold its key before a retry may take it
+// over; it must exceed http.write_timeout.
+type Idempotency struct {
+	TTL           time.Duration `yaml:"ttl"`
+	Lease         time.Duration `yaml:"lease"`
+	PurgeInterval time.Duration `yaml:"purge_interval"`
+}
+
+type Features struct {
+	OutboxDispatcher bool `yaml:"outbox_dispatcher"`
+	LocalSignIn      bool `yaml:"local_signin"`
+}
+
+func defaults() App {
+	return App{
+		HTTP: HTTP{
+			Host:            ":8000",
+			ReadTimeout:     15 * time.Second,
+			WriteTimeout:    30 * time.Second,
+			ShutdownTimeout: 5 * time.Second,
+		},
+		DB: DB{
+			Port:              "5432",
+			SSLMode:           "disable",
+			MaxConns:          10,
+			MinConns:          0,
+			MaxConnLifetime:   time.Hour,
+			MaxConnIdleTime:   30 * time.Minute,
+			HealthCheckPeriod: time.Minute,
+			StatementTimeout:  30 * time.Second,
+		},
+		Outbox: Outbox{
+			PollInterval: 5 * time.Second,
+			Lease:        30 * time.Second,
+			BatchSize:    20,
+			MaxAttempts:  8,
+			BaseBackoff:  5 * time.Second,
+			MaxBackoff:   30 * time.Minute,
+		},
+		Auth: Auth{
+			Provider:           "firebase",
+			LocalStore:         "memory",
+			LocalTokenTTL:      time.Hour,
+			OIDCRoleClaim:      "role",
+			OIDCCommunityClaim: "community_id",
+		},
+		Log: Log{
+			Level:  "info",
+			Format: "text",
+		},
+		Tracing: Tracing{
+			Exporter:    "none",
+			ServiceName: "capstone-backend",
+			SampleRatio: 1,
+		},
+		Metrics: Metrics{
+			Host: "127.0.0.1:9090",
+		},
+		Health: Health{
+			CacheTTL:   5 * time.Second,
+			Timeout:    2 * time.Second,
+			DrainDelay: 5 * time.Second,
+		},
+		RateLimit: RateLimit{
+			Enabled: true,
+			Store:   "memory",
+			Rules: map[string]RateRule{
+				"signup":    {Requests: 5, Per: time.Hour, Key: "ip"},
+				"signin":    {Requests: 10, Per: time.Minute, Key: "ip"},
+				"user":      {Requests: 120, Per: time.Minute, Key: "uid"},
+				"community": {Requests: 600, Per: time.Minute, Key: "community"},
+			},
+		},
+		Retention: Retention{
+			UserPurgeAfter: 30 * 24 * time.Hour,
+			PurgeInterval:  time.Hour,
+			PurgeBatchSize: 100,
+		},
+		Idempotency: Idempotency{
+			TTL:           24 * time.Hour,
+			Lease:         time.Minute,
+			PurgeInterval: 10 * time.Minute,
+		},
+		Features: Features{
+			OutboxDispatcher: true,
+			LocalSignIn:      true,
+		},
+	}
+}
+
+// Load builds the configuration from, in increasing precedence, the built-in
+// defaults, the YAML file named by CONFIG_FILE and the environment. A .env
+// file in the working directory is merged into the environment when present.
+// Every problem found is reported in the returned error.
+func Load() (App, error) {
+	return load((*App).validate)
+}
+
+// LoadDatabase is Load for commands that only talk to the database, such as
+// migrate: every section is read but only db has to be valid.
+func LoadDatabase() (App, error) {
+	return load(func(c *App, p *Problems) {
+		c.DB.validate(p)
+	})
+}
+
+// LoadCommand is Load for one-off commands that build the services but serve
+// nothing, such as seed: only db, outbox, auth and log have to be valid.
+// Accounts they create in a memory store would be gone when they exit, so
+// the local provider has to keep them in postgres.
+func LoadCommand() (App, error) {
+	return load(func(c *App, p *Problems) {
+		c.DB.validate(p)
+		c.Outbox.validate(p)
+		c.Auth.validate(p)
+		c.Log.validate(p)
+		if c.Auth.Provider == "local" && c.Auth.LocalStore != "postgres" {
+			p.add("auth.local_store (LOCAL_AUTH_STORE)", "must be postgres for commands that create accounts")
+		}
+	})
+}
+
+func load(validate func(*App, *Problems)) (App, error) {
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return App{}, fmt.Errorf("failed to load .env file: %w", err)
+	}
+
+	cfg := defaults()
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		if err := loadFile(path, &cfg); err != nil {
+			return App{}, err
+		}
+	}
+
+	var problems Problems
+	env := envLoader{problems: &problems}
+	env.app(&cfg)
+	validate(&cfg, &problems)
+
+	if len(problems) > 0 {
+		return cfg, problems
+	}
+	return cfg, nil
+}
+
+func loadFile(path string, cfg *App) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer f.Close()
+
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// Database reads only the database section from the environment, for tools
+// and tests that do not need the full application config.
 func Database() DB {
-	return DB{
-		Host: os.Getenv("POSTGRES_HOST"),
-		Port: os.Getenv("POSTGRES_PORT"),
-		Pass: os.Getenv("POSTGRES_PASSWORD"),
-		User: os.Getenv("POSTGRES_USER"),
-		Name: os.Getenv("POSTGRES_DB"),
+	cfg := defaults().DB
+	envLoader{problems: new(Problems)}.db(&cfg)
+	return cfg
+}
+
+const redacted = "[redacted]"
+
+// Redacted returns a copy safe to print, with every secret masked.
+func (c App) Redacted() App {
+	mask := func(s *string) {
+		if *s != "" {
+			*s = redacted
+		}
+	}
+	mask(&c.DB.Pass)
+	mask(&c.Outbox.PayloadSecret)
+	mask(&c.Auth.LocalSecret)
+	mask(&c.Auth.SupabaseAnonKey)
+	mask(&c.Auth.SupabaseServiceRoleKey)
+	return c
+}
+
+func (c App) String() string {
+	out, err := yaml.Marshal(c.Redacted())
+	if err != nil {
+		return err.Error()
 	}
+	return string(out)
 }
@@ -0,0 +1,235 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// envLoader overrides config values with environment variables that are set.
+// Unparsable values are recorded as problems instead of silently falling back
+// to the default.
+type envLoader struct {
+	problems *Problems
+}
+
+func (e envLoader) app(cfg *App) {
+	e.string("APP_HOST", &cfg.HTTP.Host)
+	e.duration("APP_READ_TIMEOUT", &cfg.HTTP.ReadTimeout)
+	e.duration("APP_WRITE_TIMEOUT", &cfg.HTTP.WriteTimeout)
+	e.duration("APP_SHUTDOWN_TIMEOUT", &cfg.HTTP.ShutdownTimeout)
+	e.list("APP_TRUSTED_PROXIES", &cfg.HTTP.TrustedProxies)
+
+	e.db(&cfg.DB)
+	e.outbox(&cfg.Outbox)
+	e.auth(&cfg.Auth)
+
+	e.string("LOG_LEVEL", &cfg.Log.Level)
+	e.string("LOG_FORMAT", &cfg.Log.Format)
+	e.levels("LOG_LEVELS", &cfg.Log.Levels)
+
+	e.string("TRACING_EXPORTER", &cfg.Tracing.Exporter)
+	e.string("TRACING_OTLP_ENDPOINT", &cfg.Tracing.OTLPEndpoint)
+	e.string("TRACING_SERVICE_NAME", &cfg.Tracing.ServiceName)
+	e.float64("TRACING_SAMPLE_RATIO", &cfg.Tracing.SampleRatio)
+
+	e.string("METRICS_HOST", &cfg.Metrics.Host)
+
+	e.duration("HEALTH_CACHE_TTL", &cfg.Health.CacheTTL)
+	e.duration("HEALTH_TIMEOUT", &cfg.Health.Timeout)
+	e.duration("HEALTH_DRAIN_DELAY", &cfg.Health.DrainDelay)
+
+	e.bool("RATE_LIMIT_ENABLED", &cfg.RateLimit.Enabled)
+	e.string("RATE_LIMIT_STORE", &cfg.RateLimit.Store)
+	e.rules("RATE_LIMIT_RULES", &cfg.RateLimit.Rules)
+
+	e.duration("RETENTION_USER_PURGE_AFTER", &cfg.Retention.UserPurgeAfter)
+	e.duration("RETENTION_PURGE_INTERVAL", &cfg.Retention.PurgeInterval)
+	e.int32("RETENTION_PURGE_BATCH_SIZE", &cfg.Retention.PurgeBatchSize)
+
+	e.duration("IDEMPOTENCY_TTL", &cfg.Idempotency.TTL)
+	e.duration("IDEMPOTENCY_LEASE", &cfg.Idempotency.Lease)
+	e.duration("IDEMPOTENCY_PURGE_INTERVAL", &cfg.Idempotency.PurgeInterval)
+
+	e.bool("FEATURE_OUTBOX_DISPATCHER", &cfg.Features.OutboxDispatcher)
+	e.bool("FEATURE_LOCAL_SIGNIN", &cfg.Features.LocalSignIn)
+}
+
+func (e envLoader) db(cfg *DB) {
+	e.string("POSTGRES_HOST", &cfg.Host)
+	e.string("POSTGRES_PORT", &cfg.Port)
+	e.string("POSTGRES_PASSWORD", &cfg.Pass)
+	e.string("POSTGRES_USER", &cfg.User)
+	e.string("POSTGRES_DB", &cfg.Name)
+	e.string("POSTGRES_SSLMODE", &cfg.SSLMode)
+	e.string("POSTGRES_SSLROOTCERT", &cfg.SSLRootCert)
+
+	e.int32("POSTGRES_MAX_CONNS", &cfg.MaxConns)
+	e.int32("POSTGRES_MIN_CONNS", &cfg.MinConns)
+	e.duration("POSTGRES_MAX_CONN_LIFETIME", &cfg.MaxConnLifetime)
+	e.duration("POSTGRES_MAX_CONN_IDLE_TIME", &cfg.MaxConnIdleTime)
+	e.duration("POSTGRES_HEALTH_CHECK_PERIOD", &cfg.HealthCheckPeriod)
+	e.duration("POSTGRES_STATEMENT_TIMEOUT", &cfg.StatementTimeout)
+}
+
+func (e envLoader) outbox(cfg *Outbox) {
+	e.duration("OUTBOX_POLL_INTERVAL", &cfg.PollInterval)
+	e.duration("OUTBOX_LEASE", &cfg.Lease)
+	e.int32("OUTBOX_BATCH_SIZE", &cfg.BatchSize)
+	e.int32("OUTBOX_MAX_ATTEMPTS", &cfg.MaxAttempts)
+	e.duration("OUTBOX_BASE_BACKOFF", &cfg.BaseBackoff)
+	e.duration("OUTBOX_MAX_BACKOFF", &cfg.MaxBackoff)
+	e.string("OUTBOX_PAYLOAD_SECRET", &cfg.PayloadSecret)
+}
+
+func (e envLoader) auth(cfg *Auth) {
+	e.string("AUTH_PROVIDER", &cfg.Provider)
+	e.string("AUTH_TOKEN_VERIFIER", &cfg.TokenVerifier)
+	e.string("FIREBASE_KEY_PATH", &cfg.FirebaseKeyPath)
+	e.string("LOCAL_AUTH_STORE", &cfg.LocalStore)
+	e.string("LOCAL_AUTH_SECRET", &cfg.LocalSecret)
+	e.duration("LOCAL_AUTH_TOKEN_TTL", &cfg.LocalTokenTTL)
+
+	e.string("SUPABASE_PROJECT_REFERENCE", &cfg.SupabaseProjectReference)
+	e.string("SUPABASE_ANON_KEY", &cfg.SupabaseAnonKey)
+	e.string("SUPABASE_SERVICE_ROLE_KEY", &cfg.SupabaseServiceRoleKey)
+	e.string("SUPABASE_AUTH_URL", &cfg.SupabaseAuthURL)
+
+	e.string("OIDC_ISSUER", &cfg.OIDCIssuer)
+	e.string("OIDC_JWKS_URL", &cfg.OIDCJWKSURL)
+	e.string("OIDC_AUDIENCE", &cfg.OIDCAudience)
+	e.string("OIDC_ROLE_CLAIM", &cfg.OIDCRoleClaim)
+	e.string("OIDC_COMMUNITY_CLAIM", &cfg.OIDCCommunityClaim)
+
+	// The verifier follows the provider unless configured on its own.
+	if cfg.TokenVerifier == "" {
+		cfg.TokenVerifier = cfg.Provider
+	}
+}
+
+func (e envLoader) string(key string, dst *string) {
+	if v := os.Getenv(key); v != "" {
+		*dst = v
+	}
+}
+
+func (e envLoader) list(key string, dst *[]string) {
+	v := os.Getenv(key)
+	if v == "" {
+		return
+	}
+	*dst = nil
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*dst = append(*dst, item)
+		}
+	}
+}
+
+func (e envLoader) int32(key string, dst *int32) {
+	v := os.Getenv(key)
+	if v == "" {
+		return
+	}
+	n, err := strconv.ParseInt(v, 10, 32)
+	if err != nil {
+		e.problems.add(key, fmt.Sprintf("%q is not an integer", v))
+		return
+	}
+	*dst = int32(n)
+}
+
+func (e envLoader) float64(key string, dst *float64) {
+	v := os.Getenv(key)
+	if v == "" {
+		return
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		e.problems.add(key, fmt.Sprintf("%q is not a number", v))
+		return
+	}
+	*dst = f
+}
+
+func (e envLoader) duration(key string, dst *time.Duration) {
+	v := os.Getenv(key)
+	if v == "" {
+		return
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		e.problems.add(key, fmt.Sprintf("%q is not a duration", v))
+		return
+	}
+	*dst = d
+}
+
+func (e envLoader) bool(key string, dst *bool) {
+	v := os.Getenv(key)
+	if v == "" {
+		return
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		e.problems.add(key, fmt.Sprintf("%q is not a boolean", v))
+		return
+	}
+	*dst = b
+}
+
+// levels reads a comma separated list of component=level pairs, e.g.
+// "db=debug,outbox=warn", on top of the levels already configured.
+func (e envLoader) levels(key string, dst *map[string]string) {
+	v := os.Getenv(key)
+	if v == "" {
+		return
+	}
+	if *dst == nil {
+		*dst = make(map[string]string)
+	}
+	for _, pair := range strings.Split(v, ",") {
+		component, level, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || component == "" {
+			e.problems.add(key, fmt.Sprintf("%q is not a component=level pair", pair))
+			continue
+		}
+		(*dst)[component] = level
+	}
+}
+
+// rules reads a comma separated list of name=requests/per:key rules, e.g.
+// "signup=5/1h:ip,user=60/1m:uid", on top of the rules already configured.
+func (e envLoader) rules(key string, dst *map[string]RateRule) {
+	v := os.Getenv(key)
+	if v == "" {
+		return
+	}
+	if *dst == nil {
+		*dst = make(map[string]RateRule)
+	}
+	for _, item := range strings.Split(v, ",") {
+		item = strings.TrimSpace(item)
+		name, spec, ok := strings.Cut(item, "=")
+		rate, ruleKey, ok2 := strings.Cut(spec, ":")
+		requests, per, ok3 := strings.Cut(rate, "/")
+		if !ok || !ok2 || !ok3 || name == "" {
+			e.problems.add(key, fmt.Sprintf("%q is not a name=requests/per:key rule", item))
+			continue
+		}
+
+		n, err := strconv.Atoi(requests)
+		if err != nil {
+			e.problems.add(key, fmt.Sprintf("%q is not an integer", requests))
+			continue
+		}
+		d, err := time.ParseDuration(per)
+		if err != nil {
+			e.problems.add(key, fmt.Sprintf("%q is not a duration", per))
+			continue
+		}
+		(*dst)[name] = RateRule{Requests: n, Per: d, Key: ruleKey}
+	}
+}
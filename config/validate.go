@@ -0,0 +1,185 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Problems collects every invalid setting so they can be fixed in one go.
+type Problems []string
+
+func (p *Problems) add(field, msg string) {
+	*p = append(*p, field+": "+msg)
+}
+
+func (p Problems) Error() string {
+	return fmt.Sprintf("%d configuration problem(s):\n  - %s", len(p), strings.Join(p, "\n  - "))
+}
+
+var (
+	sslModes       = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+	authProviders  = []string{"firebase", "local", "supabase"}
+	tokenVerifiers = []string{"firebase", "local", "supabase", "oidc"}
+	localStores    = []string{"memory", "postgres"}
+	logLevels      = []string{"debug", "info", "warn", "error"}
+	logFormats     = []string{"text", "json"}
+	traceExporters = []string{"none", "stdout", "otlp"}
+	limitStores    = []string{"memory", "postgres"}
+	limitKeys      = []string{"ip", "uid", "community"}
+)
+
+func (c *App) validate(p *Problems) {
+	if c.HTTP.Host == "" {
+		p.add("http.host (APP_HOST)", "is required")
+	}
+	positive(p, "http.shutdown_timeout (APP_SHUTDOWN_TIMEOUT)", c.HTTP.ShutdownTimeout)
+	if c.Metrics.Host != "" && c.Metrics.Host == c.HTTP.Host {
+		p.add("metrics.host (METRICS_HOST)", "must differ from http.host")
+	}
+
+	if c.Health.CacheTTL < 0 {
+		p.add("health.cache_ttl (HEALTH_CACHE_TTL)", "must not be negative")
+	}
+	positive(p, "health.timeout (HEALTH_TIMEOUT)", c.Health.Timeout)
+	if c.Health.DrainDelay < 0 {
+		p.add("health.drain_delay (HEALTH_DRAIN_DELAY)", "must not be negative")
+	}
+
+	positive(p, "retention.user_purge_after (RETENTION_USER_PURGE_AFTER)", c.Retention.UserPurgeAfter)
+	positive(p, "retention.purge_interval (RETENTION_PURGE_INTERVAL)", c.Retention.PurgeInterval)
+	positive(p, "retention.purge_batch_size (RETENTION_PURGE_BATCH_SIZE)", c.Retention.PurgeBatchSize)
+
+	positive(p, "idempotency.ttl (IDEMPOTENCY_TTL)", c.Idempotency.TTL)
+	positive(p, "idempotency.lease (IDEMPOTENCY_LEASE)", c.Idempotency.Lease)
+	if c.Idempotency.Lease <= c.HTTP.WriteTimeout {
+		// a request still running past its lease would have its key taken
+		// over by a retry
+		p.add("idempotency.lease (IDEMPOTENCY_LEASE)", "must exceed http.write_timeout (APP_WRITE_TIMEOUT)")
+	}
+	positive(p, "idempotency.purge_interval (IDEMPOTENCY_PURGE_INTERVAL)", c.Idempotency.PurgeInterval)
+
+	c.DB.validate(p)
+	c.RateLimit.validate(p)
+	c.Outbox.validate(p)
+	c.Auth.validate(p)
+
+	c.Log.validate(p)
+
+	oneOf(p, "tracing.exporter (TRACING_EXPORTER)", c.Tracing.Exporter, traceExporters)
+	if c.Tracing.Exporter != "none" {
+		required(p, "tracing.service_name (TRACING_SERVICE_NAME)", c.Tracing.ServiceName)
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		p.add("tracing.sample_ratio (TRACING_SAMPLE_RATIO)", "must be between 0 and 1")
+	}
+}
+
+func (c *DB) validate(p *Problems) {
+	required(p, "db.host (POSTGRES_HOST)", c.Host)
+	required(p, "db.user (POSTGRES_USER)", c.User)
+	required(p, "db.name (POSTGRES_DB)", c.Name)
+	if port, err := strconv.Atoi(c.Port); err != nil || port <= 0 || port > 65535 {
+		p.add("db.port (POSTGRES_PORT)", fmt.Sprintf("%q is not a valid port", c.Port))
+	}
+	oneOf(p, "db.sslmode (POSTGRES_SSLMODE)", c.SSLMode, sslModes)
+
+	positive(p, "db.max_conns (POSTGRES_MAX_CONNS)", c.MaxConns)
+	if c.MinConns < 0 || c.MinConns > c.MaxConns {
+		p.add("db.min_conns (POSTGRES_MIN_CONNS)", "must be between 0 and max_conns")
+	}
+	positive(p, "db.max_conn_lifetime (POSTGRES_MAX_CONN_LIFETIME)", c.MaxConnLifetime)
+	positive(p, "db.max_conn_idle_time (POSTGRES_MAX_CONN_IDLE_TIME)", c.MaxConnIdleTime)
+	positive(p, "db.health_check_period (POSTGRES_HEALTH_CHECK_PERIOD)", c.HealthCheckPeriod)
+	if c.StatementTimeout < 0 {
+		p.add("db.statement_timeout (POSTGRES_STATEMENT_TIMEOUT)", "must not be negative")
+	}
+}
+
+func (c *Log) validate(p *Problems) {
+	oneOf(p, "log.level (LOG_LEVEL)", c.Level, logLevels)
+	for _, component := range slices.Sorted(maps.Keys(c.Levels)) {
+		oneOf(p, "log.levels."+component+" (LOG_LEVELS)", c.Levels[component], logLevels)
+	}
+	oneOf(p, "log.format (LOG_FORMAT)", c.Format, logFormats)
+}
+
+func (c *Outbox) validate(p *Problems) {
+	positive(p, "outbox.poll_interval (OUTBOX_POLL_INTERVAL)", c.PollInterval)
+	positive(p, "outbox.lease (OUTBOX_LEASE)", c.Lease)
+	positive(p, "outbox.batch_size (OUTBOX_BATCH_SIZE)", c.BatchSize)
+	positive(p, "outbox.max_attempts (OUTBOX_MAX_ATTEMPTS)", c.MaxAttempts)
+	positive(p, "outbox.base_backoff (OUTBOX_BASE_BACKOFF)", c.BaseBackoff)
+	if c.MaxBackoff < c.BaseBackoff {
+		p.add("outbox.max_backoff (OUTBOX_MAX_BACKOFF)", "must not be shorter than base_backoff")
+	}
+	required(p, "outbox.payload_secret (OUTBOX_PAYLOAD_SECRET)", c.PayloadSecret)
+}
+
+func (c *Auth) validate(p *Problems) {
+	oneOf(p, "auth.provider (AUTH_PROVIDER)", c.Provider, authProviders)
+	oneOf(p, "auth.token_verifier (AUTH_TOKEN_VERIFIER)", c.TokenVerifier, tokenVerifiers)
+
+	uses := func(name string) bool {
+		return c.Provider == name || c.TokenVerifier == name
+	}
+
+	if uses("firebase") {
+		required(p, "auth.firebase_key_path (FIREBASE_KEY_PATH)", c.FirebaseKeyPath)
+	}
+	if uses("local") {
+		required(p, "auth.local_secret (LOCAL_AUTH_SECRET)", c.LocalSecret)
+		oneOf(p, "auth.local_store (LOCAL_AUTH_STORE)", c.LocalStore, localStores)
+		positive(p, "auth.local_token_ttl (LOCAL_AUTH_TOKEN_TTL)", c.LocalTokenTTL)
+	}
+	if uses("supabase") {
+		if c.SupabaseProjectReference == "" && c.SupabaseAuthURL == "" {
+			p.add("auth.supabase_project_reference (SUPABASE_PROJECT_REFERENCE)", "or auth.supabase_auth_url is required")
+		}
+		required(p, "auth.supabase_anon_key (SUPABASE_ANON_KEY)", c.SupabaseAnonKey)
+	}
+	if c.Provider == "supabase" {
+		required(p, "auth.supabase_service_role_key (SUPABASE_SERVICE_ROLE_KEY)", c.SupabaseServiceRoleKey)
+	}
+	if c.TokenVerifier == "oidc" && c.OIDCIssuer == "" && c.OIDCJWKSURL == "" {
+		p.add("auth.oidc_issuer (OIDC_ISSUER)", "or auth.oidc_jwks_url is required")
+	}
+}
+
+func (c *RateLimit) validate(p *Problems) {
+	if !c.Enabled {
+		return
+	}
+
+	oneOf(p, "rate_limit.store (RATE_LIMIT_STORE)", c.Store, limitStores)
+	for _, name := range slices.Sorted(maps.Keys(c.Rules)) {
+		rule, field := c.Rules[name], "rate_limit.rules."+name+" (RATE_LIMIT_RULES)"
+		if rule.Requests <= 0 || rule.Per <= 0 {
+			p.add(field, "requests and per must be positive")
+		}
+		if rule.Burst < 0 {
+			p.add(field, "burst must not be negative")
+		}
+		oneOf(p, field, rule.Key, limitKeys)
+	}
+}
+
+func required(p *Problems, field, value string) {
+	if value == "" {
+		p.add(field, "is required")
+	}
+}
+
+func oneOf(p *Problems, field, value string, allowed []string) {
+	if !slices.Contains(allowed, value) {
+		p.add(field, fmt.Sprintf("%q must be one of %s", value, strings.Join(allowed, ", ")))
+	}
+}
+
+func positive[T ~int32 | ~int64](p *Problems, field string, value T) {
+	if value <= 0 {
+		p.add(field, "must be positive")
+	}
+}
@@ -0,0 +1,83 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	database "github.com/dvvnFrtn/capstone-backend/infra/db/sqlc"
+	"github.com/dvvnFrtn/capstone-backend/pkg/idempotency"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type IdempotencyStore struct {
+	pool *pgxpool.Pool
+}
+
+// NewIdempotencyStore shares the idempotency keys between every instance
+// using the database, so a retry is recognised wherever it lands.
+func NewIdempotencyStore(pool *pgxpool.Pool) *IdempotencyStore {
+	return &IdempotencyStore{pool: pool}
+}
+
+func (s *IdempotencyStore) Begin(ctx context.Context, claim idempotency.Claim) (idempotency.Record, bool, error) {
+	queries := database.New(s.pool)
+
+	// The holder of the key may release it between the failed claim and the
+	// lookup, in which case claiming again succeeds.
+	for range 2 {
+		claimedAt, err := queries.ClaimIdempotencyKey(ctx, database.ClaimIdempotencyKeyParams{
+			Key:          claim.Key,
+			RequestHash:  claim.RequestHash,
+			TtlSeconds:   claim.TTL.Seconds(),
+			LeaseSeconds: claim.Lease.Seconds(),
+		})
+		if err == nil {
+			return idempotency.Record{ClaimedAt: claimedAt.Time, RequestHash: claim.RequestHash}, true, nil
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return idempotency.Record{}, false, err
+		}
+
+		row, err := queries.FindIdempotencyKey(ctx, claim.Key)
+		if errors.Is(err, pgx.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return idempotency.Record{}, false, err
+		}
+		return idempotency.Record{
+			ClaimedAt:   row.CreatedAt.Time,
+			RequestHash: row.RequestHash,
+			Completed:   row.Status == "completed",
+			Status:      int(row.ResponseStatus.Int32),
+			ContentType: row.ResponseContentType.String,
+			Body:        row.ResponseBody,
+		}, false, nil
+	}
+	return idempotency.Record{}, false, errors.New("idempotency key changed hands while claiming it")
+}
+
+func (s *IdempotencyStore) Complete(ctx context.Context, key string, claimedAt time.Time, status int, contentType string, body []byte) error {
+	return database.New(s.pool).CompleteIdempotencyKey(ctx, database.CompleteIdempotencyKeyParams{
+		Key:                 key,
+		ClaimedAt:           pgtype.Timestamptz{Time: claimedAt, Valid: true},
+		ResponseStatus:      pgtype.Int4{Int32: int32(status), Valid: true},
+		ResponseContentType: pgtype.Text{String: contentType, Valid: contentType != ""},
+		ResponseBody:        body,
+	})
+}
+
+func (s *IdempotencyStore) Release(ctx context.Context, key string, claimedAt time.Time) error {
+	return database.New(s.pool).ReleaseIdempotencyKey(ctx, database.ReleaseIdempotencyKeyParams{
+		Key:       key,
+		ClaimedAt: pgtype.Timestamptz{Time: claimedAt, Valid: true},
+	})
+}
+
+// Purge deletes expired keys.
+func (s *IdempotencyStore) Purge(ctx context.Context) (int64, error) {
+	return database.New(s.pool).PurgeIdempotencyKeys(ctx)
+}
@@ -0,0 +1,112 @@
+package db_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dvvnFrtn/capstone-backend/infra/db"
+	"github.com/dvvnFrtn/capstone-backend/pkg/idempotency"
+	"github.com/dvvnFrtn/capstone-backend/pkg/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotencyStore(t *testing.T) {
+	ctx := context.Background()
+	store := db.NewIdempotencyStore(testutil.NewTestPool(t))
+
+	t.Run("concurrent claims grant one", func(t *testing.T) {
+		claim := idempotency.Claim{Key: "concurrent", RequestHash: "h", TTL: time.Hour, Lease: time.Minute}
+
+		var (
+			wg      sync.WaitGroup
+			granted atomic.Int32
+		)
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, claimed, err := store.Begin(ctx, claim)
+				assert.NoError(t, err)
+				if claimed {
+					granted.Add(1)
+				}
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, int32(1), granted.Load())
+	})
+
+	t.Run("completed key is replayed", func(t *testing.T) {
+		claim := idempotency.Claim{Key: "replayed", RequestHash: "h", TTL: time.Hour, Lease: time.Minute}
+
+		held, claimed, err := store.Begin(ctx, claim)
+		require.NoError(t, err)
+		require.True(t, claimed)
+
+		rec, claimed, err := store.Begin(ctx, claim)
+		require.NoError(t, err)
+		assert.False(t, claimed)
+		assert.False(t, rec.Completed)
+
+		require.NoError(t, store.Complete(ctx, claim.Key, held.ClaimedAt, 201, "application/json", []byte(`{"id":1}`)))
+
+		rec, claimed, err = store.Begin(ctx, claim)
+		require.NoError(t, err)
+		assert.False(t, claimed)
+		assert.True(t, rec.Completed)
+		assert.Equal(t, 201, rec.Status)
+		assert.Equal(t, []byte(`{"id":1}`), rec.Body)
+	})
+
+	t.Run("abandoned claim is taken over", func(t *testing.T) {
+		claim := idempotency.Claim{Key: "abandoned", RequestHash: "h", TTL: time.Hour, Lease: 100 * time.Millisecond}
+
+		abandoned, claimed, err := store.Begin(ctx, claim)
+		require.NoError(t, err)
+		require.True(t, claimed)
+
+		time.Sleep(200 * time.Millisecond)
+		held, claimed, err := store.Begin(ctx, claim)
+		require.NoError(t, err)
+		require.True(t, claimed)
+
+		// the request that lost its claim finishes late
+		require.NoError(t, store.Complete(ctx, claim.Key, abandoned.ClaimedAt, 500, "", nil))
+		require.NoError(t, store.Release(ctx, claim.Key, abandoned.ClaimedAt))
+
+		rec, claimed, err := store.Begin(ctx, claim)
+		require.NoError(t, err)
+		assert.False(t, claimed)
+		assert.False(t, rec.Completed)
+		assert.True(t, held.ClaimedAt.Equal(rec.ClaimedAt))
+
+		require.NoError(t, store.Complete(ctx, claim.Key, held.ClaimedAt, 201, "", nil))
+		rec, _, err = store.Begin(ctx, claim)
+		require.NoError(t, err)
+		assert.Equal(t, 201, rec.Status)
+	})
+
+	t.Run("expired key is claimed again", func(t *testing.T) {
+		claim := idempotency.Claim{Key: "expired", RequestHash: "h", TTL: 100 * time.Millisecond, Lease: time.Minute}
+
+		held, claimed, err := store.Begin(ctx, claim)
+		require.NoError(t, err)
+		require.True(t, claimed)
+		require.NoError(t, store.Complete(ctx, claim.Key, held.ClaimedAt, 201, "", nil))
+
+		time.Sleep(200 * time.Millisecond)
+		purged, err := store.Purge(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), purged)
+
+		claim.TTL = time.Hour
+		_, claimed, err = store.Begin(ctx, claim)
+		require.NoError(t, err)
+		assert.True(t, claimed)
+	})
+}
@@ -0,0 +1,121 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	database "github.com/dvvnFrtn/capstone-backend/infra/db/sqlc"
+	"github.com/dvvnFrtn/capstone-backend/pkg/authx"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type localAccountStore struct {
+	pool *pgxpool.Pool
+}
+
+// NewLocalAccountStore keeps the accounts of the local identity provider in
+// the database.
+func NewLocalAccountStore(pool *pgxpool.Pool) authx.LocalStore {
+	return &localAccountStore{pool: pool}
+}
+
+func (s *localAccountStore) Create(ctx context.Context, acc authx.LocalAccount) error {
+	claims, err := json.Marshal(acc.Claims)
+	if err != nil {
+		return err
+	}
+
+	err = database.New(s.pool).InsertLocalAccount(ctx, database.InsertLocalAccountParams{
+		Uid:          acc.UID,
+		Email:        pgtype.Text{String: acc.Email, Valid: acc.Email != ""},
+		Phone:        pgtype.Text{String: acc.Phone, Valid: acc.Phone != ""},
+		PasswordHash: acc.PasswordHash,
+		Claims:       claims,
+	})
+	return mapLocalAccountError(err)
+}
+
+func (s *localAccountStore) Update(ctx context.Context, acc authx.LocalAccount) error {
+	claims, err := json.Marshal(acc.Claims)
+	if err != nil {
+		return err
+	}
+
+	n, err := database.New(s.pool).UpdateLocalAccount(ctx, database.UpdateLocalAccountParams{
+		Uid:          acc.UID,
+		Email:        pgtype.Text{String: acc.Email, Valid: acc.Email != ""},
+		Phone:        pgtype.Text{String: acc.Phone, Valid: acc.Phone != ""},
+		PasswordHash: acc.PasswordHash,
+		Claims:       claims,
+		Disabled:     acc.Disabled,
+		ValidAfter:   pgtype.Timestamptz{Time: acc.ValidAfter, Valid: !acc.ValidAfter.IsZero()},
+	})
+	if err != nil {
+		return mapLocalAccountError(err)
+	}
+	if n == 0 {
+		return authx.ErrAccountNotFound
+	}
+	return nil
+}
+
+func (s *localAccountStore) Delete(ctx context.Context, uid string) error {
+	n, err := database.New(s.pool).DeleteLocalAccount(ctx, uid)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return authx.ErrAccountNotFound
+	}
+	return nil
+}
+
+func (s *localAccountStore) Get(ctx context.Context, uid string) (authx.LocalAccount, error) {
+	row, err := database.New(s.pool).FindLocalAccountByUID(ctx, uid)
+	if err != nil {
+		return authx.LocalAccount{}, mapLocalAccountError(err)
+	}
+	return toLocalAccount(row)
+}
+
+func (s *localAccountStore) FindByLogin(ctx context.Context, login string) (authx.LocalAccount, error) {
+	row, err := database.New(s.pool).FindLocalAccountByLogin(ctx, login)
+	if err != nil {
+		return authx.LocalAccount{}, mapLocalAccountError(err)
+	}
+	return toLocalAccount(row)
+}
+
+func toLocalAccount(row database.LocalAccount) (authx.LocalAccount, error) {
+	acc := authx.LocalAccount{
+		UID:          row.Uid,
+		Email:        row.Email.String,
+		Phone:        row.Phone.String,
+		PasswordHash: row.PasswordHash,
+		Disabled:     row.Disabled,
+		ValidAfter:   row.ValidAfter.Time,
+	}
+	if err := json.Unmarshal(row.Claims, &acc.Claims); err != nil {
+		return authx.LocalAccount{}, err
+	}
+	return acc, nil
+}
+
+func mapLocalAccountError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		return authx.ErrAccountNotFound
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return authx.ErrAccountExists
+	}
+	return err
+}
@@ -0,0 +1,158 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/dvvnFrtn/capstone-backend/infra/db/migrations"
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Migrator applies the migrations embedded in the binary. The postgres driver
+// holds a pg_advisory_lock for the duration of every operation, so several
+// instances migrating at boot are serialized instead of racing.
+type Migrator struct {
+	m *migrate.Migrate
+}
+
+type MigrationStatus struct {
+	Version uint
+	Name    string
+	Applied bool
+}
+
+func NewMigrator(dsn string, lockTimeout time.Duration) (*Migrator, error) {
+	src, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := migrate.NewWithSourceInstance("iofs", src, dsn)
+	if err != nil {
+		return nil, err
+	}
+	m.LockTimeout = lockTimeout
+
+	return &Migrator{m: m}, nil
+}
+
+func (mg *Migrator) Close() error {
+	srcErr, dbErr := mg.m.Close()
+	return errors.Join(srcErr, dbErr)
+}
+
+func (mg *Migrator) Up() error {
+	if err := mg.m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return err
+	}
+	return nil
+}
+
+// Down reverts the given number of migrations, or all of them when steps is 0.
+func (mg *Migrator) Down(steps int) error {
+	var err error
+	if steps == 0 {
+		err = mg.m.Down()
+	} else {
+		err = mg.m.Steps(-steps)
+	}
+	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return err
+	}
+	return nil
+}
+
+func (mg *Migrator) Force(version int) error {
+	return mg.m.Force(version)
+}
+
+// Version returns the applied version, 0 when nothing has been applied yet.
+func (mg *Migrator) Version() (version uint, dirty bool, err error) {
+	version, dirty, err = mg.m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, nil
+	}
+	return version, dirty, err
+}
+
+func (mg *Migrator) Status() ([]MigrationStatus, error) {
+	current, _, err := mg.Version()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses, err := embeddedMigrations()
+	if err != nil {
+		return nil, err
+	}
+	for i := range statuses {
+		statuses[i].Applied = statuses[i].Version <= current
+	}
+
+	return statuses, nil
+}
+
+func embeddedMigrations() ([]MigrationStatus, error) {
+	files, err := fs.Glob(migrations.FS, "*.up.sql")
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(files)
+
+	statuses := make([]MigrationStatus, 0, len(files))
+	for _, file := range files {
+		var version uint
+		if _, err := fmt.Sscanf(file, "%d_", &version); err != nil {
+			return nil, fmt.Errorf("malformed migration name %s: %w", file, err)
+		}
+
+		name := strings.TrimSuffix(file, ".up.sql")
+		name = strings.TrimPrefix(name, fmt.Sprintf("%d_", version))
+		statuses = append(statuses, MigrationStatus{Version: version, Name: name})
+	}
+
+	return statuses, nil
+}
+
+// CheckMigrations fails when the database is dirty or behind the newest
+// migration embedded in the binary. It reads the golang-migrate bookkeeping
+// table directly so it can run on the application pool.
+func CheckMigrations(ctx context.Context, pool *pgxpool.Pool) error {
+	statuses, err := embeddedMigrations()
+	if err != nil {
+		return err
+	}
+	if len(statuses) == 0 {
+		return nil
+	}
+	latest := statuses[len(statuses)-1].Version
+
+	var (
+		version int64
+		dirty   bool
+	)
+	err = pool.QueryRow(ctx, "select version, dirty from schema_migrations limit 1").Scan(&version, &dirty)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("no migration applied, expected version %d", latest)
+	}
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case dirty:
+		return fmt.Errorf("migration %d is dirty", version)
+	case uint(version) < latest:
+		return fmt.Errorf("database at version %d, expected %d", version, latest)
+	}
+	return nil
+}
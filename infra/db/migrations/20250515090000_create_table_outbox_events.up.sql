@@ -0,0 +1,21 @@
+create table if not exists outbox_events (
+    id uuid not null primary key,
+    community_id uuid not null,
+    aggregate_id uuid not null,
+    event_type varchar not null,
+    payload jsonb not null,
+    status varchar not null default 'pending',
+    attempts int not null default 0,
+    last_error varchar,
+    next_attempt_at timestamp not null default current_timestamp,
+    delivered_at timestamp,
+    created_at timestamp default current_timestamp,
+    updated_at timestamp default current_timestamp
+);
+
+create index if not exists idx_outbox_events_pending
+    on outbox_events (next_attempt_at)
+    where status = 'pending';
+
+create index if not exists idx_outbox_events_community_status
+    on outbox_events (community_id, status);
@@ -0,0 +1 @@
+drop table if exists local_accounts;
@@ -0,0 +1,10 @@
+create table if not exists local_accounts (
+    uid varchar not null primary key,
+    email varchar unique,
+    phone varchar unique,
+    password_hash bytea not null,
+    claims jsonb not null default '{}',
+    disabled boolean not null default false,
+    created_at timestamp default current_timestamp,
+    updated_at timestamp default current_timestamp
+);
@@ -0,0 +1,4 @@
+alter table local_accounts
+    drop column valid_after;
+alter table users
+    drop column tokens_valid_after;
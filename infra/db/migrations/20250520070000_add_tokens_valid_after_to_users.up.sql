@@ -0,0 +1,4 @@
+alter table users
+    add column tokens_valid_after timestamptz;
+alter table local_accounts
+    add column valid_after timestamptz;
@@ -0,0 +1,3 @@
+drop table if exists role_permissions;
+drop table if exists roles;
+drop table if exists permissions;
@@ -0,0 +1,55 @@
+create table if not exists permissions (
+    name varchar not null primary key,
+    description varchar not null
+);
+
+create table if not exists roles (
+    id uuid not null primary key default gen_random_uuid(),
+    community_id uuid references communities(id) on delete cascade,
+    name varchar not null,
+    description varchar not null default '',
+    created_at timestamp default current_timestamp,
+    updated_at timestamp default current_timestamp
+);
+
+create unique index if not exists roles_system_name_key
+    on roles (name) where community_id is null;
+create unique index if not exists roles_community_name_key
+    on roles (community_id, name) where community_id is not null;
+
+create table if not exists role_permissions (
+    role_id uuid not null references roles(id) on delete cascade,
+    permission varchar not null references permissions(name) on delete cascade,
+    primary key (role_id, permission)
+);
+
+insert into permissions (name, description) values
+    ('users:create', 'Menambahkan warga ke komunitas'),
+    ('users:read', 'Melihat data seluruh warga komunitas'),
+    ('users:read:self', 'Melihat data diri sendiri'),
+    ('users:update', 'Mengubah data warga komunitas'),
+    ('users:delete', 'Menghapus warga dari komunitas'),
+    ('roles:read', 'Melihat peran dan hak akses komunitas'),
+    ('roles:manage', 'Mengelola peran dan hak akses komunitas'),
+    ('outbox:read', 'Melihat sinkronisasi akun yang tertunda'),
+    ('outbox:replay', 'Menjadwalkan ulang sinkronisasi akun');
+
+insert into roles (name, description) values
+    ('admin', 'Pengelola komunitas'),
+    ('pengurus', 'Pengurus RT/RW'),
+    ('warga', 'Warga');
+
+insert into role_permissions (role_id, permission)
+select r.id, p.name
+from roles r, permissions p
+where r.community_id is null and r.name = 'admin';
+
+insert into role_permissions (role_id, permission)
+select r.id, p.permission
+from roles r, (values ('users:read'), ('users:read:self'), ('roles:read')) as p(permission)
+where r.community_id is null and r.name = 'pengurus';
+
+insert into role_permissions (role_id, permission)
+select r.id, 'users:read:self'
+from roles r
+where r.community_id is null and r.name = 'warga';
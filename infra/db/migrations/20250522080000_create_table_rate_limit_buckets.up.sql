@@ -0,0 +1,12 @@
+-- A bucket may only be purged once it has refilled, which takes burst/rate
+-- of the limit it was taken under, so every take records when that is.
+create table if not exists rate_limit_buckets (
+    key varchar not null primary key,
+    tokens double precision not null,
+    allowed boolean not null,
+    updated_at timestamptz not null default now(),
+    expires_at timestamptz not null
+);
+
+create index if not exists rate_limit_buckets_expires_at_idx
+    on rate_limit_buckets (expires_at);
@@ -0,0 +1,5 @@
+drop index if exists users_community_role_idx;
+drop index if exists users_community_fullname_idx;
+drop index if exists users_community_created_at_idx;
+
+alter table users alter column created_at drop not null;
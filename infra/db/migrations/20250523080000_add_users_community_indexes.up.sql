@@ -0,0 +1,11 @@
+update users set created_at = current_timestamp where created_at is null;
+alter table users alter column created_at set not null;
+
+create index if not exists users_community_created_at_idx
+    on users (community_id, created_at, id);
+
+create index if not exists users_community_fullname_idx
+    on users (community_id, fullname, id);
+
+create index if not exists users_community_role_idx
+    on users (community_id, role);
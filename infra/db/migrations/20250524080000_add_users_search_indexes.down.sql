@@ -0,0 +1,6 @@
+drop index if exists users_address_trgm_idx;
+drop index if exists users_phone_trgm_idx;
+drop index if exists users_fullname_trgm_idx;
+drop index if exists users_search_document_idx;
+
+drop function if exists users_search_document(varchar, varchar);
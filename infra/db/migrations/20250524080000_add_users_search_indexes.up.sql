@@ -0,0 +1,26 @@
+create extension if not exists pg_trgm;
+
+-- users_search_document is shared by the search query and its index, so the
+-- planner can match them. The simple configuration does no stemming, which
+-- suits Indonesian names and street names.
+create or replace function users_search_document(fullname varchar, address varchar)
+returns tsvector
+language sql
+immutable
+as $$
+    select
+        setweight(to_tsvector('simple', coalesce(fullname, '')), 'A') ||
+        setweight(to_tsvector('simple', coalesce(address, '')), 'B')
+$$;
+
+create index if not exists users_search_document_idx
+    on users using gin (users_search_document(fullname, address));
+
+create index if not exists users_fullname_trgm_idx
+    on users using gin (fullname gin_trgm_ops);
+
+create index if not exists users_phone_trgm_idx
+    on users using gin (phone gin_trgm_ops);
+
+create index if not exists users_address_trgm_idx
+    on users using gin (address gin_trgm_ops);
@@ -0,0 +1,3 @@
+drop index if exists users_deleted_at_idx;
+
+alter table users drop column if exists deleted_at;
@@ -0,0 +1,44 @@
+create table if not exists audit_events (
+    id uuid not null primary key,
+    community_id uuid not null,
+    actor_uid uuid,
+    action varchar not null,
+    target_type varchar not null,
+    target_id uuid not null,
+    diff jsonb not null default '{}'::jsonb,
+    request_id varchar,
+    -- clock_timestamp keeps the order of events written by one transaction
+    created_at timestamptz not null default clock_timestamp()
+);
+
+create index if not exists audit_events_community_created_at_idx
+    on audit_events (community_id, created_at desc, id desc);
+
+create index if not exists audit_events_community_target_idx
+    on audit_events (community_id, target_id);
+
+-- audit_events is append-only, even for the application's own role.
+create or replace function audit_events_append_only()
+returns trigger
+language plpgsql
+as $$
+begin
+    raise exception 'audit_events is append-only';
+end;
+$$;
+
+create trigger audit_events_no_update_delete
+    before update or delete on audit_events
+    for each row execute function audit_events_append_only();
+
+create trigger audit_events_no_truncate
+    before truncate on audit_events
+    for each statement execute function audit_events_append_only();
+
+insert into permissions (name, description) values
+    ('audit:read', 'Melihat riwayat perubahan data warga');
+
+insert into role_permissions (role_id, permission)
+select r.id, 'audit:read'
+from roles r
+where r.community_id is null and r.name = 'admin';
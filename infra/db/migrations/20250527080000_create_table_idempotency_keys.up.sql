@@ -0,0 +1,14 @@
+create table if not exists idempotency_keys (
+    key varchar not null primary key,
+    request_hash varchar not null,
+    status varchar not null default 'pending',
+    response_status int,
+    response_content_type varchar,
+    response_body bytea,
+    created_at timestamptz not null default now(),
+    expires_at timestamptz not null,
+    constraint idempotency_keys_status_check check (status in ('pending', 'completed'))
+);
+
+create index if not exists idempotency_keys_expires_at_idx
+    on idempotency_keys (expires_at);
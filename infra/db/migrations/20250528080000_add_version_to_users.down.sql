@@ -0,0 +1,5 @@
+drop trigger if exists users_touch on users;
+
+drop function if exists users_touch();
+
+alter table users drop column if exists version;
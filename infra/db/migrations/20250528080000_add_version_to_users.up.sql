@@ -0,0 +1,25 @@
+alter table users add column if not exists version bigint not null default 1;
+
+update users set updated_at = coalesce(updated_at, created_at, current_timestamp)
+where updated_at is null;
+
+-- Every change to a field clients edit bumps the version, which they send
+-- back in If-Match to detect concurrent edits. Revoking tokens or soft
+-- deleting a user is not counted, so it leaves the ETags clients hold valid.
+create or replace function users_touch()
+returns trigger
+language plpgsql
+as $$
+begin
+    new.updated_at := current_timestamp;
+    new.version := old.version + 1;
+    return new;
+end;
+$$;
+
+create trigger users_touch
+    before update on users
+    for each row
+    when ((old.fullname, old.email, old.phone, old.address, old.role)
+        is distinct from (new.fullname, new.email, new.phone, new.address, new.role))
+    execute function users_touch();
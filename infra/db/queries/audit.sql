@@ -0,0 +1,37 @@
+-- name: InsertAuditEvent :exec
+insert into audit_events (
+    id,
+    community_id,
+    actor_uid,
+    action,
+    target_type,
+    target_id,
+    diff,
+    request_id
+) values ($1, $2, $3, $4, $5, $6, $7, $8);
+
+-- name: ListAuditEvents :many
+select * from audit_events
+where
+  community_id = sqlc.arg('community_id')::uuid
+  and (sqlc.narg('action')::text is null or action = sqlc.narg('action')::text)
+  and (sqlc.narg('actor_uid')::uuid is null or actor_uid = sqlc.narg('actor_uid')::uuid)
+  and (sqlc.narg('target_id')::uuid is null or target_id = sqlc.narg('target_id')::uuid)
+  and (sqlc.narg('created_from')::timestamptz is null or created_at >= sqlc.narg('created_from')::timestamptz)
+  and (sqlc.narg('created_to')::timestamptz is null or created_at < sqlc.narg('created_to')::timestamptz)
+  and (
+    sqlc.narg('cursor_id')::uuid is null or
+    (created_at, id) < (sqlc.narg('cursor_created_at')::timestamptz, sqlc.narg('cursor_id')::uuid)
+  )
+order by created_at desc, id desc
+limit sqlc.arg('page_size');
+
+-- name: CountAuditEvents :one
+select count(*) from audit_events
+where
+  community_id = sqlc.arg('community_id')::uuid
+  and (sqlc.narg('action')::text is null or action = sqlc.narg('action')::text)
+  and (sqlc.narg('actor_uid')::uuid is null or actor_uid = sqlc.narg('actor_uid')::uuid)
+  and (sqlc.narg('target_id')::uuid is null or target_id = sqlc.narg('target_id')::uuid)
+  and (sqlc.narg('created_from')::timestamptz is null or created_at >= sqlc.narg('created_from')::timestamptz)
+  and (sqlc.narg('created_to')::timestamptz is null or created_at < sqlc.narg('created_to')::timestamptz);
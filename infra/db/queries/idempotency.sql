@@ -0,0 +1,38 @@
+-- name: ClaimIdempotencyKey :one
+-- Claims the key for a new request. An existing row is taken over only once
+-- it has expired, or when it is still pending past the lease, which means the
+-- request that claimed it never finished. created_at identifies the claim; no
+-- row is returned when the key is held by another.
+insert into idempotency_keys as k (key, request_hash, expires_at)
+values (sqlc.arg('key'), sqlc.arg('request_hash'), now() + make_interval(secs => sqlc.arg('ttl_seconds')::float8))
+on conflict (key) do update set
+  request_hash = excluded.request_hash,
+  status = 'pending',
+  response_status = null,
+  response_content_type = null,
+  response_body = null,
+  created_at = now(),
+  expires_at = excluded.expires_at
+where k.expires_at <= now()
+   or (k.status = 'pending' and k.created_at <= now() - make_interval(secs => sqlc.arg('lease_seconds')::float8))
+returning created_at;
+
+-- name: FindIdempotencyKey :one
+select * from idempotency_keys
+where key = $1;
+
+-- name: CompleteIdempotencyKey :exec
+update idempotency_keys set
+  status = 'completed',
+  response_status = sqlc.arg('response_status'),
+  response_content_type = sqlc.arg('response_content_type'),
+  response_body = sqlc.arg('response_body')
+where key = sqlc.arg('key') and status = 'pending' and created_at = sqlc.arg('claimed_at');
+
+-- name: ReleaseIdempotencyKey :exec
+delete from idempotency_keys
+where key = sqlc.arg('key') and status = 'pending' and created_at = sqlc.arg('claimed_at');
+
+-- name: PurgeIdempotencyKeys :execrows
+delete from idempotency_keys
+where expires_at <= now();
@@ -0,0 +1,34 @@
+-- name: InsertLocalAccount :exec
+insert into local_accounts (
+    uid,
+    email,
+    phone,
+    password_hash,
+    claims
+) values ($1, $2, $3, $4, $5);
+
+-- name: UpdateLocalAccount :execrows
+update local_accounts
+set
+  email = sqlc.narg('email'),
+  phone = sqlc.narg('phone'),
+  password_hash = sqlc.arg('password_hash'),
+  claims = sqlc.arg('claims'),
+  disabled = sqlc.arg('disabled'),
+  valid_after = sqlc.narg('valid_after'),
+  updated_at = current_timestamp
+where uid = sqlc.arg('uid');
+
+-- name: DeleteLocalAccount :execrows
+delete from local_accounts
+where uid = $1;
+
+-- name: FindLocalAccountByUID :one
+select * from local_accounts
+where uid = $1;
+
+-- name: FindLocalAccountByLogin :one
+select * from local_accounts
+where
+  email = sqlc.arg('login')::text or
+  phone = sqlc.arg('login')::text;
@@ -0,0 +1,84 @@
+-- name: InsertOutboxEvent :one
+insert into outbox_events (
+    id,
+    community_id,
+    aggregate_id,
+    event_type,
+    payload,
+    next_attempt_at
+) values (
+    $1, $2, $3, $4, $5,
+    current_timestamp + make_interval(secs => sqlc.arg('delay_seconds')::float8)
+)
+returning *;
+
+-- name: ClaimOutboxEvents :many
+update outbox_events
+set
+  next_attempt_at = current_timestamp + make_interval(secs => sqlc.arg('lease_seconds')::float8),
+  updated_at = current_timestamp
+where id in (
+  select o.id from outbox_events o
+  where
+    o.status = 'pending' and
+    o.next_attempt_at <= current_timestamp
+  order by o.next_attempt_at
+  limit sqlc.arg('batch_size')::int
+  for update skip locked
+)
+returning *;
+
+-- name: MarkOutboxEventDelivered :exec
+update outbox_events
+set
+  status = 'delivered',
+  payload = payload - array['password', 'sealed_password'],
+  attempts = attempts + 1,
+  last_error = null,
+  delivered_at = current_timestamp,
+  updated_at = current_timestamp
+where id = $1;
+
+-- MarkOutboxEventFailed drops the password of dead events, a replay then
+-- creates the account without one.
+-- name: MarkOutboxEventFailed :exec
+update outbox_events
+set
+  status = sqlc.arg('status')::text,
+  payload = case
+    when sqlc.arg('status')::text = 'dead' then payload - array['password', 'sealed_password']
+    else payload
+  end,
+  attempts = attempts + 1,
+  last_error = sqlc.arg('last_error')::text,
+  next_attempt_at = current_timestamp + make_interval(secs => sqlc.arg('retry_after_seconds')::float8),
+  updated_at = current_timestamp
+where id = sqlc.arg('id')::uuid;
+
+-- name: DeleteOutboxEvent :exec
+delete from outbox_events
+where id = $1;
+
+-- name: FindStuckOutboxEvents :many
+select * from outbox_events
+where
+  community_id = sqlc.arg('community_id')::uuid and
+  (
+    status = 'dead' or
+    (status = 'pending' and attempts > 0)
+  )
+order by created_at desc;
+
+-- name: ReplayOutboxEvent :one
+update outbox_events
+set
+  status = 'pending',
+  attempts = 0,
+  last_error = null,
+  next_attempt_at = current_timestamp,
+  updated_at = current_timestamp
+where
+  id = sqlc.arg('id')::uuid and
+  community_id = sqlc.arg('community_id')::uuid and
+  status <> 'delivered'
+returning *;
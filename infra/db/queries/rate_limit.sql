@@ -0,0 +1,24 @@
+-- name: TakeRateLimitToken :one
+-- Refills the bucket for the time elapsed since its last use, then takes one
+-- token when available. Runs as a single statement so concurrent instances
+-- cannot both take the last token. An untouched bucket is full again after
+-- refill_seconds, and may be purged from then on.
+insert into rate_limit_buckets as b (key, tokens, allowed, updated_at, expires_at)
+values (
+  sqlc.arg('key'), sqlc.arg('burst')::float8 - 1, true, now(),
+  now() + make_interval(secs => sqlc.arg('refill_seconds')::float8)
+)
+on conflict (key) do update set
+  tokens = case
+    when least(sqlc.arg('burst')::float8, b.tokens + extract(epoch from now() - b.updated_at)::float8 * sqlc.arg('rate')::float8) >= 1
+    then least(sqlc.arg('burst')::float8, b.tokens + extract(epoch from now() - b.updated_at)::float8 * sqlc.arg('rate')::float8) - 1
+    else least(sqlc.arg('burst')::float8, b.tokens + extract(epoch from now() - b.updated_at)::float8 * sqlc.arg('rate')::float8)
+  end,
+  allowed = least(sqlc.arg('burst')::float8, b.tokens + extract(epoch from now() - b.updated_at)::float8 * sqlc.arg('rate')::float8) >= 1,
+  updated_at = now(),
+  expires_at = excluded.expires_at
+returning tokens, allowed;
+
+-- name: PurgeRateLimitBuckets :execrows
+delete from rate_limit_buckets
+where expires_at < now();
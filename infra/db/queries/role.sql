@@ -0,0 +1,70 @@
+-- name: FindPermissions :many
+select * from permissions
+order by name;
+
+-- name: FindRolePermissions :many
+select rp.permission
+from role_permissions rp
+join roles r on r.id = rp.role_id
+where
+  r.name = sqlc.arg('name') and
+  (r.community_id is null or r.community_id = sqlc.arg('community_id'))
+order by rp.permission;
+
+-- name: IsRoleAssignable :one
+select exists (
+  select 1 from roles
+  where
+    name = sqlc.arg('name') and
+    (community_id is null or community_id = sqlc.arg('community_id'))
+);
+
+-- name: FindRolesByCommunityID :many
+select * from roles
+where community_id is null or community_id = $1
+order by community_id nulls first, name;
+
+-- name: FindRolePermissionsByCommunityID :many
+select rp.role_id, rp.permission
+from role_permissions rp
+join roles r on r.id = rp.role_id
+where r.community_id is null or r.community_id = $1
+order by rp.permission;
+
+-- name: FindRoleByID :one
+select * from roles
+where id = $1 and community_id = $2;
+
+-- name: InsertRole :one
+insert into roles (
+    community_id,
+    name,
+    description
+) values ($1, $2, $3)
+returning *;
+
+-- name: UpdateRole :exec
+update roles
+set
+  description = coalesce(sqlc.narg('description'), description),
+  updated_at = current_timestamp
+where id = sqlc.arg('id');
+
+-- name: InsertRolePermissions :exec
+insert into role_permissions (role_id, permission)
+select sqlc.arg('role_id')::uuid, unnest(sqlc.arg('permissions')::varchar[]);
+
+-- name: DeleteRolePermissions :exec
+delete from role_permissions
+where role_id = $1;
+
+-- name: DeleteRole :exec
+delete from roles
+where id = $1;
+
+-- Soft-deleted users keep their role in use so they can be restored.
+-- name: IsRoleInUse :one
+select exists (
+  select 1 from users
+  where community_id = $1 and role = $2
+);
@@ -10,6 +10,8 @@ insert into users (
 ) values ($1, $2, $3, $4, $5, $6, $7)
 returning id;
 
+-- UpdateUser only applies to a user not deleted, at one of the given
+-- versions, or at any version when they are null.
 -- name: UpdateUser :one
 update users
 set
@@ -20,12 +22,45 @@ set
   role = coalesce(sqlc.narg('role')::text, role)
 where
   id = sqlc.arg('id')::uuid
-returning id;
+  and deleted_at is null
+  and (sqlc.narg('versions')::bigint[] is null or version = any(sqlc.narg('versions')::bigint[]))
+returning id, version;
 
 -- name: DeleteUser :exec
 delete from users
 where id = $1;
 
+-- SoftDeleteUser also revokes every token issued to the user. Like
+-- UpdateUser, it only applies at one of the given versions unless they are
+-- null.
+-- name: SoftDeleteUser :execrows
+update users
+set
+  deleted_at = current_timestamp,
+  tokens_valid_after = current_timestamp
+where
+  id = sqlc.arg('id')
+  and deleted_at is null
+  and (sqlc.narg('versions')::bigint[] is null or version = any(sqlc.narg('versions')::bigint[]));
+
+-- name: RestoreUser :execrows
+update users
+set deleted_at = null
+where id = $1 and deleted_at is not null;
+
+-- PurgeDeletedUsers removes a batch of users deleted before the given time.
+-- Locked rows are skipped so several instances can purge at once.
+-- name: PurgeDeletedUsers :many
+delete from users
+where id in (
+  select id from users
+  where deleted_at < sqlc.arg('deleted_before')::timestamptz
+  order by deleted_at
+  limit sqlc.arg('batch_size')
+  for update skip locked
+)
+returning id, community_id;
+
 -- name: InsertCommunity :one
 insert into communities (
     id,
@@ -63,17 +98,15 @@ where
   (
     sqlc.narg('community_id')::uuid is null or
     u.community_id = sqlc.narg('community_id')::uuid
+  )
+  and
+  (
+    u.deleted_at is null or
+    coalesce(sqlc.narg('with_deleted')::bool, false)
   );
 
--- name: FindUserByCommunityID :many
-select
-  u.*,
-  c.*
-from users u
-inner join communities c on c.id = u.community_id
-where
-  u.community_id = $1;
-
+-- Soft-deleted users keep their email and phone until they are purged: their
+-- identity account still holds them and a restore must not collide.
 -- name: IsEmailExists :one
 select exists(
   select 1 from users where email = $1
@@ -83,3 +116,145 @@ select exists(
 select exists(
   select 1 from users where phone = $1
 );
+
+-- name: DeleteCommunity :exec
+delete from communities
+where id = $1;
+
+-- name: RevokeUserTokens :exec
+update users
+set tokens_valid_after = current_timestamp
+where id = $1;
+
+-- name: FindUserAccess :one
+select role, community_id, tokens_valid_after from users
+where id = $1 and deleted_at is null;
+
+-- The ListCommunityUsers* queries page through a community with a keyset on
+-- (sort column, id), served by the users_community_*_idx indexes. They share
+-- their filters with CountCommunityUsers.
+
+-- name: ListCommunityUsersByCreatedAt :many
+select
+  u.*,
+  c.*
+from users u
+inner join communities c on c.id = u.community_id
+where
+  u.community_id = sqlc.arg('community_id')::uuid
+  and u.deleted_at is null
+  and (sqlc.narg('role')::text is null or u.role = sqlc.narg('role')::text)
+  and (sqlc.narg('address')::text is null or u.address ilike '%' || sqlc.narg('address')::text || '%')
+  and (sqlc.narg('created_from')::timestamp is null or u.created_at >= sqlc.narg('created_from')::timestamp)
+  and (sqlc.narg('created_to')::timestamp is null or u.created_at < sqlc.narg('created_to')::timestamp)
+  and (
+    sqlc.narg('cursor_id')::uuid is null or
+    (u.created_at, u.id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+  )
+order by u.created_at asc, u.id asc
+limit sqlc.arg('page_size');
+
+-- name: ListCommunityUsersByCreatedAtDesc :many
+select
+  u.*,
+  c.*
+from users u
+inner join communities c on c.id = u.community_id
+where
+  u.community_id = sqlc.arg('community_id')::uuid
+  and u.deleted_at is null
+  and (sqlc.narg('role')::text is null or u.role = sqlc.narg('role')::text)
+  and (sqlc.narg('address')::text is null or u.address ilike '%' || sqlc.narg('address')::text || '%')
+  and (sqlc.narg('created_from')::timestamp is null or u.created_at >= sqlc.narg('created_from')::timestamp)
+  and (sqlc.narg('created_to')::timestamp is null or u.created_at < sqlc.narg('created_to')::timestamp)
+  and (
+    sqlc.narg('cursor_id')::uuid is null or
+    (u.created_at, u.id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+  )
+order by u.created_at desc, u.id desc
+limit sqlc.arg('page_size');
+
+-- name: ListCommunityUsersByFullname :many
+select
+  u.*,
+  c.*
+from users u
+inner join communities c on c.id = u.community_id
+where
+  u.community_id = sqlc.arg('community_id')::uuid
+  and u.deleted_at is null
+  and (sqlc.narg('role')::text is null or u.role = sqlc.narg('role')::text)
+  and (sqlc.narg('address')::text is null or u.address ilike '%' || sqlc.narg('address')::text || '%')
+  and (sqlc.narg('created_from')::timestamp is null or u.created_at >= sqlc.narg('created_from')::timestamp)
+  and (sqlc.narg('created_to')::timestamp is null or u.created_at < sqlc.narg('created_to')::timestamp)
+  and (
+    sqlc.narg('cursor_id')::uuid is null or
+    (u.fullname, u.id) > (sqlc.narg('cursor_fullname')::text, sqlc.narg('cursor_id')::uuid)
+  )
+order by u.fullname asc, u.id asc
+limit sqlc.arg('page_size');
+
+-- name: ListCommunityUsersByFullnameDesc :many
+select
+  u.*,
+  c.*
+from users u
+inner join communities c on c.id = u.community_id
+where
+  u.community_id = sqlc.arg('community_id')::uuid
+  and u.deleted_at is null
+  and (sqlc.narg('role')::text is null or u.role = sqlc.narg('role')::text)
+  and (sqlc.narg('address')::text is null or u.address ilike '%' || sqlc.narg('address')::text || '%')
+  and (sqlc.narg('created_from')::timestamp is null or u.created_at >= sqlc.narg('created_from')::timestamp)
+  and (sqlc.narg('created_to')::timestamp is null or u.created_at < sqlc.narg('created_to')::timestamp)
+  and (
+    sqlc.narg('cursor_id')::uuid is null or
+    (u.fullname, u.id) < (sqlc.narg('cursor_fullname')::text, sqlc.narg('cursor_id')::uuid)
+  )
+order by u.fullname desc, u.id desc
+limit sqlc.arg('page_size');
+
+-- name: CountCommunityUsers :one
+select count(*) from users u
+where
+  u.community_id = sqlc.arg('community_id')::uuid
+  and u.deleted_at is null
+  and (sqlc.narg('role')::text is null or u.role = sqlc.narg('role')::text)
+  and (sqlc.narg('address')::text is null or u.address ilike '%' || sqlc.narg('address')::text || '%')
+  and (sqlc.narg('created_from')::timestamp is null or u.created_at >= sqlc.narg('created_from')::timestamp)
+  and (sqlc.narg('created_to')::timestamp is null or u.created_at < sqlc.narg('created_to')::timestamp);
+
+-- SearchCommunityUsers ranks full-text matches on name and address together
+-- with trigram similarity, so partial and misspelled terms still match.
+-- Highlights wrap matched words in the start_sel and stop_sel markers.
+-- name: SearchCommunityUsers :many
+select
+  u.*,
+  c.*,
+  (
+    ts_rank(users_search_document(u.fullname, u.address), to_tsquery('simple', sqlc.arg('tsquery')::text)) +
+    greatest(
+      word_similarity(sqlc.arg('term')::text, u.fullname),
+      word_similarity(sqlc.arg('term')::text, coalesce(u.address, '')),
+      similarity(sqlc.arg('term')::text, coalesce(u.phone, ''))
+    )
+  )::float8 as rank,
+  ts_headline('simple', u.fullname, to_tsquery('simple', sqlc.arg('tsquery')::text),
+    'HighlightAll=true, StartSel=' || sqlc.arg('start_sel')::text || ', StopSel=' || sqlc.arg('stop_sel')::text
+  )::text as fullname_highlight,
+  ts_headline('simple', coalesce(u.address, ''), to_tsquery('simple', sqlc.arg('tsquery')::text),
+    'HighlightAll=true, StartSel=' || sqlc.arg('start_sel')::text || ', StopSel=' || sqlc.arg('stop_sel')::text
+  )::text as address_highlight
+from users u
+inner join communities c on c.id = u.community_id
+where
+  u.community_id = sqlc.arg('community_id')::uuid
+  and u.deleted_at is null
+  and (
+    users_search_document(u.fullname, u.address) @@ to_tsquery('simple', sqlc.arg('tsquery')::text)
+    or sqlc.arg('term')::text <% u.fullname
+    or sqlc.arg('term')::text <% u.address
+    or (sqlc.narg('phone')::text is not null and u.phone like '%' || sqlc.narg('phone')::text || '%')
+  )
+order by rank desc, u.id
+limit sqlc.arg('page_size');
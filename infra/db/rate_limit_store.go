@@ -0,0 +1,37 @@
+package db
+
+import (
+	"context"
+
+	database "github.com/dvvnFrtn/capstone-backend/infra/db/sqlc"
+	"github.com/dvvnFrtn/capstone-backend/pkg/ratelimit"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type RateLimitStore struct {
+	pool *pgxpool.Pool
+}
+
+// NewRateLimitStore shares the rate limit buckets between every instance
+// using the database.
+func NewRateLimitStore(pool *pgxpool.Pool) *RateLimitStore {
+	return &RateLimitStore{pool: pool}
+}
+
+func (s *RateLimitStore) Take(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Decision, error) {
+	row, err := database.New(s.pool).TakeRateLimitToken(ctx, database.TakeRateLimitTokenParams{
+		Key:           key,
+		Burst:         limit.Capacity(),
+		Rate:          limit.Rate(),
+		RefillSeconds: limit.Refill().Seconds(),
+	})
+	if err != nil {
+		return ratelimit.Decision{}, err
+	}
+	return ratelimit.Decide(row.Tokens, row.Allowed, limit), nil
+}
+
+// Purge deletes buckets that have refilled since their last use.
+func (s *RateLimitStore) Purge(ctx context.Context) (int64, error) {
+	return database.New(s.pool).PurgeRateLimitBuckets(ctx)
+}
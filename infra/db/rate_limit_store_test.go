@@ -0,0 +1,46 @@
+package db_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dvvnFrtn/capstone-backend/infra/db"
+	"github.com/dvvnFrtn/capstone-backend/pkg/ratelimit"
+	"github.com/dvvnFrtn/capstone-backend/pkg/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimitStore_TokenBucket(t *testing.T) {
+	ctx := context.Background()
+	store := db.NewRateLimitStore(testutil.NewTestPool(t))
+	limit := ratelimit.Limit{Requests: 2, Per: time.Hour}
+
+	for range 2 {
+		decision, err := store.Take(ctx, "signup:ip:10.0.0.1", limit)
+		require.NoError(t, err)
+		assert.True(t, decision.Allowed)
+	}
+
+	decision, err := store.Take(ctx, "signup:ip:10.0.0.1", limit)
+	require.NoError(t, err)
+	assert.False(t, decision.Allowed)
+	assert.InDelta(t, 30*time.Minute, decision.RetryAfter, float64(time.Minute))
+
+	decision, err = store.Take(ctx, "signup:ip:10.0.0.2", limit)
+	require.NoError(t, err)
+	assert.True(t, decision.Allowed)
+
+	purged, err := store.Purge(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, purged, "buckets are kept until they have refilled")
+
+	_, err = store.Take(ctx, "user:uid-1", ratelimit.Limit{Requests: 1, Per: 50 * time.Millisecond})
+	require.NoError(t, err)
+	time.Sleep(100 * time.Millisecond)
+
+	purged, err = store.Purge(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+}
@@ -0,0 +1,154 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: audit.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const countAuditEvents = `-- name: CountAuditEvents :one
+select count(*) from audit_events
+where
+  community_id = $1::uuid
+  and ($2::text is null or action = $2::text)
+  and ($3::uuid is null or actor_uid = $3::uuid)
+  and ($4::uuid is null or target_id = $4::uuid)
+  and ($5::timestamptz is null or created_at >= $5::timestamptz)
+  and ($6::timestamptz is null or created_at < $6::timestamptz)
+`
+
+type CountAuditEventsParams struct {
+	CommunityID uuid.UUID          `json:"community_id"`
+	Action      pgtype.Text        `json:"action"`
+	ActorUid    pgtype.UUID        `json:"actor_uid"`
+	TargetID    pgtype.UUID        `json:"target_id"`
+	CreatedFrom pgtype.Timestamptz `json:"created_from"`
+	CreatedTo   pgtype.Timestamptz `json:"created_to"`
+}
+
+func (q *Queries) CountAuditEvents(ctx context.Context, arg CountAuditEventsParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countAuditEvents,
+		arg.CommunityID,
+		arg.Action,
+		arg.ActorUid,
+		arg.TargetID,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const insertAuditEvent = `-- name: InsertAuditEvent :exec
+insert into audit_events (
+    id,
+    community_id,
+    actor_uid,
+    action,
+    target_type,
+    target_id,
+    diff,
+    request_id
+) values ($1, $2, $3, $4, $5, $6, $7, $8)
+`
+
+type InsertAuditEventParams struct {
+	ID          uuid.UUID   `json:"id"`
+	CommunityID uuid.UUID   `json:"community_id"`
+	ActorUid    pgtype.UUID `json:"actor_uid"`
+	Action      string      `json:"action"`
+	TargetType  string      `json:"target_type"`
+	TargetID    uuid.UUID   `json:"target_id"`
+	Diff        []byte      `json:"diff"`
+	RequestID   pgtype.Text `json:"request_id"`
+}
+
+func (q *Queries) InsertAuditEvent(ctx context.Context, arg InsertAuditEventParams) error {
+	_, err := q.db.Exec(ctx, insertAuditEvent,
+		arg.ID,
+		arg.CommunityID,
+		arg.ActorUid,
+		arg.Action,
+		arg.TargetType,
+		arg.TargetID,
+		arg.Diff,
+		arg.RequestID,
+	)
+	return err
+}
+
+const listAuditEvents = `-- name: ListAuditEvents :many
+select id, community_id, actor_uid, action, target_type, target_id, diff, request_id, created_at from audit_events
+where
+  community_id = $1::uuid
+  and ($2::text is null or action = $2::text)
+  and ($3::uuid is null or actor_uid = $3::uuid)
+  and ($4::uuid is null or target_id = $4::uuid)
+  and ($5::timestamptz is null or created_at >= $5::timestamptz)
+  and ($6::timestamptz is null or created_at < $6::timestamptz)
+  and (
+    $7::uuid is null or
+    (created_at, id) < ($8::timestamptz, $7::uuid)
+  )
+order by created_at desc, id desc
+limit $9
+`
+
+type ListAuditEventsParams struct {
+	CommunityID     uuid.UUID          `json:"community_id"`
+	Action          pgtype.Text        `json:"action"`
+	ActorUid        pgtype.UUID        `json:"actor_uid"`
+	TargetID        pgtype.UUID        `json:"target_id"`
+	CreatedFrom     pgtype.Timestamptz `json:"created_from"`
+	CreatedTo       pgtype.Timestamptz `json:"created_to"`
+	CursorID        pgtype.UUID        `json:"cursor_id"`
+	CursorCreatedAt pgtype.Timestamptz `json:"cursor_created_at"`
+	PageSize        int32              `json:"page_size"`
+}
+
+func (q *Queries) ListAuditEvents(ctx context.Context, arg ListAuditEventsParams) ([]AuditEvent, error) {
+	rows, err := q.db.Query(ctx, listAuditEvents,
+		arg.CommunityID,
+		arg.Action,
+		arg.ActorUid,
+		arg.TargetID,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.CursorID,
+		arg.CursorCreatedAt,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AuditEvent
+	for rows.Next() {
+		var i AuditEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.CommunityID,
+			&i.ActorUid,
+			&i.Action,
+			&i.TargetType,
+			&i.TargetID,
+			&i.Diff,
+			&i.RequestID,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,128 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: idempotency.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const claimIdempotencyKey = `-- name: ClaimIdempotencyKey :one
+insert into idempotency_keys as k (key, request_hash, expires_at)
+values ($1, $2, now() + make_interval(secs => $3::float8))
+on conflict (key) do update set
+  request_hash = excluded.request_hash,
+  status = 'pending',
+  response_status = null,
+  response_content_type = null,
+  response_body = null,
+  created_at = now(),
+  expires_at = excluded.expires_at
+where k.expires_at <= now()
+   or (k.status = 'pending' and k.created_at <= now() - make_interval(secs => $4::float8))
+returning created_at
+`
+
+type ClaimIdempotencyKeyParams struct {
+	Key          string  `json:"key"`
+	RequestHash  string  `json:"request_hash"`
+	TtlSeconds   float64 `json:"ttl_seconds"`
+	LeaseSeconds float64 `json:"lease_seconds"`
+}
+
+// Claims the key for a new request. An existing row is taken over only once
+// it has expired, or when it is still pending past the lease, which means the
+// request that claimed it never finished. created_at identifies the claim; no
+// row is returned when the key is held by another.
+func (q *Queries) ClaimIdempotencyKey(ctx context.Context, arg ClaimIdempotencyKeyParams) (pgtype.Timestamptz, error) {
+	row := q.db.QueryRow(ctx, claimIdempotencyKey,
+		arg.Key,
+		arg.RequestHash,
+		arg.TtlSeconds,
+		arg.LeaseSeconds,
+	)
+	var created_at pgtype.Timestamptz
+	err := row.Scan(&created_at)
+	return created_at, err
+}
+
+const completeIdempotencyKey = `-- name: CompleteIdempotencyKey :exec
+update idempotency_keys set
+  status = 'completed',
+  response_status = $1,
+  response_content_type = $2,
+  response_body = $3
+where key = $4 and status = 'pending' and created_at = $5
+`
+
+type CompleteIdempotencyKeyParams struct {
+	ResponseStatus      pgtype.Int4        `json:"response_status"`
+	ResponseContentType pgtype.Text        `json:"response_content_type"`
+	ResponseBody        []byte             `json:"response_body"`
+	Key                 string             `json:"key"`
+	ClaimedAt           pgtype.Timestamptz `json:"claimed_at"`
+}
+
+func (q *Queries) CompleteIdempotencyKey(ctx context.Context, arg CompleteIdempotencyKeyParams) error {
+	_, err := q.db.Exec(ctx, completeIdempotencyKey,
+		arg.ResponseStatus,
+		arg.ResponseContentType,
+		arg.ResponseBody,
+		arg.Key,
+		arg.ClaimedAt,
+	)
+	return err
+}
+
+const findIdempotencyKey = `-- name: FindIdempotencyKey :one
+select key, request_hash, status, response_status, response_content_type, response_body, created_at, expires_at from idempotency_keys
+where key = $1
+`
+
+func (q *Queries) FindIdempotencyKey(ctx context.Context, key string) (IdempotencyKey, error) {
+	row := q.db.QueryRow(ctx, findIdempotencyKey, key)
+	var i IdempotencyKey
+	err := row.Scan(
+		&i.Key,
+		&i.RequestHash,
+		&i.Status,
+		&i.ResponseStatus,
+		&i.ResponseContentType,
+		&i.ResponseBody,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const purgeIdempotencyKeys = `-- name: PurgeIdempotencyKeys :execrows
+delete from idempotency_keys
+where expires_at <= now()
+`
+
+func (q *Queries) PurgeIdempotencyKeys(ctx context.Context) (int64, error) {
+	result, err := q.db.Exec(ctx, purgeIdempotencyKeys)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const releaseIdempotencyKey = `-- name: ReleaseIdempotencyKey :exec
+delete from idempotency_keys
+where key = $1 and status = 'pending' and created_at = $2
+`
+
+type ReleaseIdempotencyKeyParams struct {
+	Key       string             `json:"key"`
+	ClaimedAt pgtype.Timestamptz `json:"claimed_at"`
+}
+
+func (q *Queries) ReleaseIdempotencyKey(ctx context.Context, arg ReleaseIdempotencyKeyParams) error {
+	_, err := q.db.Exec(ctx, releaseIdempotencyKey, arg.Key, arg.ClaimedAt)
+	return err
+}
@@ -0,0 +1,139 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: local_account.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const deleteLocalAccount = `-- name: DeleteLocalAccount :execrows
+delete from local_accounts
+where uid = $1
+`
+
+func (q *Queries) DeleteLocalAccount(ctx context.Context, uid string) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteLocalAccount, uid)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const findLocalAccountByLogin = `-- name: FindLocalAccountByLogin :one
+select uid, email, phone, password_hash, claims, disabled, created_at, updated_at, valid_after from local_accounts
+where
+  email = $1::text or
+  phone = $1::text
+`
+
+func (q *Queries) FindLocalAccountByLogin(ctx context.Context, login string) (LocalAccount, error) {
+	row := q.db.QueryRow(ctx, findLocalAccountByLogin, login)
+	var i LocalAccount
+	err := row.Scan(
+		&i.Uid,
+		&i.Email,
+		&i.Phone,
+		&i.PasswordHash,
+		&i.Claims,
+		&i.Disabled,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ValidAfter,
+	)
+	return i, err
+}
+
+const findLocalAccountByUID = `-- name: FindLocalAccountByUID :one
+select uid, email, phone, password_hash, claims, disabled, created_at, updated_at, valid_after from local_accounts
+where uid = $1
+`
+
+func (q *Queries) FindLocalAccountByUID(ctx context.Context, uid string) (LocalAccount, error) {
+	row := q.db.QueryRow(ctx, findLocalAccountByUID, uid)
+	var i LocalAccount
+	err := row.Scan(
+		&i.Uid,
+		&i.Email,
+		&i.Phone,
+		&i.PasswordHash,
+		&i.Claims,
+		&i.Disabled,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ValidAfter,
+	)
+	return i, err
+}
+
+const insertLocalAccount = `-- name: InsertLocalAccount :exec
+insert into local_accounts (
+    uid,
+    email,
+    phone,
+    password_hash,
+    claims
+) values ($1, $2, $3, $4, $5)
+`
+
+type InsertLocalAccountParams struct {
+	Uid          string      `json:"uid"`
+	Email        pgtype.Text `json:"email"`
+	Phone        pgtype.Text `json:"phone"`
+	PasswordHash []byte      `json:"password_hash"`
+	Claims       []byte      `json:"claims"`
+}
+
+func (q *Queries) InsertLocalAccount(ctx context.Context, arg InsertLocalAccountParams) error {
+	_, err := q.db.Exec(ctx, insertLocalAccount,
+		arg.Uid,
+		arg.Email,
+		arg.Phone,
+		arg.PasswordHash,
+		arg.Claims,
+	)
+	return err
+}
+
+const updateLocalAccount = `-- name: UpdateLocalAccount :execrows
+update local_accounts
+set
+  email = $1,
+  phone = $2,
+  password_hash = $3,
+  claims = $4,
+  disabled = $5,
+  valid_after = $6,
+  updated_at = current_timestamp
+where uid = $7
+`
+
+type UpdateLocalAccountParams struct {
+	Email        pgtype.Text        `json:"email"`
+	Phone        pgtype.Text        `json:"phone"`
+	PasswordHash []byte             `json:"password_hash"`
+	Claims       []byte             `json:"claims"`
+	Disabled     bool               `json:"disabled"`
+	ValidAfter   pgtype.Timestamptz `json:"valid_after"`
+	Uid          string             `json:"uid"`
+}
+
+func (q *Queries) UpdateLocalAccount(ctx context.Context, arg UpdateLocalAccountParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateLocalAccount,
+		arg.Email,
+		arg.Phone,
+		arg.PasswordHash,
+		arg.Claims,
+		arg.Disabled,
+		arg.ValidAfter,
+		arg.Uid,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
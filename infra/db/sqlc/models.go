@@ -9,6 +9,18 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type AuditEvent struct {
+	ID          uuid.UUID          `json:"id"`
+	CommunityID uuid.UUID          `json:"community_id"`
+	ActorUid    pgtype.UUID        `json:"actor_uid"`
+	Action      string             `json:"action"`
+	TargetType  string             `json:"target_type"`
+	TargetID    uuid.UUID          `json:"target_id"`
+	Diff        []byte             `json:"diff"`
+	RequestID   pgtype.Text        `json:"request_id"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+}
+
 type Community struct {
 	ID          uuid.UUID        `json:"id"`
 	RtNumber    int32            `json:"rt_number"`
@@ -21,14 +33,82 @@ type Community struct {
 	UpdatedAt   pgtype.Timestamp `json:"updated_at"`
 }
 
-type User struct {
+type IdempotencyKey struct {
+	Key                 string             `json:"key"`
+	RequestHash         string             `json:"request_hash"`
+	Status              string             `json:"status"`
+	ResponseStatus      pgtype.Int4        `json:"response_status"`
+	ResponseContentType pgtype.Text        `json:"response_content_type"`
+	ResponseBody        []byte             `json:"response_body"`
+	CreatedAt           pgtype.Timestamptz `json:"created_at"`
+	ExpiresAt           pgtype.Timestamptz `json:"expires_at"`
+}
+
+type LocalAccount struct {
+	Uid          string             `json:"uid"`
+	Email        pgtype.Text        `json:"email"`
+	Phone        pgtype.Text        `json:"phone"`
+	PasswordHash []byte             `json:"password_hash"`
+	Claims       []byte             `json:"claims"`
+	Disabled     bool               `json:"disabled"`
+	CreatedAt    pgtype.Timestamp   `json:"created_at"`
+	UpdatedAt    pgtype.Timestamp   `json:"updated_at"`
+	ValidAfter   pgtype.Timestamptz `json:"valid_after"`
+}
+
+type OutboxEvent struct {
+	ID            uuid.UUID        `json:"id"`
+	CommunityID   uuid.UUID        `json:"community_id"`
+	AggregateID   uuid.UUID        `json:"aggregate_id"`
+	EventType     string           `json:"event_type"`
+	Payload       []byte           `json:"payload"`
+	Status        string           `json:"status"`
+	Attempts      int32            `json:"attempts"`
+	LastError     pgtype.Text      `json:"last_error"`
+	NextAttemptAt pgtype.Timestamp `json:"next_attempt_at"`
+	DeliveredAt   pgtype.Timestamp `json:"delivered_at"`
+	CreatedAt     pgtype.Timestamp `json:"created_at"`
+	UpdatedAt     pgtype.Timestamp `json:"updated_at"`
+}
+
+type Permission struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type RateLimitBucket struct {
+	Key       string             `json:"key"`
+	Tokens    float64            `json:"tokens"`
+	Allowed   bool               `json:"allowed"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+}
+
+type Role struct {
 	ID          uuid.UUID        `json:"id"`
-	Fullname    string           `json:"fullname"`
-	Email       pgtype.Text      `json:"email"`
-	Phone       pgtype.Text      `json:"phone"`
-	Address     pgtype.Text      `json:"address"`
-	Role        string           `json:"role"`
+	CommunityID pgtype.UUID      `json:"community_id"`
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
 	CreatedAt   pgtype.Timestamp `json:"created_at"`
 	UpdatedAt   pgtype.Timestamp `json:"updated_at"`
-	CommunityID uuid.UUID        `json:"community_id"`
+}
+
+type RolePermission struct {
+	RoleID     uuid.UUID `json:"role_id"`
+	Permission string    `json:"permission"`
+}
+
+type User struct {
+	ID               uuid.UUID          `json:"id"`
+	Fullname         string             `json:"fullname"`
+	Email            pgtype.Text        `json:"email"`
+	Phone            pgtype.Text        `json:"phone"`
+	Address          pgtype.Text        `json:"address"`
+	Role             string             `json:"role"`
+	CreatedAt        pgtype.Timestamp   `json:"created_at"`
+	UpdatedAt        pgtype.Timestamp   `json:"updated_at"`
+	CommunityID      uuid.UUID          `json:"community_id"`
+	TokensValidAfter pgtype.Timestamptz `json:"tokens_valid_after"`
+	DeletedAt        pgtype.Timestamptz `json:"deleted_at"`
+	Version          int64              `json:"version"`
 }
@@ -0,0 +1,263 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: outbox.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const claimOutboxEvents = `-- name: ClaimOutboxEvents :many
+update outbox_events
+set
+  next_attempt_at = current_timestamp + make_interval(secs => $1::float8),
+  updated_at = current_timestamp
+where id in (
+  select o.id from outbox_events o
+  where
+    o.status = 'pending' and
+    o.next_attempt_at <= current_timestamp
+  order by o.next_attempt_at
+  limit $2::int
+  for update skip locked
+)
+returning id, community_id, aggregate_id, event_type, payload, status, attempts, last_error, next_attempt_at, delivered_at, created_at, updated_at
+`
+
+type ClaimOutboxEventsParams struct {
+	LeaseSeconds float64 `json:"lease_seconds"`
+	BatchSize    int32   `json:"batch_size"`
+}
+
+func (q *Queries) ClaimOutboxEvents(ctx context.Context, arg ClaimOutboxEventsParams) ([]OutboxEvent, error) {
+	rows, err := q.db.Query(ctx, claimOutboxEvents, arg.LeaseSeconds, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []OutboxEvent
+	for rows.Next() {
+		var i OutboxEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.CommunityID,
+			&i.AggregateID,
+			&i.EventType,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.LastError,
+			&i.NextAttemptAt,
+			&i.DeliveredAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteOutboxEvent = `-- name: DeleteOutboxEvent :exec
+delete from outbox_events
+where id = $1
+`
+
+func (q *Queries) DeleteOutboxEvent(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.Exec(ctx, deleteOutboxEvent, id)
+	return err
+}
+
+const findStuckOutboxEvents = `-- name: FindStuckOutboxEvents :many
+select id, community_id, aggregate_id, event_type, payload, status, attempts, last_error, next_attempt_at, delivered_at, created_at, updated_at from outbox_events
+where
+  community_id = $1::uuid and
+  (
+    status = 'dead' or
+    (status = 'pending' and attempts > 0)
+  )
+order by created_at desc
+`
+
+func (q *Queries) FindStuckOutboxEvents(ctx context.Context, communityID uuid.UUID) ([]OutboxEvent, error) {
+	rows, err := q.db.Query(ctx, findStuckOutboxEvents, communityID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []OutboxEvent
+	for rows.Next() {
+		var i OutboxEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.CommunityID,
+			&i.AggregateID,
+			&i.EventType,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.LastError,
+			&i.NextAttemptAt,
+			&i.DeliveredAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const insertOutboxEvent = `-- name: InsertOutboxEvent :one
+insert into outbox_events (
+    id,
+    community_id,
+    aggregate_id,
+    event_type,
+    payload,
+    next_attempt_at
+) values (
+    $1, $2, $3, $4, $5,
+    current_timestamp + make_interval(secs => $6::float8)
+)
+returning id, community_id, aggregate_id, event_type, payload, status, attempts, last_error, next_attempt_at, delivered_at, created_at, updated_at
+`
+
+type InsertOutboxEventParams struct {
+	ID           uuid.UUID `json:"id"`
+	CommunityID  uuid.UUID `json:"community_id"`
+	AggregateID  uuid.UUID `json:"aggregate_id"`
+	EventType    string    `json:"event_type"`
+	Payload      []byte    `json:"payload"`
+	DelaySeconds float64   `json:"delay_seconds"`
+}
+
+func (q *Queries) InsertOutboxEvent(ctx context.Context, arg InsertOutboxEventParams) (OutboxEvent, error) {
+	row := q.db.QueryRow(ctx, insertOutboxEvent,
+		arg.ID,
+		arg.CommunityID,
+		arg.AggregateID,
+		arg.EventType,
+		arg.Payload,
+		arg.DelaySeconds,
+	)
+	var i OutboxEvent
+	err := row.Scan(
+		&i.ID,
+		&i.CommunityID,
+		&i.AggregateID,
+		&i.EventType,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.LastError,
+		&i.NextAttemptAt,
+		&i.DeliveredAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const markOutboxEventDelivered = `-- name: MarkOutboxEventDelivered :exec
+update outbox_events
+set
+  status = 'delivered',
+  payload = payload - array['password', 'sealed_password'],
+  attempts = attempts + 1,
+  last_error = null,
+  delivered_at = current_timestamp,
+  updated_at = current_timestamp
+where id = $1
+`
+
+func (q *Queries) MarkOutboxEventDelivered(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.Exec(ctx, markOutboxEventDelivered, id)
+	return err
+}
+
+const markOutboxEventFailed = `-- name: MarkOutboxEventFailed :exec
+update outbox_events
+set
+  status = $1::text,
+  payload = case
+    when $1::text = 'dead' then payload - array['password', 'sealed_password']
+    else payload
+  end,
+  attempts = attempts + 1,
+  last_error = $2::text,
+  next_attempt_at = current_timestamp + make_interval(secs => $3::float8),
+  updated_at = current_timestamp
+where id = $4::uuid
+`
+
+type MarkOutboxEventFailedParams struct {
+	Status            string    `json:"status"`
+	LastError         string    `json:"last_error"`
+	RetryAfterSeconds float64   `json:"retry_after_seconds"`
+	ID                uuid.UUID `json:"id"`
+}
+
+// MarkOutboxEventFailed drops the password of dead events, a replay then
+// creates the account without one.
+func (q *Queries) MarkOutboxEventFailed(ctx context.Context, arg MarkOutboxEventFailedParams) error {
+	_, err := q.db.Exec(ctx, markOutboxEventFailed,
+		arg.Status,
+		arg.LastError,
+		arg.RetryAfterSeconds,
+		arg.ID,
+	)
+	return err
+}
+
+const replayOutboxEvent = `-- name: ReplayOutboxEvent :one
+update outbox_events
+set
+  status = 'pending',
+  attempts = 0,
+  last_error = null,
+  next_attempt_at = current_timestamp,
+  updated_at = current_timestamp
+where
+  id = $1::uuid and
+  community_id = $2::uuid and
+  status <> 'delivered'
+returning id, community_id, aggregate_id, event_type, payload, status, attempts, last_error, next_attempt_at, delivered_at, created_at, updated_at
+`
+
+type ReplayOutboxEventParams struct {
+	ID          uuid.UUID `json:"id"`
+	CommunityID uuid.UUID `json:"community_id"`
+}
+
+func (q *Queries) ReplayOutboxEvent(ctx context.Context, arg ReplayOutboxEventParams) (OutboxEvent, error) {
+	row := q.db.QueryRow(ctx, replayOutboxEvent, arg.ID, arg.CommunityID)
+	var i OutboxEvent
+	err := row.Scan(
+		&i.ID,
+		&i.CommunityID,
+		&i.AggregateID,
+		&i.EventType,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.LastError,
+		&i.NextAttemptAt,
+		&i.DeliveredAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,69 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: rate_limit.sql
+
+package database
+
+import (
+	"context"
+)
+
+const purgeRateLimitBuckets = `-- name: PurgeRateLimitBuckets :execrows
+delete from rate_limit_buckets
+where expires_at < now()
+`
+
+func (q *Queries) PurgeRateLimitBuckets(ctx context.Context) (int64, error) {
+	result, err := q.db.Exec(ctx, purgeRateLimitBuckets)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const takeRateLimitToken = `-- name: TakeRateLimitToken :one
+insert into rate_limit_buckets as b (key, tokens, allowed, updated_at, expires_at)
+values (
+  $1, $2::float8 - 1, true, now(),
+  now() + make_interval(secs => $3::float8)
+)
+on conflict (key) do update set
+  tokens = case
+    when least($2::float8, b.tokens + extract(epoch from now() - b.updated_at)::float8 * $4::float8) >= 1
+    then least($2::float8, b.tokens + extract(epoch from now() - b.updated_at)::float8 * $4::float8) - 1
+    else least($2::float8, b.tokens + extract(epoch from now() - b.updated_at)::float8 * $4::float8)
+  end,
+  allowed = least($2::float8, b.tokens + extract(epoch from now() - b.updated_at)::float8 * $4::float8) >= 1,
+  updated_at = now(),
+  expires_at = excluded.expires_at
+returning tokens, allowed
+`
+
+type TakeRateLimitTokenParams struct {
+	Key           string  `json:"key"`
+	Burst         float64 `json:"burst"`
+	RefillSeconds float64 `json:"refill_seconds"`
+	Rate          float64 `json:"rate"`
+}
+
+type TakeRateLimitTokenRow struct {
+	Tokens  float64 `json:"tokens"`
+	Allowed bool    `json:"allowed"`
+}
+
+// Refills the bucket for the time elapsed since its last use, then takes one
+// token when available. Runs as a single statement so concurrent instances
+// cannot both take the last token. An untouched bucket is full again after
+// refill_seconds, and may be purged from then on.
+func (q *Queries) TakeRateLimitToken(ctx context.Context, arg TakeRateLimitTokenParams) (TakeRateLimitTokenRow, error) {
+	row := q.db.QueryRow(ctx, takeRateLimitToken,
+		arg.Key,
+		arg.Burst,
+		arg.RefillSeconds,
+		arg.Rate,
+	)
+	var i TakeRateLimitTokenRow
+	err := row.Scan(&i.Tokens, &i.Allowed)
+	return i, err
+}
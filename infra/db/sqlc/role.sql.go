@@ -0,0 +1,281 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: role.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const deleteRole = `-- name: DeleteRole :exec
+delete from roles
+where id = $1
+`
+
+func (q *Queries) DeleteRole(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.Exec(ctx, deleteRole, id)
+	return err
+}
+
+const deleteRolePermissions = `-- name: DeleteRolePermissions :exec
+delete from role_permissions
+where role_id = $1
+`
+
+func (q *Queries) DeleteRolePermissions(ctx context.Context, roleID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, deleteRolePermissions, roleID)
+	return err
+}
+
+const findPermissions = `-- name: FindPermissions :many
+select name, description from permissions
+order by name
+`
+
+func (q *Queries) FindPermissions(ctx context.Context) ([]Permission, error) {
+	rows, err := q.db.Query(ctx, findPermissions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Permission
+	for rows.Next() {
+		var i Permission
+		if err := rows.Scan(&i.Name, &i.Description); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const findRoleByID = `-- name: FindRoleByID :one
+select id, community_id, name, description, created_at, updated_at from roles
+where id = $1 and community_id = $2
+`
+
+type FindRoleByIDParams struct {
+	ID          uuid.UUID   `json:"id"`
+	CommunityID pgtype.UUID `json:"community_id"`
+}
+
+func (q *Queries) FindRoleByID(ctx context.Context, arg FindRoleByIDParams) (Role, error) {
+	row := q.db.QueryRow(ctx, findRoleByID, arg.ID, arg.CommunityID)
+	var i Role
+	err := row.Scan(
+		&i.ID,
+		&i.CommunityID,
+		&i.Name,
+		&i.Description,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const findRolePermissions = `-- name: FindRolePermissions :many
+select rp.permission
+from role_permissions rp
+join roles r on r.id = rp.role_id
+where
+  r.name = $1 and
+  (r.community_id is null or r.community_id = $2)
+order by rp.permission
+`
+
+type FindRolePermissionsParams struct {
+	Name        string      `json:"name"`
+	CommunityID pgtype.UUID `json:"community_id"`
+}
+
+func (q *Queries) FindRolePermissions(ctx context.Context, arg FindRolePermissionsParams) ([]string, error) {
+	rows, err := q.db.Query(ctx, findRolePermissions, arg.Name, arg.CommunityID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var permission string
+		if err := rows.Scan(&permission); err != nil {
+			return nil, err
+		}
+		items = append(items, permission)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const findRolePermissionsByCommunityID = `-- name: FindRolePermissionsByCommunityID :many
+select rp.role_id, rp.permission
+from role_permissions rp
+join roles r on r.id = rp.role_id
+where r.community_id is null or r.community_id = $1
+order by rp.permission
+`
+
+func (q *Queries) FindRolePermissionsByCommunityID(ctx context.Context, communityID pgtype.UUID) ([]RolePermission, error) {
+	rows, err := q.db.Query(ctx, findRolePermissionsByCommunityID, communityID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []RolePermission
+	for rows.Next() {
+		var i RolePermission
+		if err := rows.Scan(&i.RoleID, &i.Permission); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const findRolesByCommunityID = `-- name: FindRolesByCommunityID :many
+select id, community_id, name, description, created_at, updated_at from roles
+where community_id is null or community_id = $1
+order by community_id nulls first, name
+`
+
+func (q *Queries) FindRolesByCommunityID(ctx context.Context, communityID pgtype.UUID) ([]Role, error) {
+	rows, err := q.db.Query(ctx, findRolesByCommunityID, communityID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Role
+	for rows.Next() {
+		var i Role
+		if err := rows.Scan(
+			&i.ID,
+			&i.CommunityID,
+			&i.Name,
+			&i.Description,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const insertRole = `-- name: InsertRole :one
+insert into roles (
+    community_id,
+    name,
+    description
+) values ($1, $2, $3)
+returning id, community_id, name, description, created_at, updated_at
+`
+
+type InsertRoleParams struct {
+	CommunityID pgtype.UUID `json:"community_id"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+}
+
+func (q *Queries) InsertRole(ctx context.Context, arg InsertRoleParams) (Role, error) {
+	row := q.db.QueryRow(ctx, insertRole, arg.CommunityID, arg.Name, arg.Description)
+	var i Role
+	err := row.Scan(
+		&i.ID,
+		&i.CommunityID,
+		&i.Name,
+		&i.Description,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const insertRolePermissions = `-- name: InsertRolePermissions :exec
+insert into role_permissions (role_id, permission)
+select $1::uuid, unnest($2::varchar[])
+`
+
+type InsertRolePermissionsParams struct {
+	RoleID      uuid.UUID `json:"role_id"`
+	Permissions []string  `json:"permissions"`
+}
+
+func (q *Queries) InsertRolePermissions(ctx context.Context, arg InsertRolePermissionsParams) error {
+	_, err := q.db.Exec(ctx, insertRolePermissions, arg.RoleID, arg.Permissions)
+	return err
+}
+
+const isRoleAssignable = `-- name: IsRoleAssignable :one
+select exists (
+  select 1 from roles
+  where
+    name = $1 and
+    (community_id is null or community_id = $2)
+)
+`
+
+type IsRoleAssignableParams struct {
+	Name        string      `json:"name"`
+	CommunityID pgtype.UUID `json:"community_id"`
+}
+
+func (q *Queries) IsRoleAssignable(ctx context.Context, arg IsRoleAssignableParams) (bool, error) {
+	row := q.db.QueryRow(ctx, isRoleAssignable, arg.Name, arg.CommunityID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const isRoleInUse = `-- name: IsRoleInUse :one
+select exists (
+  select 1 from users
+  where community_id = $1 and role = $2
+)
+`
+
+type IsRoleInUseParams struct {
+	CommunityID uuid.UUID `json:"community_id"`
+	Role        string    `json:"role"`
+}
+
+// Soft-deleted users keep their role in use so they can be restored.
+func (q *Queries) IsRoleInUse(ctx context.Context, arg IsRoleInUseParams) (bool, error) {
+	row := q.db.QueryRow(ctx, isRoleInUse, arg.CommunityID, arg.Role)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const updateRole = `-- name: UpdateRole :exec
+update roles
+set
+  description = coalesce($1, description),
+  updated_at = current_timestamp
+where id = $2
+`
+
+type UpdateRoleParams struct {
+	Description pgtype.Text `json:"description"`
+	ID          uuid.UUID   `json:"id"`
+}
+
+func (q *Queries) UpdateRole(ctx context.Context, arg UpdateRoleParams) error {
+	_, err := q.db.Exec(ctx, updateRole, arg.Description, arg.ID)
+	return err
+}
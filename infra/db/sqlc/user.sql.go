@@ -12,6 +12,48 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const countCommunityUsers = `-- name: CountCommunityUsers :one
+select count(*) from users u
+where
+  u.community_id = $1::uuid
+  and u.deleted_at is null
+  and ($2::text is null or u.role = $2::text)
+  and ($3::text is null or u.address ilike '%' || $3::text || '%')
+  and ($4::timestamp is null or u.created_at >= $4::timestamp)
+  and ($5::timestamp is null or u.created_at < $5::timestamp)
+`
+
+type CountCommunityUsersParams struct {
+	CommunityID uuid.UUID        `json:"community_id"`
+	Role        pgtype.Text      `json:"role"`
+	Address     pgtype.Text      `json:"address"`
+	CreatedFrom pgtype.Timestamp `json:"created_from"`
+	CreatedTo   pgtype.Timestamp `json:"created_to"`
+}
+
+func (q *Queries) CountCommunityUsers(ctx context.Context, arg CountCommunityUsersParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countCommunityUsers,
+		arg.CommunityID,
+		arg.Role,
+		arg.Address,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const deleteCommunity = `-- name: DeleteCommunity :exec
+delete from communities
+where id = $1
+`
+
+func (q *Queries) DeleteCommunity(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.Exec(ctx, deleteCommunity, id)
+	return err
+}
+
 const deleteUser = `-- name: DeleteUser :exec
 delete from users
 where id = $1
@@ -22,79 +64,27 @@ func (q *Queries) DeleteUser(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
-const findUserByCommunityID = `-- name: FindUserByCommunityID :many
-select
-  u.id, u.fullname, u.email, u.phone, u.address, u.role, u.created_at, u.updated_at, u.community_id,
-  c.id, c.rt_number, c.rw_number, c.subdistrict, c.district, c.city, c.province, c.created_at, c.updated_at
-from users u
-inner join communities c on c.id = u.community_id
-where
-  u.community_id = $1
+const findUserAccess = `-- name: FindUserAccess :one
+select role, community_id, tokens_valid_after from users
+where id = $1 and deleted_at is null
 `
 
-type FindUserByCommunityIDRow struct {
-	ID          uuid.UUID        `json:"id"`
-	Fullname    string           `json:"fullname"`
-	Email       pgtype.Text      `json:"email"`
-	Phone       pgtype.Text      `json:"phone"`
-	Address     pgtype.Text      `json:"address"`
-	Role        string           `json:"role"`
-	CreatedAt   pgtype.Timestamp `json:"created_at"`
-	UpdatedAt   pgtype.Timestamp `json:"updated_at"`
-	CommunityID uuid.UUID        `json:"community_id"`
-	ID_2        uuid.UUID        `json:"id_2"`
-	RtNumber    int32            `json:"rt_number"`
-	RwNumber    int32            `json:"rw_number"`
-	Subdistrict string           `json:"subdistrict"`
-	District    string           `json:"district"`
-	City        string           `json:"city"`
-	Province    string           `json:"province"`
-	CreatedAt_2 pgtype.Timestamp `json:"created_at_2"`
-	UpdatedAt_2 pgtype.Timestamp `json:"updated_at_2"`
-}
-
-func (q *Queries) FindUserByCommunityID(ctx context.Context, communityID uuid.UUID) ([]FindUserByCommunityIDRow, error) {
-	rows, err := q.db.Query(ctx, findUserByCommunityID, communityID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []FindUserByCommunityIDRow
-	for rows.Next() {
-		var i FindUserByCommunityIDRow
-		if err := rows.Scan(
-			&i.ID,
-			&i.Fullname,
-			&i.Email,
-			&i.Phone,
-			&i.Address,
-			&i.Role,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-			&i.CommunityID,
-			&i.ID_2,
-			&i.RtNumber,
-			&i.RwNumber,
-			&i.Subdistrict,
-			&i.District,
-			&i.City,
-			&i.Province,
-			&i.CreatedAt_2,
-			&i.UpdatedAt_2,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+type FindUserAccessRow struct {
+	Role             string             `json:"role"`
+	CommunityID      uuid.UUID          `json:"community_id"`
+	TokensValidAfter pgtype.Timestamptz `json:"tokens_valid_after"`
+}
+
+func (q *Queries) FindUserAccess(ctx context.Context, id uuid.UUID) (FindUserAccessRow, error) {
+	row := q.db.QueryRow(ctx, findUserAccess, id)
+	var i FindUserAccessRow
+	err := row.Scan(&i.Role, &i.CommunityID, &i.TokensValidAfter)
+	return i, err
 }
 
 const findUserByID = `-- name: FindUserByID :one
 select
-  u.id, u.fullname, u.email, u.phone, u.address, u.role, u.created_at, u.updated_at, u.community_id,
+  u.id, u.fullname, u.email, u.phone, u.address, u.role, u.created_at, u.updated_at, u.community_id, u.tokens_valid_after, u.deleted_at, u.version,
   c.id, c.rt_number, c.rw_number, c.subdistrict, c.district, c.city, c.province, c.created_at, c.updated_at
 from users u
 inner join communities c on c.id = u.community_id
@@ -118,6 +108,11 @@ where
     $4::uuid is null or
     u.community_id = $4::uuid
   )
+  and
+  (
+    u.deleted_at is null or
+    coalesce($5::bool, false)
+  )
 `
 
 type FindUserByIDParams struct {
@@ -125,27 +120,31 @@ type FindUserByIDParams struct {
 	Email       pgtype.Text `json:"email"`
 	Phone       pgtype.Text `json:"phone"`
 	CommunityID pgtype.UUID `json:"community_id"`
+	WithDeleted pgtype.Bool `json:"with_deleted"`
 }
 
 type FindUserByIDRow struct {
-	ID          uuid.UUID        `json:"id"`
-	Fullname    string           `json:"fullname"`
-	Email       pgtype.Text      `json:"email"`
-	Phone       pgtype.Text      `json:"phone"`
-	Address     pgtype.Text      `json:"address"`
-	Role        string           `json:"role"`
-	CreatedAt   pgtype.Timestamp `json:"created_at"`
-	UpdatedAt   pgtype.Timestamp `json:"updated_at"`
-	CommunityID uuid.UUID        `json:"community_id"`
-	ID_2        uuid.UUID        `json:"id_2"`
-	RtNumber    int32            `json:"rt_number"`
-	RwNumber    int32            `json:"rw_number"`
-	Subdistrict string           `json:"subdistrict"`
-	District    string           `json:"district"`
-	City        string           `json:"city"`
-	Province    string           `json:"province"`
-	CreatedAt_2 pgtype.Timestamp `json:"created_at_2"`
-	UpdatedAt_2 pgtype.Timestamp `json:"updated_at_2"`
+	ID               uuid.UUID          `json:"id"`
+	Fullname         string             `json:"fullname"`
+	Email            pgtype.Text        `json:"email"`
+	Phone            pgtype.Text        `json:"phone"`
+	Address          pgtype.Text        `json:"address"`
+	Role             string             `json:"role"`
+	CreatedAt        pgtype.Timestamp   `json:"created_at"`
+	UpdatedAt        pgtype.Timestamp   `json:"updated_at"`
+	CommunityID      uuid.UUID          `json:"community_id"`
+	TokensValidAfter pgtype.Timestamptz `json:"tokens_valid_after"`
+	DeletedAt        pgtype.Timestamptz `json:"deleted_at"`
+	Version          int64              `json:"version"`
+	ID_2             uuid.UUID          `json:"id_2"`
+	RtNumber         int32              `json:"rt_number"`
+	RwNumber         int32              `json:"rw_number"`
+	Subdistrict      string             `json:"subdistrict"`
+	District         string             `json:"district"`
+	City             string             `json:"city"`
+	Province         string             `json:"province"`
+	CreatedAt_2      pgtype.Timestamp   `json:"created_at_2"`
+	UpdatedAt_2      pgtype.Timestamp   `json:"updated_at_2"`
 }
 
 func (q *Queries) FindUserByID(ctx context.Context, arg FindUserByIDParams) (FindUserByIDRow, error) {
@@ -154,6 +153,7 @@ func (q *Queries) FindUserByID(ctx context.Context, arg FindUserByIDParams) (Fin
 		arg.Email,
 		arg.Phone,
 		arg.CommunityID,
+		arg.WithDeleted,
 	)
 	var i FindUserByIDRow
 	err := row.Scan(
@@ -166,6 +166,9 @@ func (q *Queries) FindUserByID(ctx context.Context, arg FindUserByIDParams) (Fin
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.CommunityID,
+		&i.TokensValidAfter,
+		&i.DeletedAt,
+		&i.Version,
 		&i.ID_2,
 		&i.RtNumber,
 		&i.RwNumber,
@@ -261,6 +264,8 @@ select exists(
 )
 `
 
+// Soft-deleted users keep their email and phone until they are purged: their
+// identity account still holds them and a restore must not collide.
 func (q *Queries) IsEmailExists(ctx context.Context, email pgtype.Text) (bool, error) {
 	row := q.db.QueryRow(ctx, isEmailExists, email)
 	var exists bool
@@ -281,6 +286,660 @@ func (q *Queries) IsPhoneExists(ctx context.Context, phone pgtype.Text) (bool, e
 	return exists, err
 }
 
+const listCommunityUsersByCreatedAt = `-- name: ListCommunityUsersByCreatedAt :many
+
+select
+  u.id, u.fullname, u.email, u.phone, u.address, u.role, u.created_at, u.updated_at, u.community_id, u.tokens_valid_after, u.deleted_at, u.version,
+  c.id, c.rt_number, c.rw_number, c.subdistrict, c.district, c.city, c.province, c.created_at, c.updated_at
+from users u
+inner join communities c on c.id = u.community_id
+where
+  u.community_id = $1::uuid
+  and u.deleted_at is null
+  and ($2::text is null or u.role = $2::text)
+  and ($3::text is null or u.address ilike '%' || $3::text || '%')
+  and ($4::timestamp is null or u.created_at >= $4::timestamp)
+  and ($5::timestamp is null or u.created_at < $5::timestamp)
+  and (
+    $6::uuid is null or
+    (u.created_at, u.id) > ($7::timestamp, $6::uuid)
+  )
+order by u.created_at asc, u.id asc
+limit $8
+`
+
+type ListCommunityUsersByCreatedAtParams struct {
+	CommunityID     uuid.UUID        `json:"community_id"`
+	Role            pgtype.Text      `json:"role"`
+	Address         pgtype.Text      `json:"address"`
+	CreatedFrom     pgtype.Timestamp `json:"created_from"`
+	CreatedTo       pgtype.Timestamp `json:"created_to"`
+	CursorID        pgtype.UUID      `json:"cursor_id"`
+	CursorCreatedAt pgtype.Timestamp `json:"cursor_created_at"`
+	PageSize        int32            `json:"page_size"`
+}
+
+type ListCommunityUsersByCreatedAtRow struct {
+	ID               uuid.UUID          `json:"id"`
+	Fullname         string             `json:"fullname"`
+	Email            pgtype.Text        `json:"email"`
+	Phone            pgtype.Text        `json:"phone"`
+	Address          pgtype.Text        `json:"address"`
+	Role             string             `json:"role"`
+	CreatedAt        pgtype.Timestamp   `json:"created_at"`
+	UpdatedAt        pgtype.Timestamp   `json:"updated_at"`
+	CommunityID      uuid.UUID          `json:"community_id"`
+	TokensValidAfter pgtype.Timestamptz `json:"tokens_valid_after"`
+	DeletedAt        pgtype.Timestamptz `json:"deleted_at"`
+	Version          int64              `json:"version"`
+	ID_2             uuid.UUID          `json:"id_2"`
+	RtNumber         int32              `json:"rt_number"`
+	RwNumber         int32              `json:"rw_number"`
+	Subdistrict      string             `json:"subdistrict"`
+	District         string             `json:"district"`
+	City             string             `json:"city"`
+	Province         string             `json:"province"`
+	CreatedAt_2      pgtype.Timestamp   `json:"created_at_2"`
+	UpdatedAt_2      pgtype.Timestamp   `json:"updated_at_2"`
+}
+
+// The ListCommunityUsers* queries page through a community with a keyset on
+// (sort column, id), served by the users_community_*_idx indexes. They share
+// their filters with CountCommunityUsers.
+func (q *Queries) ListCommunityUsersByCreatedAt(ctx context.Context, arg ListCommunityUsersByCreatedAtParams) ([]ListCommunityUsersByCreatedAtRow, error) {
+	rows, err := q.db.Query(ctx, listCommunityUsersByCreatedAt,
+		arg.CommunityID,
+		arg.Role,
+		arg.Address,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.CursorID,
+		arg.CursorCreatedAt,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListCommunityUsersByCreatedAtRow
+	for rows.Next() {
+		var i ListCommunityUsersByCreatedAtRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Fullname,
+			&i.Email,
+			&i.Phone,
+			&i.Address,
+			&i.Role,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.CommunityID,
+			&i.TokensValidAfter,
+			&i.DeletedAt,
+			&i.Version,
+			&i.ID_2,
+			&i.RtNumber,
+			&i.RwNumber,
+			&i.Subdistrict,
+			&i.District,
+			&i.City,
+			&i.Province,
+			&i.CreatedAt_2,
+			&i.UpdatedAt_2,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCommunityUsersByCreatedAtDesc = `-- name: ListCommunityUsersByCreatedAtDesc :many
+select
+  u.id, u.fullname, u.email, u.phone, u.address, u.role, u.created_at, u.updated_at, u.community_id, u.tokens_valid_after, u.deleted_at, u.version,
+  c.id, c.rt_number, c.rw_number, c.subdistrict, c.district, c.city, c.province, c.created_at, c.updated_at
+from users u
+inner join communities c on c.id = u.community_id
+where
+  u.community_id = $1::uuid
+  and u.deleted_at is null
+  and ($2::text is null or u.role = $2::text)
+  and ($3::text is null or u.address ilike '%' || $3::text || '%')
+  and ($4::timestamp is null or u.created_at >= $4::timestamp)
+  and ($5::timestamp is null or u.created_at < $5::timestamp)
+  and (
+    $6::uuid is null or
+    (u.created_at, u.id) < ($7::timestamp, $6::uuid)
+  )
+order by u.created_at desc, u.id desc
+limit $8
+`
+
+type ListCommunityUsersByCreatedAtDescParams struct {
+	CommunityID     uuid.UUID        `json:"community_id"`
+	Role            pgtype.Text      `json:"role"`
+	Address         pgtype.Text      `json:"address"`
+	CreatedFrom     pgtype.Timestamp `json:"created_from"`
+	CreatedTo       pgtype.Timestamp `json:"created_to"`
+	CursorID        pgtype.UUID      `json:"cursor_id"`
+	CursorCreatedAt pgtype.Timestamp `json:"cursor_created_at"`
+	PageSize        int32            `json:"page_size"`
+}
+
+type ListCommunityUsersByCreatedAtDescRow struct {
+	ID               uuid.UUID          `json:"id"`
+	Fullname         string             `json:"fullname"`
+	Email            pgtype.Text        `json:"email"`
+	Phone            pgtype.Text        `json:"phone"`
+	Address          pgtype.Text        `json:"address"`
+	Role             string             `json:"role"`
+	CreatedAt        pgtype.Timestamp   `json:"created_at"`
+	UpdatedAt        pgtype.Timestamp   `json:"updated_at"`
+	CommunityID      uuid.UUID          `json:"community_id"`
+	TokensValidAfter pgtype.Timestamptz `json:"tokens_valid_after"`
+	DeletedAt        pgtype.Timestamptz `json:"deleted_at"`
+	Version          int64              `json:"version"`
+	ID_2             uuid.UUID          `json:"id_2"`
+	RtNumber         int32              `json:"rt_number"`
+	RwNumber         int32              `json:"rw_number"`
+	Subdistrict      string             `json:"subdistrict"`
+	District         string             `json:"district"`
+	City             string             `json:"city"`
+	Province         string             `json:"province"`
+	CreatedAt_2      pgtype.Timestamp   `json:"created_at_2"`
+	UpdatedAt_2      pgtype.Timestamp   `json:"updated_at_2"`
+}
+
+func (q *Queries) ListCommunityUsersByCreatedAtDesc(ctx context.Context, arg ListCommunityUsersByCreatedAtDescParams) ([]ListCommunityUsersByCreatedAtDescRow, error) {
+	rows, err := q.db.Query(ctx, listCommunityUsersByCreatedAtDesc,
+		arg.CommunityID,
+		arg.Role,
+		arg.Address,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.CursorID,
+		arg.CursorCreatedAt,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListCommunityUsersByCreatedAtDescRow
+	for rows.Next() {
+		var i ListCommunityUsersByCreatedAtDescRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Fullname,
+			&i.Email,
+			&i.Phone,
+			&i.Address,
+			&i.Role,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.CommunityID,
+			&i.TokensValidAfter,
+			&i.DeletedAt,
+			&i.Version,
+			&i.ID_2,
+			&i.RtNumber,
+			&i.RwNumber,
+			&i.Subdistrict,
+			&i.District,
+			&i.City,
+			&i.Province,
+			&i.CreatedAt_2,
+			&i.UpdatedAt_2,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCommunityUsersByFullname = `-- name: ListCommunityUsersByFullname :many
+select
+  u.id, u.fullname, u.email, u.phone, u.address, u.role, u.created_at, u.updated_at, u.community_id, u.tokens_valid_after, u.deleted_at, u.version,
+  c.id, c.rt_number, c.rw_number, c.subdistrict, c.district, c.city, c.province, c.created_at, c.updated_at
+from users u
+inner join communities c on c.id = u.community_id
+where
+  u.community_id = $1::uuid
+  and u.deleted_at is null
+  and ($2::text is null or u.role = $2::text)
+  and ($3::text is null or u.address ilike '%' || $3::text || '%')
+  and ($4::timestamp is null or u.created_at >= $4::timestamp)
+  and ($5::timestamp is null or u.created_at < $5::timestamp)
+  and (
+    $6::uuid is null or
+    (u.fullname, u.id) > ($7::text, $6::uuid)
+  )
+order by u.fullname asc, u.id asc
+limit $8
+`
+
+type ListCommunityUsersByFullnameParams struct {
+	CommunityID    uuid.UUID        `json:"community_id"`
+	Role           pgtype.Text      `json:"role"`
+	Address        pgtype.Text      `json:"address"`
+	CreatedFrom    pgtype.Timestamp `json:"created_from"`
+	CreatedTo      pgtype.Timestamp `json:"created_to"`
+	CursorID       pgtype.UUID      `json:"cursor_id"`
+	CursorFullname pgtype.Text      `json:"cursor_fullname"`
+	PageSize       int32            `json:"page_size"`
+}
+
+type ListCommunityUsersByFullnameRow struct {
+	ID               uuid.UUID          `json:"id"`
+	Fullname         string             `json:"fullname"`
+	Email            pgtype.Text        `json:"email"`
+	Phone            pgtype.Text        `json:"phone"`
+	Address          pgtype.Text        `json:"address"`
+	Role             string             `json:"role"`
+	CreatedAt        pgtype.Timestamp   `json:"created_at"`
+	UpdatedAt        pgtype.Timestamp   `json:"updated_at"`
+	CommunityID      uuid.UUID          `json:"community_id"`
+	TokensValidAfter pgtype.Timestamptz `json:"tokens_valid_after"`
+	DeletedAt        pgtype.Timestamptz `json:"deleted_at"`
+	Version          int64              `json:"version"`
+	ID_2             uuid.UUID          `json:"id_2"`
+	RtNumber         int32              `json:"rt_number"`
+	RwNumber         int32              `json:"rw_number"`
+	Subdistrict      string             `json:"subdistrict"`
+	District         string             `json:"district"`
+	City             string             `json:"city"`
+	Province         string             `json:"province"`
+	CreatedAt_2      pgtype.Timestamp   `json:"created_at_2"`
+	UpdatedAt_2      pgtype.Timestamp   `json:"updated_at_2"`
+}
+
+func (q *Queries) ListCommunityUsersByFullname(ctx context.Context, arg ListCommunityUsersByFullnameParams) ([]ListCommunityUsersByFullnameRow, error) {
+	rows, err := q.db.Query(ctx, listCommunityUsersByFullname,
+		arg.CommunityID,
+		arg.Role,
+		arg.Address,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.CursorID,
+		arg.CursorFullname,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListCommunityUsersByFullnameRow
+	for rows.Next() {
+		var i ListCommunityUsersByFullnameRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Fullname,
+			&i.Email,
+			&i.Phone,
+			&i.Address,
+			&i.Role,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.CommunityID,
+			&i.TokensValidAfter,
+			&i.DeletedAt,
+			&i.Version,
+			&i.ID_2,
+			&i.RtNumber,
+			&i.RwNumber,
+			&i.Subdistrict,
+			&i.District,
+			&i.City,
+			&i.Province,
+			&i.CreatedAt_2,
+			&i.UpdatedAt_2,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCommunityUsersByFullnameDesc = `-- name: ListCommunityUsersByFullnameDesc :many
+select
+  u.id, u.fullname, u.email, u.phone, u.address, u.role, u.created_at, u.updated_at, u.community_id, u.tokens_valid_after, u.deleted_at, u.version,
+  c.id, c.rt_number, c.rw_number, c.subdistrict, c.district, c.city, c.province, c.created_at, c.updated_at
+from users u
+inner join communities c on c.id = u.community_id
+where
+  u.community_id = $1::uuid
+  and u.deleted_at is null
+  and ($2::text is null or u.role = $2::text)
+  and ($3::text is null or u.address ilike '%' || $3::text || '%')
+  and ($4::timestamp is null or u.created_at >= $4::timestamp)
+  and ($5::timestamp is null or u.created_at < $5::timestamp)
+  and (
+    $6::uuid is null or
+    (u.fullname, u.id) < ($7::text, $6::uuid)
+  )
+order by u.fullname desc, u.id desc
+limit $8
+`
+
+type ListCommunityUsersByFullnameDescParams struct {
+	CommunityID    uuid.UUID        `json:"community_id"`
+	Role           pgtype.Text      `json:"role"`
+	Address        pgtype.Text      `json:"address"`
+	CreatedFrom    pgtype.Timestamp `json:"created_from"`
+	CreatedTo      pgtype.Timestamp `json:"created_to"`
+	CursorID       pgtype.UUID      `json:"cursor_id"`
+	CursorFullname pgtype.Text      `json:"cursor_fullname"`
+	PageSize       int32            `json:"page_size"`
+}
+
+type ListCommunityUsersByFullnameDescRow struct {
+	ID               uuid.UUID          `json:"id"`
+	Fullname         string             `json:"fullname"`
+	Email            pgtype.Text        `json:"email"`
+	Phone            pgtype.Text        `json:"phone"`
+	Address          pgtype.Text        `json:"address"`
+	Role             string             `json:"role"`
+	CreatedAt        pgtype.Timestamp   `json:"created_at"`
+	UpdatedAt        pgtype.Timestamp   `json:"updated_at"`
+	CommunityID      uuid.UUID          `json:"community_id"`
+	TokensValidAfter pgtype.Timestamptz `json:"tokens_valid_after"`
+	DeletedAt        pgtype.Timestamptz `json:"deleted_at"`
+	Version          int64              `json:"version"`
+	ID_2             uuid.UUID          `json:"id_2"`
+	RtNumber         int32              `json:"rt_number"`
+	RwNumber         int32              `json:"rw_number"`
+	Subdistrict      string             `json:"subdistrict"`
+	District         string             `json:"district"`
+	City             string             `json:"city"`
+	Province         string             `json:"province"`
+	CreatedAt_2      pgtype.Timestamp   `json:"created_at_2"`
+	UpdatedAt_2      pgtype.Timestamp   `json:"updated_at_2"`
+}
+
+func (q *Queries) ListCommunityUsersByFullnameDesc(ctx context.Context, arg ListCommunityUsersByFullnameDescParams) ([]ListCommunityUsersByFullnameDescRow, error) {
+	rows, err := q.db.Query(ctx, listCommunityUsersByFullnameDesc,
+		arg.CommunityID,
+		arg.Role,
+		arg.Address,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.CursorID,
+		arg.CursorFullname,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListCommunityUsersByFullnameDescRow
+	for rows.Next() {
+		var i ListCommunityUsersByFullnameDescRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Fullname,
+			&i.Email,
+			&i.Phone,
+			&i.Address,
+			&i.Role,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.CommunityID,
+			&i.TokensValidAfter,
+			&i.DeletedAt,
+			&i.Version,
+			&i.ID_2,
+			&i.RtNumber,
+			&i.RwNumber,
+			&i.Subdistrict,
+			&i.District,
+			&i.City,
+			&i.Province,
+			&i.CreatedAt_2,
+			&i.UpdatedAt_2,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const purgeDeletedUsers = `-- name: PurgeDeletedUsers :many
+delete from users
+where id in (
+  select id from users
+  where deleted_at < $1::timestamptz
+  order by deleted_at
+  limit $2
+  for update skip locked
+)
+returning id, community_id
+`
+
+type PurgeDeletedUsersParams struct {
+	DeletedBefore pgtype.Timestamptz `json:"deleted_before"`
+	BatchSize     int32              `json:"batch_size"`
+}
+
+type PurgeDeletedUsersRow struct {
+	ID          uuid.UUID `json:"id"`
+	CommunityID uuid.UUID `json:"community_id"`
+}
+
+// PurgeDeletedUsers removes a batch of users deleted before the given time.
+// Locked rows are skipped so several instances can purge at once.
+func (q *Queries) PurgeDeletedUsers(ctx context.Context, arg PurgeDeletedUsersParams) ([]PurgeDeletedUsersRow, error) {
+	rows, err := q.db.Query(ctx, purgeDeletedUsers, arg.DeletedBefore, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PurgeDeletedUsersRow
+	for rows.Next() {
+		var i PurgeDeletedUsersRow
+		if err := rows.Scan(&i.ID, &i.CommunityID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const restoreUser = `-- name: RestoreUser :execrows
+update users
+set deleted_at = null
+where id = $1 and deleted_at is not null
+`
+
+func (q *Queries) RestoreUser(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, restoreUser, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const revokeUserTokens = `-- name: RevokeUserTokens :exec
+update users
+set tokens_valid_after = current_timestamp
+where id = $1
+`
+
+func (q *Queries) RevokeUserTokens(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.Exec(ctx, revokeUserTokens, id)
+	return err
+}
+
+const searchCommunityUsers = `-- name: SearchCommunityUsers :many
+select
+  u.id, u.fullname, u.email, u.phone, u.address, u.role, u.created_at, u.updated_at, u.community_id, u.tokens_valid_after, u.deleted_at, u.version,
+  c.id, c.rt_number, c.rw_number, c.subdistrict, c.district, c.city, c.province, c.created_at, c.updated_at,
+  (
+    ts_rank(users_search_document(u.fullname, u.address), to_tsquery('simple', $1::text)) +
+    greatest(
+      word_similarity($2::text, u.fullname),
+      word_similarity($2::text, coalesce(u.address, '')),
+      similarity($2::text, coalesce(u.phone, ''))
+    )
+  )::float8 as rank,
+  ts_headline('simple', u.fullname, to_tsquery('simple', $1::text),
+    'HighlightAll=true, StartSel=' || $3::text || ', StopSel=' || $4::text
+  )::text as fullname_highlight,
+  ts_headline('simple', coalesce(u.address, ''), to_tsquery('simple', $1::text),
+    'HighlightAll=true, StartSel=' || $3::text || ', StopSel=' || $4::text
+  )::text as address_highlight
+from users u
+inner join communities c on c.id = u.community_id
+where
+  u.community_id = $5::uuid
+  and u.deleted_at is null
+  and (
+    users_search_document(u.fullname, u.address) @@ to_tsquery('simple', $1::text)
+    or $2::text <% u.fullname
+    or $2::text <% u.address
+    or ($6::text is not null and u.phone like '%' || $6::text || '%')
+  )
+order by rank desc, u.id
+limit $7
+`
+
+type SearchCommunityUsersParams struct {
+	Tsquery     string      `json:"tsquery"`
+	Term        string      `json:"term"`
+	StartSel    string      `json:"start_sel"`
+	StopSel     string      `json:"stop_sel"`
+	CommunityID uuid.UUID   `json:"community_id"`
+	Phone       pgtype.Text `json:"phone"`
+	PageSize    int32       `json:"page_size"`
+}
+
+type SearchCommunityUsersRow struct {
+	ID                uuid.UUID          `json:"id"`
+	Fullname          string             `json:"fullname"`
+	Email             pgtype.Text        `json:"email"`
+	Phone             pgtype.Text        `json:"phone"`
+	Address           pgtype.Text        `json:"address"`
+	Role              string             `json:"role"`
+	CreatedAt         pgtype.Timestamp   `json:"created_at"`
+	UpdatedAt         pgtype.Timestamp   `json:"updated_at"`
+	CommunityID       uuid.UUID          `json:"community_id"`
+	TokensValidAfter  pgtype.Timestamptz `json:"tokens_valid_after"`
+	DeletedAt         pgtype.Timestamptz `json:"deleted_at"`
+	Version           int64              `json:"version"`
+	ID_2              uuid.UUID          `json:"id_2"`
+	RtNumber          int32              `json:"rt_number"`
+	RwNumber          int32              `json:"rw_number"`
+	Subdistrict       string             `json:"subdistrict"`
+	District          string             `json:"district"`
+	City              string             `json:"city"`
+	Province          string             `json:"province"`
+	CreatedAt_2       pgtype.Timestamp   `json:"created_at_2"`
+	UpdatedAt_2       pgtype.Timestamp   `json:"updated_at_2"`
+	Rank              float64            `json:"rank"`
+	FullnameHighlight string             `json:"fullname_highlight"`
+	AddressHighlight  string             `json:"address_highlight"`
+}
+
+// SearchCommunityUsers ranks full-text matches on name and address together
+// with trigram similarity, so partial and misspelled terms still match.
+// Highlights wrap matched words in the start_sel and stop_sel markers.
+func (q *Queries) SearchCommunityUsers(ctx context.Context, arg SearchCommunityUsersParams) ([]SearchCommunityUsersRow, error) {
+	rows, err := q.db.Query(ctx, searchCommunityUsers,
+		arg.Tsquery,
+		arg.Term,
+		arg.StartSel,
+		arg.StopSel,
+		arg.CommunityID,
+		arg.Phone,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchCommunityUsersRow
+	for rows.Next() {
+		var i SearchCommunityUsersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Fullname,
+			&i.Email,
+			&i.Phone,
+			&i.Address,
+			&i.Role,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.CommunityID,
+			&i.TokensValidAfter,
+			&i.DeletedAt,
+			&i.Version,
+			&i.ID_2,
+			&i.RtNumber,
+			&i.RwNumber,
+			&i.Subdistrict,
+			&i.District,
+			&i.City,
+			&i.Province,
+			&i.CreatedAt_2,
+			&i.UpdatedAt_2,
+			&i.Rank,
+			&i.FullnameHighlight,
+			&i.AddressHighlight,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const softDeleteUser = `-- name: SoftDeleteUser :execrows
+update users
+set
+  deleted_at = current_timestamp,
+  tokens_valid_after = current_timestamp
+where
+  id = $1
+  and deleted_at is null
+  and ($2::bigint[] is null or version = any($2::bigint[]))
+`
+
+type SoftDeleteUserParams struct {
+	ID       uuid.UUID `json:"id"`
+	Versions []int64   `json:"versions"`
+}
+
+// SoftDeleteUser also revokes every token issued to the user. Like
+// UpdateUser, it only applies at one of the given versions unless they are
+// null.
+func (q *Queries) SoftDeleteUser(ctx context.Context, arg SoftDeleteUserParams) (int64, error) {
+	result, err := q.db.Exec(ctx, softDeleteUser, arg.ID, arg.Versions)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const updateUser = `-- name: UpdateUser :one
 update users
 set
@@ -291,7 +950,9 @@ set
   role = coalesce($5::text, role)
 where
   id = $6::uuid
-returning id
+  and deleted_at is null
+  and ($7::bigint[] is null or version = any($7::bigint[]))
+returning id, version
 `
 
 type UpdateUserParams struct {
@@ -301,9 +962,17 @@ type UpdateUserParams struct {
 	Address  pgtype.Text `json:"address"`
 	Role     pgtype.Text `json:"role"`
 	ID       uuid.UUID   `json:"id"`
+	Versions []int64     `json:"versions"`
 }
 
-func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (uuid.UUID, error) {
+type UpdateUserRow struct {
+	ID      uuid.UUID `json:"id"`
+	Version int64     `json:"version"`
+}
+
+// UpdateUser only applies to a user not deleted, at one of the given
+// versions, or at any version when they are null.
+func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (UpdateUserRow, error) {
 	row := q.db.QueryRow(ctx, updateUser,
 		arg.Fullname,
 		arg.Email,
@@ -311,8 +980,9 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (uuid.UU
 		arg.Address,
 		arg.Role,
 		arg.ID,
+		arg.Versions,
 	)
-	var id uuid.UUID
-	err := row.Scan(&id)
-	return id, err
+	var i UpdateUserRow
+	err := row.Scan(&i.ID, &i.Version)
+	return i, err
 }
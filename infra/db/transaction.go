@@ -6,50 +6,73 @@ import (
 	"log/slog"
 
 	database "github.com/dvvnFrtn/capstone-backend/infra/db/sqlc"
+	"github.com/dvvnFrtn/capstone-backend/internal/metrics"
 	"github.com/dvvnFrtn/capstone-backend/internal/types"
 	"github.com/dvvnFrtn/capstone-backend/pkg/errs"
-	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 )
 
-func RunTransaction(ctx context.Context, conn *pgx.Conn, cb func(q *database.Queries) error) error {
+func RunTransaction(ctx context.Context, pool *pgxpool.Pool, cb func(q *database.Queries) error) (err error) {
 	const op errs.Op = "db.RunTransaction"
-	reqID := ctx.Value(types.RequestIDKey)
+	reqID := types.RequestIDFrom(ctx)
 
-	tx, err := conn.Begin(ctx)
+	ctx, span := tracer.Start(ctx, "db.RunTransaction")
+	outcome := "error"
+	defer func() {
+		metrics.Transactions.WithLabelValues(outcome).Inc()
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
+	tx, err := pool.Begin(ctx)
 	if err != nil {
-		return errs.New(
+		return errs.WithRequestID(errs.New(
 			op,
 			errs.Internal,
 			fmt.Errorf("failed to start tx: %w", err),
-		)
+		), reqID)
 	} else {
-		slog.Info("starting database tx", "request_id", reqID)
+		logger().InfoContext(ctx, "starting database tx")
 	}
 
-	queries := database.New(conn)
+	queries := database.New(pool)
 	qtx := queries.WithTx(tx)
 	if err := cb(qtx); err != nil {
+		span.SetAttributes(attribute.String("db.tx.outcome", "rollback"))
 		if rbErr := tx.Rollback(ctx); rbErr != nil {
-			return errs.New(
+			return errs.WithRequestID(errs.New(
 				op,
 				errs.Internal,
 				fmt.Errorf("failed to rollback tx: %w", err),
-			)
+			), reqID)
 		} else {
-			slog.Info("rollback database tx", "request_id", reqID)
+			outcome = "rollback"
+			logger().InfoContext(ctx, "rollback database tx")
 		}
-		return err
+		return errs.WithRequestID(err, reqID)
 	}
 
+	span.SetAttributes(attribute.String("db.tx.outcome", "commit"))
 	if err := tx.Commit(ctx); err != nil {
-		return errs.New(
+		return errs.WithRequestID(errs.New(
 			op,
 			errs.Internal,
 			fmt.Errorf("failed to commit tx: %w", err),
-		)
+		), reqID)
 	} else {
-		slog.Info("commiting database tx", "request_id", reqID)
+		outcome = "commit"
+		logger().InfoContext(ctx, "commiting database tx")
 	}
 
 	return nil
 }
+
+// logger is looked up on every call so it follows slog.SetDefault.
+func logger() *slog.Logger {
+	return slog.Default().With("component", "db")
+}
@@ -2,6 +2,7 @@ package app
 
 import (
 	"context"
+	"errors"
 	"log"
 	"log/slog"
 	"net/http"
@@ -14,61 +15,317 @@ import (
 	"github.com/dvvnFrtn/capstone-backend/infra/db"
 	"github.com/dvvnFrtn/capstone-backend/internal/handler"
 	"github.com/dvvnFrtn/capstone-backend/internal/handler/middleware"
+	"github.com/dvvnFrtn/capstone-backend/internal/health"
+	"github.com/dvvnFrtn/capstone-backend/internal/lifecycle"
+	"github.com/dvvnFrtn/capstone-backend/internal/logging"
+	"github.com/dvvnFrtn/capstone-backend/internal/metrics"
 	"github.com/dvvnFrtn/capstone-backend/internal/service"
-	"github.com/dvvnFrtn/capstone-backend/pkg/authx"
+	"github.com/dvvnFrtn/capstone-backend/internal/telemetry"
+	"github.com/dvvnFrtn/capstone-backend/pkg/ratelimit"
+	"github.com/dvvnFrtn/capstone-backend/pkg/secretbox"
 	"github.com/gin-gonic/gin"
-	"github.com/joho/godotenv"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
-func Run() {
-	if err := godotenv.Load(); err != nil {
-		log.Fatal("failed to load .env file: ", err)
-	} else {
-		log.Println("success loading .env file")
+// container holds the database pool and the services shared by the HTTP
+// server and the CLI commands.
+type container struct {
+	cfg       config.App
+	logger    *slog.Logger
+	pool      *pgxpool.Pool
+	providers *authProviders
+
+	authService   service.AuthService
+	outboxService service.OutboxService
+	userService   service.UserService
+	roleService   service.RoleService
+	auditService  service.AuditService
+}
+
+func newContainer(ctx context.Context, cfg config.App) (*container, error) {
+	box, err := secretbox.New(cfg.Outbox.PayloadSecret)
+	if err != nil {
+		return nil, err
 	}
 
-	cfg := config.Database()
-	conn, err := db.NewPostgreConn(context.Background(), &cfg)
+	pool, err := db.NewPostgrePool(ctx, &cfg.DB)
 	if err != nil {
-		log.Fatal("failed to connect database: ", err)
+		return nil, err
 	}
 
-	firebaseClient, err := authx.InitFirebase(context.Background(), os.Getenv("FIREBASE_KEY_PATH"))
+	providers := &authProviders{cfg: cfg.Auth, pool: pool}
+	authService, err := providers.authService(ctx)
 	if err != nil {
-		log.Fatal("failed to init firebase: ", err)
+		pool.Close()
+		return nil, err
 	}
+	authService = service.NewMeteredAuthService(authService, cfg.Auth.Provider)
+	authService = service.NewTracedAuthService(authService, cfg.Auth.Provider)
 
-	var (
-		logger      = slog.Default()
-		router      = gin.Default()
-		authService = service.NewFirebaseAuthService(firebaseClient.Auth)
-		firebaseMw  = middleware.NewFirebaseAuthMiddleware(firebaseClient.Auth)
-		userService = service.NewUserService(conn, authService)
-		userHandler = handler.NewUserHandler(logger, userService)
-	)
+	logger := logging.New(os.Stdout, cfg.Log)
+	slog.SetDefault(logger)
+	outboxService := service.NewOutboxService(logger.With(logging.ComponentKey, "outbox"), pool, authService, cfg.Outbox, box)
+
+	return &container{
+		cfg:           cfg,
+		logger:        logger,
+		pool:          pool,
+		providers:     providers,
+		authService:   authService,
+		outboxService: outboxService,
+		userService:   service.NewUserService(pool, outboxService),
+		roleService:   service.NewRoleService(pool),
+		auditService:  service.NewAuditService(pool),
+	}, nil
+}
+
+func (c *container) Close() {
+	c.pool.Close()
+}
 
-	handler.Register(router, logger, userHandler, *firebaseMw)
-	server := &http.Server{
-		Addr:    os.Getenv("APP_HOST"),
-		Handler: router,
+func serve(cfg config.App) error {
+	log.Printf("loaded configuration:\n%s", cfg)
+
+	shutdownTracing, err := telemetry.Setup(context.Background(), cfg.Tracing, os.Stdout)
+	if err != nil {
+		return err
 	}
 
-	go func() {
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("listen: %s\n", err)
-		}
-	}()
+	c, err := newContainer(context.Background(), cfg)
+	if err != nil {
+		return err
+	}
+
+	// Components are stopped in reverse: first traffic, then background
+	// workers, then the pool they all share and finally the span exporter.
+	lc := lifecycle.New(c.logger.With(logging.ComponentKey, "lifecycle"))
+	lc.OnShutdown("tracing", shutdownTracing)
+	lc.OnShutdown("database pool", func(context.Context) error {
+		c.Close()
+		return nil
+	})
+
+	if err := run(cfg, c, lc); err != nil {
+		lc.Shutdown(context.Background())
+		return err
+	}
 
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
-	log.Println("shutdown server...")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	var failure error
+	select {
+	case <-quit:
+		c.logger.Info("shutdown server...")
+	case failure = <-lc.Failed():
+		c.logger.Error("component failed, shutting down", "err", failure)
+	}
+
+	// the drain delay is spent before the servers are shut down
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Health.DrainDelay+cfg.HTTP.ShutdownTimeout)
 	defer cancel()
-	if err := server.Shutdown(ctx); err != nil {
-		log.Println("server shutdown error: ", err)
+	if err := errors.Join(failure, lc.Shutdown(ctx)); err != nil {
+		return err
+	}
+
+	c.logger.Info("server shutdown gracefully. bye!")
+	return nil
+}
+
+// run starts the background workers and listeners, registering each with lc.
+func run(cfg config.App, c *container, lc *lifecycle.Manager) error {
+	verifier, err := c.providers.tokenVerifier(context.Background())
+	if err != nil {
+		return err
+	}
+	c.logger.Info("using auth provider", "provider", cfg.Auth.Provider, "token_verifier", cfg.Auth.TokenVerifier)
+
+	if cfg.Features.OutboxDispatcher {
+		lc.Go("outbox dispatcher", c.outboxService.Run)
+	}
+	lc.Go("user purge", userPurger(cfg.Retention, c, c.logger.With(logging.ComponentKey, "retention")))
+
+	checks := []health.Check{
+		{Name: "postgres", Run: c.pool.Ping},
+		{Name: "migrations", Run: func(ctx context.Context) error { return db.CheckMigrations(ctx, c.pool) }},
+	}
+	checker := health.NewChecker(cfg.Health.CacheTTL, cfg.Health.Timeout, append(checks, c.providers.healthChecks()...)...)
+
+	// report the state of every dependency before accepting traffic
+	for name, res := range checker.Ready(context.Background()).Checks {
+		if res.Status != health.StatusUp {
+			c.logger.Warn("dependency not ready", "check", name, "err", res.Error)
+		}
+	}
+
+	if cfg.Metrics.Host != "" {
+		if err := metrics.RegisterPool(c.pool); err != nil {
+			return err
+		}
+
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metrics.Handler())
+		listen(c.logger, lc, "metrics server", &http.Server{
+			Addr:        cfg.Metrics.Host,
+			Handler:     mux,
+			ReadTimeout: cfg.HTTP.ReadTimeout,
+		})
+	}
+
+	httpLogger := c.logger.With(logging.ComponentKey, "http")
+	limiter := rateLimiter(cfg.RateLimit, c, lc, httpLogger)
+	idempotent := idempotentRequests(cfg.Idempotency, c, lc, httpLogger)
+
+	router := gin.New()
+	if err := router.SetTrustedProxies(cfg.HTTP.TrustedProxies); err != nil {
+		return err
+	}
+	// RequestContext comes first so every response, including 404s and
+	// recovered panics, carries an X-Request-ID.
+	router.Use(
+		middleware.RequestContext(),
+		otelgin.Middleware(cfg.Tracing.ServiceName),
+		middleware.AccessLog(httpLogger),
+		middleware.Metrics(),
+		gin.Recovery(),
+	)
+	handler.RegisterHealth(router, handler.NewHealthHandler(httpLogger, checker))
+	handler.RegisterDocs(router, handler.NewDocsHandler(httpLogger))
+	if c.providers.local != nil && cfg.Features.LocalSignIn {
+		handler.RegisterLocalAuth(router, handler.NewLocalAuthHandler(httpLogger, c.providers.local), limiter)
 	}
 
-	log.Println("server shutdown gracefully. bye!")
+	var (
+		userHandler   = handler.NewUserHandler(httpLogger, c.userService)
+		outboxHandler = handler.NewOutboxHandler(httpLogger, c.outboxService)
+		roleHandler   = handler.NewRoleHandler(httpLogger, c.roleService)
+		auditHandler  = handler.NewAuditHandler(httpLogger, c.auditService)
+	)
+	handler.Register(router, httpLogger, userHandler, outboxHandler, roleHandler, auditHandler, *middleware.NewAuthMiddleware(verifier, &c.userService, &c.roleService), limiter, idempotent)
+
+	listen(c.logger, lc, "http server", &http.Server{
+		Addr:         cfg.HTTP.Host,
+		Handler:      router,
+		ReadTimeout:  cfg.HTTP.ReadTimeout,
+		WriteTimeout: cfg.HTTP.WriteTimeout,
+	})
+
+	// registered last so it runs first: fail readiness, then keep serving
+	// until load balancers have noticed and stopped sending traffic
+	lc.OnShutdown("readiness", func(ctx context.Context) error {
+		checker.Drain()
+		select {
+		case <-time.After(cfg.Health.DrainDelay):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+
+	return nil
+}
+
+func rateLimiter(cfg config.RateLimit, c *container, lc *lifecycle.Manager, logger *slog.Logger) middleware.RateLimiter {
+	if !cfg.Enabled {
+		return middleware.RateLimiter{}
+	}
+
+	keys := map[string]middleware.KeyFunc{
+		"ip":        middleware.ByIP,
+		"uid":       middleware.ByUID,
+		"community": middleware.ByCommunity,
+	}
+	rules := make(map[string]middleware.RateRule, len(cfg.Rules))
+	for name, rule := range cfg.Rules {
+		rules[name] = middleware.RateRule{
+			Limit: ratelimit.Limit{Requests: rule.Requests, Per: rule.Per, Burst: rule.Burst},
+			Key:   keys[rule.Key],
+		}
+	}
+
+	var store ratelimit.Store = ratelimit.NewMemoryStore()
+	if cfg.Store == "postgres" {
+		pgStore := db.NewRateLimitStore(c.pool)
+		lc.Go("rate limit purge", func(ctx context.Context) {
+			ticker := time.NewTicker(10 * time.Minute)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					if _, err := pgStore.Purge(ctx); err != nil {
+						logger.ErrorContext(ctx, "rate limit purge failed", "err", err)
+					}
+				}
+			}
+		})
+		store = pgStore
+	}
+
+	return middleware.NewRateLimiter(logger, store, rules)
+}
+
+// idempotentRequests keeps Idempotency-Key responses in the database, so a
+// retry is replayed whichever instance it reaches.
+func idempotentRequests(cfg config.Idempotency, c *container, lc *lifecycle.Manager, logger *slog.Logger) middleware.Idempotency {
+	store := db.NewIdempotencyStore(c.pool)
+	lc.Go("idempotency purge", func(ctx context.Context) {
+		ticker := time.NewTicker(cfg.PurgeInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := store.Purge(ctx); err != nil {
+					logger.ErrorContext(ctx, "idempotency purge failed", "err", err)
+				}
+			}
+		}
+	})
+
+	return middleware.NewIdempotency(logger, store, cfg.TTL, cfg.Lease)
+}
+
+// userPurger removes soft deleted users once they are past the retention
+// period, in batches until none is left.
+func userPurger(cfg config.Retention, c *container, logger *slog.Logger) func(context.Context) {
+	return func(ctx context.Context) {
+		ticker := time.NewTicker(cfg.PurgeInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			for {
+				purged, err := c.userService.PurgeDeletedUsers(ctx, time.Now().Add(-cfg.UserPurgeAfter), cfg.PurgeBatchSize)
+				if err != nil {
+					logger.ErrorContext(ctx, "user purge failed", "err", err)
+					break
+				}
+				if purged > 0 {
+					logger.InfoContext(ctx, "purged deleted users", "count", purged)
+				}
+				if purged < int(cfg.PurgeBatchSize) {
+					break
+				}
+			}
+		}
+	}
+}
+
+func listen(logger *slog.Logger, lc *lifecycle.Manager, name string, server *http.Server) {
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			lc.Fail(name, err)
+		}
+	}()
+	logger.Info("listening", "server", name, "addr", server.Addr)
+
+	lc.OnShutdown(name, server.Shutdown)
 }
@@ -0,0 +1,146 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/dvvnFrtn/capstone-backend/config"
+	"github.com/dvvnFrtn/capstone-backend/infra/db"
+	"github.com/dvvnFrtn/capstone-backend/internal/handler/middleware"
+	"github.com/dvvnFrtn/capstone-backend/internal/health"
+	"github.com/dvvnFrtn/capstone-backend/internal/service"
+	"github.com/dvvnFrtn/capstone-backend/pkg/authx"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type authProviders struct {
+	cfg      config.Auth
+	pool     *pgxpool.Pool
+	local    *authx.LocalIdentity
+	firebase *authx.FirebaseClients
+}
+
+func (p *authProviders) localIdentity() (*authx.LocalIdentity, error) {
+	if p.local != nil {
+		return p.local, nil
+	}
+
+	var store authx.LocalStore
+	if p.cfg.LocalStore == "postgres" {
+		store = db.NewLocalAccountStore(p.pool)
+	} else {
+		store = authx.NewLocalMemoryStore()
+	}
+
+	p.local = authx.NewLocalIdentity(store, p.cfg.LocalSecret, p.cfg.LocalTokenTTL)
+	return p.local, nil
+}
+
+func (p *authProviders) firebaseClients(ctx context.Context) (*authx.FirebaseClients, error) {
+	if p.firebase != nil {
+		return p.firebase, nil
+	}
+
+	clients, err := authx.InitFirebase(ctx, p.cfg.FirebaseKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init firebase: %w", err)
+	}
+
+	p.firebase = clients
+	return p.firebase, nil
+}
+
+func (p *authProviders) authService(ctx context.Context) (service.AuthService, error) {
+	switch p.cfg.Provider {
+	case "local":
+		identity, err := p.localIdentity()
+		if err != nil {
+			return nil, err
+		}
+		return service.NewLocalAuthService(identity), nil
+	case "firebase":
+		client, err := p.firebaseClients(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return service.NewFirebaseAuthService(client.Auth), nil
+	case "supabase":
+		return service.NewSupabaseAuthService(p.supabaseConfig()), nil
+	default:
+		return nil, fmt.Errorf("unsupported auth provider %q", p.cfg.Provider)
+	}
+}
+
+func (p *authProviders) tokenVerifier(ctx context.Context) (middleware.TokenVerifier, error) {
+	switch p.cfg.TokenVerifier {
+	case "local":
+		identity, err := p.localIdentity()
+		if err != nil {
+			return nil, err
+		}
+		return middleware.NewLocalVerifier(identity), nil
+	case "firebase":
+		client, err := p.firebaseClients(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return middleware.NewFirebaseVerifier(client.Auth), nil
+	case "supabase":
+		return middleware.NewSupabaseVerifier(authx.NewSupabase(p.supabaseConfig())), nil
+	case "oidc":
+		return middleware.NewOIDCVerifier(ctx, middleware.OIDCConfig{
+			Issuer:         p.cfg.OIDCIssuer,
+			JWKSURL:        p.cfg.OIDCJWKSURL,
+			Audience:       p.cfg.OIDCAudience,
+			RoleClaim:      p.cfg.OIDCRoleClaim,
+			CommunityClaim: p.cfg.OIDCCommunityClaim,
+		})
+	default:
+		return nil, fmt.Errorf("unsupported token verifier %q", p.cfg.TokenVerifier)
+	}
+}
+
+func (p *authProviders) supabaseConfig() authx.SupabaseConfig {
+	return authx.SupabaseConfig{
+		ProjectReference: p.cfg.SupabaseProjectReference,
+		AnonKey:          p.cfg.SupabaseAnonKey,
+		ServiceRoleKey:   p.cfg.SupabaseServiceRoleKey,
+		AuthURL:          p.cfg.SupabaseAuthURL,
+	}
+}
+
+// firebaseCertsURL serves the keys Firebase ID tokens are signed with.
+const firebaseCertsURL = "https://www.googleapis.com/robot/v1/metadata/x509/securetoken@system.gserviceaccount.com"
+
+// healthChecks probes the identity providers used for account management and
+// token verification. The local provider lives in this process and the
+// database, which are checked on their own.
+func (p *authProviders) healthChecks() []health.Check {
+	var checks []health.Check
+	seen := make(map[string]bool)
+
+	for _, name := range []string{p.cfg.Provider, p.cfg.TokenVerifier} {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		switch name {
+		case "firebase":
+			checks = append(checks, health.HTTP("firebase", firebaseCertsURL, nil))
+		case "supabase":
+			header := http.Header{"Apikey": {p.cfg.SupabaseAnonKey}}
+			checks = append(checks, health.HTTP("supabase", p.supabaseConfig().URL()+"/health", header))
+		case "oidc":
+			url := p.cfg.OIDCJWKSURL
+			if url == "" {
+				url = strings.TrimSuffix(p.cfg.OIDCIssuer, "/") + "/.well-known/openid-configuration"
+			}
+			checks = append(checks, health.HTTP("oidc", url, nil))
+		}
+	}
+
+	return checks
+}
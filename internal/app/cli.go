@@ -0,0 +1,270 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dvvnFrtn/capstone-backend/config"
+	"github.com/dvvnFrtn/capstone-backend/infra/db"
+	"github.com/dvvnFrtn/capstone-backend/internal/handler/middleware"
+	"github.com/dvvnFrtn/capstone-backend/internal/service"
+	"github.com/dvvnFrtn/capstone-backend/internal/types"
+	"github.com/google/uuid"
+)
+
+const usage = `usage: capstone <command> [arguments]
+
+commands:
+  serve                          run the HTTP server (default)
+  migrate up                     apply all pending migrations
+  migrate down [-steps N]        revert N migrations, all when N is 0
+  migrate force VERSION          mark VERSION as applied and clean
+  migrate status                 list migrations and the applied version
+  seed [-password P]             create a demo community with sample users
+  admin create [flags]           register a community and its admin; the
+                                 password is read from ADMIN_PASSWORD or stdin
+`
+
+var errUsage = errors.New("invalid usage")
+
+// Execute runs the command named by args, as given on the command line
+// without the program name.
+func Execute(args []string) error {
+	if len(args) == 0 {
+		args = []string{"serve"}
+	}
+
+	if args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		fmt.Fprint(os.Stdout, usage)
+		return nil
+	}
+
+	var run func(config.App) error
+	load := config.Load
+	switch args[0] {
+	case "serve":
+		run = serve
+	case "migrate":
+		load = config.LoadDatabase
+		run = func(cfg config.App) error { return migrateCommand(os.Stdout, cfg, args[1:]) }
+	case "seed":
+		load = config.LoadCommand
+		run = func(cfg config.App) error { return seedCommand(os.Stdout, cfg, args[1:]) }
+	case "admin":
+		load = config.LoadCommand
+		run = func(cfg config.App) error { return adminCommand(os.Stdin, os.Stdout, cfg, args[1:]) }
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		return fmt.Errorf("%w: unknown command %q", errUsage, args[0])
+	}
+
+	cfg, err := load()
+	if err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+	return run(cfg)
+}
+
+func migrateCommand(out io.Writer, cfg config.App, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: migrate needs one of up, down, force, status", errUsage)
+	}
+
+	m, err := db.NewMigrator(cfg.DB.DSN(), time.Minute)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	switch args[0] {
+	case "up":
+		if err := m.Up(); err != nil {
+			return err
+		}
+	case "down":
+		fs := flag.NewFlagSet("migrate down", flag.ContinueOnError)
+		steps := fs.Int("steps", 1, "number of migrations to revert, 0 reverts all")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		if err := m.Down(*steps); err != nil {
+			return err
+		}
+	case "force":
+		if len(args) != 2 {
+			return fmt.Errorf("%w: migrate force needs a version", errUsage)
+		}
+		version, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("%w: %q is not a version", errUsage, args[1])
+		}
+		if err := m.Force(version); err != nil {
+			return err
+		}
+	case "status":
+		statuses, err := m.Status()
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			mark := " "
+			if s.Applied {
+				mark = "x"
+			}
+			fmt.Fprintf(out, "[%s] %d %s\n", mark, s.Version, s.Name)
+		}
+	default:
+		return fmt.Errorf("%w: unknown migrate command %q", errUsage, args[0])
+	}
+
+	version, dirty, err := m.Version()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "database version %d (dirty: %t)\n", version, dirty)
+	return nil
+}
+
+func adminCommand(in io.Reader, out io.Writer, cfg config.App, args []string) error {
+	if len(args) == 0 || args[0] != "create" {
+		return fmt.Errorf("%w: admin needs the create command", errUsage)
+	}
+
+	var req service.AdminRegistrationRequest
+	fs := flag.NewFlagSet("admin create", flag.ContinueOnError)
+	fs.StringVar(&req.Email, "email", "", "admin email")
+	fs.StringVar(&req.Phone, "phone", "", "admin phone number")
+	fs.StringVar(&req.Fullname, "fullname", "", "admin full name")
+	fs.StringVar(&req.Address, "address", "", "admin address")
+	rt := fs.Int("rt", 0, "RT number of the community")
+	rw := fs.Int("rw", 0, "RW number of the community")
+	fs.StringVar(&req.Subdistrict, "subdistrict", "", "community subdistrict")
+	fs.StringVar(&req.District, "district", "", "community district")
+	fs.StringVar(&req.City, "city", "", "community city")
+	fs.StringVar(&req.Province, "province", "", "community province")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	req.RtNumber, req.RwNumber = int32(*rt), int32(*rw)
+
+	var missing []string
+	fs.VisitAll(func(f *flag.Flag) {
+		if f.Value.String() == "" || f.Value.String() == "0" {
+			missing = append(missing, "-"+f.Name)
+		}
+	})
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: admin create needs %v", errUsage, missing)
+	}
+
+	password, err := readPassword(in)
+	if err != nil {
+		return err
+	}
+	req.Password = password
+
+	ctx := types.WithRequestID(context.Background(), uuid.NewString())
+	c, err := newContainer(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	res, err := c.userService.AdminRegistration(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "created admin %s in community %s\n", res.AdminID, res.CommunityID)
+	return nil
+}
+
+func seedCommand(out io.Writer, cfg config.App, args []string) error {
+	fs := flag.NewFlagSet("seed", flag.ContinueOnError)
+	password := fs.String("password", "password123", "password of every seeded account")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	ctx := types.WithRequestID(context.Background(), uuid.NewString())
+	c, err := newContainer(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	const adminEmail = "admin@demo.local"
+	if c.userService.IsUserExists(ctx, service.IsUserExistsInput{Email: adminEmail}) {
+		fmt.Fprintln(out, "demo community already seeded")
+		return nil
+	}
+
+	reg, err := c.userService.AdminRegistration(ctx, service.AdminRegistrationRequest{
+		Email:       adminEmail,
+		Password:    *password,
+		Phone:       "+6281200000001",
+		Address:     "Jl. Contoh No. 1",
+		Fullname:    "Admin Demo",
+		RtNumber:    1,
+		RwNumber:    1,
+		Subdistrict: "Demo",
+		District:    "Demo",
+		City:        "Demo",
+		Province:    "Demo",
+	})
+	if err != nil {
+		return err
+	}
+
+	permissions, err := c.roleService.RolePermissions(ctx, "admin", reg.CommunityID.String())
+	if err != nil {
+		return err
+	}
+	admin := &middleware.UserClaims{
+		UID:         reg.AdminID.String(),
+		Role:        "admin",
+		CommunityID: reg.CommunityID.String(),
+		Permissions: permissions,
+	}
+
+	residents := []service.AdminCreateUserRequest{
+		{Fullname: "Pengurus Demo", Phone: "+6281200000002", Role: "pengurus"},
+		{Fullname: "Warga Demo Satu", Phone: "+6281200000003", Role: "warga"},
+		{Fullname: "Warga Demo Dua", Phone: "+6281200000004", Role: "warga"},
+	}
+	for _, resident := range residents {
+		resident.Password = *password
+		if _, err := c.userService.AdminCreateUser(ctx, admin, resident); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(out, "seeded community %s with admin %s and %d residents\n", reg.CommunityID, adminEmail, len(residents))
+	return nil
+}
+
+// readPassword takes the password from ADMIN_PASSWORD, or else from the first
+// line of in, so it never shows up in the process list or shell history.
+func readPassword(in io.Reader) (string, error) {
+	if password := os.Getenv("ADMIN_PASSWORD"); password != "" {
+		return password, nil
+	}
+
+	line, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("failed to read password: %w", err)
+	}
+	password := strings.TrimRight(line, "\r\n")
+	if password == "" {
+		return "", fmt.Errorf("%w: admin create needs a password from ADMIN_PASSWORD or stdin", errUsage)
+	}
+	return password, nil
+}
@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/dvvnFrtn/capstone-backend/internal/handler/middleware"
+	"github.com/dvvnFrtn/capstone-backend/internal/handler/response"
+	"github.com/dvvnFrtn/capstone-backend/internal/service"
+	"github.com/dvvnFrtn/capstone-backend/pkg/errs"
+	"github.com/gin-gonic/gin"
+)
+
+type AuditHandler struct {
+	auditService service.AuditService
+	logger       *slog.Logger
+}
+
+func NewAuditHandler(logger *slog.Logger, as service.AuditService) AuditHandler {
+	return AuditHandler{
+		auditService: as,
+		logger:       logger,
+	}
+}
+
+func (h *AuditHandler) GetEvents(ctx *gin.Context) {
+	const op errs.Op = "handler.audit.GetEvents"
+
+	var req service.ListAuditRequest
+	if err := bindQuery(ctx, op, &req); err != nil {
+		response.SendRESTError(ctx, err)
+		return
+	}
+
+	claims := middleware.GetUserClaims(ctx)
+
+	page, err := h.auditService.ListEvents(ctx, claims, req)
+	if err != nil {
+		response.SendRESTError(ctx, err)
+		return
+	}
+
+	response.SendRESTPage(ctx, http.StatusOK, "Riwayat perubahan berhasil dimuat", page.Events, response.Meta{
+		Total:      page.Total,
+		NextCursor: page.NextCursor,
+	})
+}
@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"github.com/dvvnFrtn/capstone-backend/internal/validation"
+	"github.com/dvvnFrtn/capstone-backend/pkg/errs"
+	"github.com/gin-gonic/gin"
+)
+
+// bindJSON decodes the request body into req. Validation failures become a
+// BadRequest listing every invalid field, in the language the client asked
+// for.
+func bindJSON(ctx *gin.Context, op errs.Op, req any) error {
+	if err := validation.Setup(); err != nil {
+		return errs.New(op, errs.Internal, err)
+	}
+
+	return bindWith(ctx, op, req, ctx.ShouldBindJSON)
+}
+
+// bindQuery is bindJSON for the query string.
+func bindQuery(ctx *gin.Context, op errs.Op, req any) error {
+	if err := validation.Setup(); err != nil {
+		return errs.New(op, errs.Internal, err)
+	}
+
+	return bindWith(ctx, op, req, ctx.ShouldBindQuery)
+}
+
+func bindWith(ctx *gin.Context, op errs.Op, req any, bind func(any) error) error {
+	if err := bind(req); err != nil {
+		lang := validation.Lang(ctx.GetHeader("Accept-Language"))
+		return errs.New(op, errs.BadRequest, errs.Msg("Request tidak valid"), validation.Fields(err, lang), err)
+	}
+	return nil
+}
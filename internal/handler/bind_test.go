@@ -0,0 +1,124 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dvvnFrtn/capstone-backend/internal/handler"
+	"github.com/dvvnFrtn/capstone-backend/internal/handler/response"
+	"github.com/dvvnFrtn/capstone-backend/internal/service"
+	"github.com/dvvnFrtn/capstone-backend/pkg/errs"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminSignup_FieldErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	uh := handler.NewUserHandler(slog.New(slog.DiscardHandler), service.UserService{})
+	r := gin.New()
+	r.POST("/signup", uh.AdminSignup)
+
+	body := `{
+		"email": "admin@example.com",
+		"password": "password123",
+		"phone": "0812",
+		"address": "Jl. Contoh",
+		"fullname": "Admin",
+		"rt_number": 1,
+		"rw_number": 0,
+		"subdistrict": "a", "district": "b", "city": "c", "province": "d"
+	}`
+	req := httptest.NewRequest(http.MethodPost, "/signup", strings.NewReader(body))
+	req.Header.Set("Accept-Language", "en")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	var res response.RESTResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	assert.Equal(t, errs.BadRequest.String(), res.Code)
+	assert.Equal(t, []errs.FieldError{
+		{Field: "phone", Rule: "phone_id", Message: "phone must be an Indonesian mobile number formatted as +628xxxxxxxx"},
+		{Field: "rw_number", Rule: "required", Message: "rw_number is a required field"},
+	}, res.Errors)
+}
+
+func TestAdminSignup_TypeMismatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	uh := handler.NewUserHandler(slog.New(slog.DiscardHandler), service.UserService{})
+	r := gin.New()
+	r.POST("/signup", uh.AdminSignup)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/signup", strings.NewReader(`{"rt_number": "satu"}`)))
+
+	var res response.RESTResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	require.Len(t, res.Errors, 1)
+	assert.Equal(t, "rt_number", res.Errors[0].Field)
+	assert.Equal(t, "type", res.Errors[0].Rule)
+}
+
+func TestGetUsersCommunity_QueryErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	uh := handler.NewUserHandler(slog.New(slog.DiscardHandler), service.UserService{})
+	r := gin.New()
+	r.GET("/users", uh.GetUsersCommunity)
+
+	req := httptest.NewRequest(http.MethodGet, "/users?sort=email&limit=500", nil)
+	req.Header.Set("Accept-Language", "en")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	var res response.RESTResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	require.Len(t, res.Errors, 2)
+	assert.Equal(t, "sort", res.Errors[0].Field)
+	assert.Equal(t, "oneof", res.Errors[0].Rule)
+	assert.Equal(t, "limit", res.Errors[1].Field)
+	assert.Equal(t, "max", res.Errors[1].Rule)
+}
+
+func TestSearchUsers_RequiresQuery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	uh := handler.NewUserHandler(slog.New(slog.DiscardHandler), service.UserService{})
+	r := gin.New()
+	r.GET("/users/search", uh.SearchUsers)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/search?q=a", nil))
+
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	var res response.RESTResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	require.Len(t, res.Errors, 1)
+	assert.Equal(t, "q", res.Errors[0].Field)
+	assert.Equal(t, "min", res.Errors[0].Rule)
+}
+
+func TestGetAuditEvents_QueryErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ah := handler.NewAuditHandler(slog.New(slog.DiscardHandler), service.AuditService{})
+	r := gin.New()
+	r.GET("/audit", ah.GetEvents)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/audit?actor_id=admin", nil))
+
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	var res response.RESTResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	require.Len(t, res.Errors, 1)
+	assert.Equal(t, "actor_id", res.Errors[0].Field)
+	assert.Equal(t, "uuid", res.Errors[0].Rule)
+}
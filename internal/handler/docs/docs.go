@@ -0,0 +1,40 @@
+// Package docs holds the OpenAPI document of the API and the page that
+// renders it, both embedded in the binary.
+package docs
+
+import (
+	_ "embed"
+	"encoding/json"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	//go:embed openapi.yaml
+	specYAML []byte
+
+	//go:embed index.html
+	page []byte
+)
+
+// YAML is the OpenAPI document as written.
+func YAML() []byte {
+	return specYAML
+}
+
+// JSON is the OpenAPI document converted to JSON, for clients that do not
+// read YAML.
+var JSON = sync.OnceValues(func() ([]byte, error) {
+	var doc any
+	if err := yaml.Unmarshal(specYAML, &doc); err != nil {
+		return nil, err
+	}
+	return json.Marshal(doc)
+})
+
+// Page is the documentation viewer. It loads openapi.json from below its own
+// URL and needs nothing from outside the API.
+func Page() []byte {
+	return page
+}
@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/dvvnFrtn/capstone-backend/internal/handler/docs"
+	"github.com/dvvnFrtn/capstone-backend/internal/handler/response"
+	"github.com/dvvnFrtn/capstone-backend/pkg/errs"
+	"github.com/gin-gonic/gin"
+)
+
+type DocsHandler struct {
+	logger *slog.Logger
+}
+
+func NewDocsHandler(logger *slog.Logger) DocsHandler {
+	return DocsHandler{logger: logger}
+}
+
+func (h *DocsHandler) Page(ctx *gin.Context) {
+	ctx.Data(http.StatusOK, "text/html; charset=utf-8", docs.Page())
+}
+
+func (h *DocsHandler) YAML(ctx *gin.Context) {
+	ctx.Data(http.StatusOK, "application/yaml", docs.YAML())
+}
+
+func (h *DocsHandler) JSON(ctx *gin.Context) {
+	const op errs.Op = "handler.docs.JSON"
+
+	spec, err := docs.JSON()
+	if err != nil {
+		response.SendRESTError(ctx, errs.New(op, errs.Internal, err))
+		return
+	}
+	ctx.Data(http.StatusOK, "application/json", spec)
+}
@@ -0,0 +1,453 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dvvnFrtn/capstone-backend/internal/handler"
+	"github.com/dvvnFrtn/capstone-backend/internal/handler/docs"
+	"github.com/dvvnFrtn/capstone-backend/internal/handler/middleware"
+	"github.com/dvvnFrtn/capstone-backend/internal/handler/response"
+	"github.com/dvvnFrtn/capstone-backend/internal/health"
+	"github.com/dvvnFrtn/capstone-backend/internal/service"
+	"github.com/dvvnFrtn/capstone-backend/pkg/errs"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+type openAPIDoc struct {
+	OpenAPI    string                                 `yaml:"openapi"`
+	Paths      map[string]map[string]openAPIOperation `yaml:"paths"`
+	Components struct {
+		Parameters map[string]openAPIParameter `yaml:"parameters"`
+		Schemas    map[string]*openAPISchema   `yaml:"schemas"`
+	} `yaml:"components"`
+}
+
+type openAPIOperation struct {
+	Parameters  []openAPIParameter `yaml:"parameters"`
+	RequestBody *struct {
+		Content map[string]openAPIMedia `yaml:"content"`
+	} `yaml:"requestBody"`
+	Responses map[string]struct {
+		Content map[string]openAPIMedia `yaml:"content"`
+	} `yaml:"responses"`
+}
+
+type openAPIMedia struct {
+	Schema *openAPISchema `yaml:"schema"`
+}
+
+type openAPIParameter struct {
+	Ref      string `yaml:"$ref"`
+	Name     string `yaml:"name"`
+	In       string `yaml:"in"`
+	Required bool   `yaml:"required"`
+}
+
+type openAPISchema struct {
+	Ref        string                    `yaml:"$ref"`
+	Type       string                    `yaml:"type"`
+	Properties map[string]*openAPISchema `yaml:"properties"`
+	Required   []string                  `yaml:"required"`
+	AllOf      []*openAPISchema          `yaml:"allOf"`
+	Items      *openAPISchema            `yaml:"items"`
+	Enum       []string                  `yaml:"enum"`
+}
+
+func loadOpenAPI(t *testing.T) openAPIDoc {
+	var doc openAPIDoc
+	require.NoError(t, yaml.Unmarshal(docs.YAML(), &doc))
+	return doc
+}
+
+func (doc openAPIDoc) schema(ref string) *openAPISchema {
+	return doc.Components.Schemas[strings.TrimPrefix(ref, "#/components/schemas/")]
+}
+
+func (doc openAPIDoc) parameter(p openAPIParameter) openAPIParameter {
+	if p.Ref == "" {
+		return p
+	}
+	return doc.Components.Parameters[strings.TrimPrefix(p.Ref, "#/components/parameters/")]
+}
+
+// properties flattens s, following references and allOf, into its
+// properties and the names of the required ones.
+func (doc openAPIDoc) properties(s *openAPISchema) (map[string]*openAPISchema, []string) {
+	props := make(map[string]*openAPISchema)
+	var required []string
+	if s == nil {
+		return props, nil
+	}
+	if s.Ref != "" {
+		return doc.properties(doc.schema(s.Ref))
+	}
+	for _, part := range s.AllOf {
+		p, r := doc.properties(part)
+		for name, prop := range p {
+			props[name] = prop
+		}
+		required = append(required, r...)
+	}
+	for name, prop := range s.Properties {
+		props[name] = prop
+	}
+	return props, append(required, s.Required...)
+}
+
+func newDocumentedRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	logger := slog.New(slog.DiscardHandler)
+	r := gin.New()
+	handler.Register(r, logger, handler.UserHandler{}, handler.OutboxHandler{}, handler.RoleHandler{}, handler.AuditHandler{}, middleware.Auth{}, middleware.RateLimiter{}, middleware.Idempotency{})
+	handler.RegisterLocalAuth(r, handler.LocalAuthHandler{}, middleware.RateLimiter{})
+	handler.RegisterHealth(r, handler.HealthHandler{})
+	handler.RegisterDocs(r, handler.NewDocsHandler(logger))
+	return r
+}
+
+var pathParam = regexp.MustCompile(`:(\w+)`)
+
+func TestOpenAPI_DocumentsEveryRoute(t *testing.T) {
+	doc := loadOpenAPI(t)
+	assert.Equal(t, "3.0.3", doc.OpenAPI)
+
+	var routes []string
+	for _, route := range newDocumentedRouter().Routes() {
+		routes = append(routes, route.Method+" "+pathParam.ReplaceAllString(route.Path, "{$1}"))
+	}
+
+	var documented []string
+	for path, item := range doc.Paths {
+		for method := range item {
+			documented = append(documented, strings.ToUpper(method)+" "+path)
+		}
+	}
+
+	assert.ElementsMatch(t, routes, documented)
+}
+
+// schemaTypes are the Go types behind the component schemas.
+var schemaTypes = map[string]any{
+	"RESTResponse":              response.RESTResponse{},
+	"Meta":                      response.Meta{},
+	"FieldError":                errs.FieldError{},
+	"AdminRegistrationRequest":  service.AdminRegistrationRequest{},
+	"AdminRegistrationResponse": service.AdminRegistrationResponse{},
+	"LocalSignInRequest":        handler.LocalSignInRequest{},
+	"LocalSignInResponse":       handler.LocalSignInResponse{},
+	"AdminCreateUserRequest":    service.AdminCreateUserRequest{},
+	"AdminUpdateUserRequest":    service.AdminUpdateUserRequest{},
+	"IDResponse":                service.IDResponse{},
+	"UserVersionResponse":       service.UserVersionResponse{},
+	"CommunityResponse":         service.CommunityResponse{},
+	"UserResponse":              service.UserResponse{},
+	"UserSearchResult":          service.UserSearchResult{},
+	"UserHighlight":             service.UserHighlight{},
+	"OutboxEventResponse":       service.OutboxEventResponse{},
+	"PermissionResponse":        service.PermissionResponse{},
+	"RoleResponse":              service.RoleResponse{},
+	"CreateRoleRequest":         service.CreateRoleRequest{},
+	"UpdateRoleRequest":         service.UpdateRoleRequest{},
+	"AuditEventResponse":        service.AuditEventResponse{},
+	"AuditChange":               service.AuditChange{},
+	"HealthReport":              health.Report{},
+	"HealthResult":              health.Result{},
+}
+
+// schemasWithoutType are enums and envelopes with no struct of their own.
+var schemasWithoutType = []string{"ErrorCode", "HealthStatus", "HealthResponse"}
+
+type goField struct {
+	typ      reflect.Type
+	tag      reflect.StructTag
+	required bool
+}
+
+// goFields lists the fields of t by the name in tag, following embedded
+// structs the way encoding/json does.
+func goFields(t reflect.Type, tag string) map[string]goField {
+	fields := make(map[string]goField)
+	for i := range t.NumField() {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get(tag), ",")
+		if f.Anonymous && name == "" {
+			embedded := f.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			for n, ef := range goFields(embedded, tag) {
+				fields[n] = ef
+			}
+			continue
+		}
+		if !f.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = goField{
+			typ:      f.Type,
+			tag:      f.Tag,
+			required: slices.Contains(strings.Split(f.Tag.Get("binding"), ","), "required"),
+		}
+	}
+	return fields
+}
+
+// openAPIType is the schema type t is encoded as, or "" for any.
+func openAPIType(t reflect.Type) string {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t {
+	case reflect.TypeFor[time.Time](), reflect.TypeFor[uuid.UUID]():
+		return "string"
+	case reflect.TypeFor[json.RawMessage]():
+		return "object"
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	default:
+		return ""
+	}
+}
+
+func TestOpenAPI_SchemasMatchGoTypes(t *testing.T) {
+	doc := loadOpenAPI(t)
+
+	for name, schema := range doc.Components.Schemas {
+		v, ok := schemaTypes[name]
+		if !ok {
+			assert.Contains(t, schemasWithoutType, name, "schema %s has no Go type in schemaTypes", name)
+			continue
+		}
+
+		t.Run(name, func(t *testing.T) {
+			fields := goFields(reflect.TypeOf(v), "json")
+			props, required := doc.properties(schema)
+
+			assert.ElementsMatch(t, slices.Collect(maps.Keys(fields)), slices.Collect(maps.Keys(props)), "properties")
+			for prop, s := range props {
+				f, ok := fields[prop]
+				if !ok || s.Type == "" {
+					continue
+				}
+				if want := openAPIType(f.typ); want != "" {
+					assert.Equal(t, want, s.Type, "type of %s", prop)
+				}
+			}
+
+			if strings.HasSuffix(name, "Request") {
+				var bound []string
+				for prop, f := range fields {
+					if f.required {
+						bound = append(bound, prop)
+					}
+				}
+				assert.ElementsMatch(t, bound, required, "required")
+			}
+		})
+	}
+}
+
+// operationTypes are the Go types an operation binds its request from and
+// sends as data, nil where it has none.
+var operationTypes = map[string]struct {
+	body  any
+	query any
+	data  any
+}{
+	"POST /api/auth/signup":             {body: service.AdminRegistrationRequest{}, data: &service.AdminRegistrationResponse{}},
+	"POST /api/auth/local/signin":       {body: handler.LocalSignInRequest{}, data: handler.LocalSignInResponse{}},
+	"POST /api/users":                   {body: service.AdminCreateUserRequest{}, data: &service.IDResponse{}},
+	"GET /api/users":                    {query: service.ListUsersRequest{}, data: []*service.UserResponse{}},
+	"GET /api/users/search":             {query: service.SearchUsersRequest{}, data: []*service.UserSearchResult{}},
+	"GET /api/users/{userID}":           {data: &service.UserResponse{}},
+	"PATCH /api/users/{userID}":         {body: service.AdminUpdateUserRequest{}, data: &service.UserVersionResponse{}},
+	"DELETE /api/users/{userID}":        {},
+	"POST /api/users/{userID}/restore":  {data: &service.IDResponse{}},
+	"GET /api/outbox":                   {data: []*service.OutboxEventResponse{}},
+	"POST /api/outbox/{eventID}/replay": {data: &service.OutboxEventResponse{}},
+	"GET /api/permissions":              {data: []*service.PermissionResponse{}},
+	"GET /api/roles":                    {data: []*service.RoleResponse{}},
+	"POST /api/roles":                   {body: service.CreateRoleRequest{}, data: &service.RoleResponse{}},
+	"PATCH /api/roles/{roleID}":         {body: service.UpdateRoleRequest{}},
+	"DELETE /api/roles/{roleID}":        {},
+	"GET /api/audit":                    {query: service.ListAuditRequest{}, data: []*service.AuditEventResponse{}},
+	"GET /healthz":                      {},
+	"GET /readyz":                       {data: health.Report{}},
+}
+
+func TestOpenAPI_OperationsMatchGoTypes(t *testing.T) {
+	doc := loadOpenAPI(t)
+
+	for path, item := range doc.Paths {
+		for method, op := range item {
+			key := strings.ToUpper(method) + " " + path
+			if strings.HasPrefix(path, "/api/docs") {
+				continue
+			}
+			types, ok := operationTypes[key]
+			if !assert.True(t, ok, "%s is missing from operationTypes", key) {
+				continue
+			}
+
+			t.Run(key, func(t *testing.T) {
+				var body reflect.Type
+				if op.RequestBody != nil {
+					body = reflect.TypeOf(schemaTypes[strings.TrimPrefix(op.RequestBody.Content["application/json"].Schema.Ref, "#/components/schemas/")])
+				}
+				assert.Equal(t, reflect.TypeOf(types.body), body, "request body")
+
+				query := make(map[string]bool)
+				for _, p := range op.Parameters {
+					if p = doc.parameter(p); p.In == "query" {
+						query[p.Name] = p.Required
+					}
+				}
+				bound := make(map[string]bool)
+				if types.query != nil {
+					for name, f := range goFields(reflect.TypeOf(types.query), "form") {
+						bound[name] = f.required
+					}
+				}
+				assert.Equal(t, bound, query, "query parameters")
+
+				assert.Equal(t, dataType(types.data), documentedData(doc, op), "data")
+			})
+		}
+	}
+}
+
+// dataType names the schema of the data sent by a handler, "T" or "[]T".
+func dataType(v any) string {
+	if v == nil {
+		return ""
+	}
+	t := reflect.TypeOf(v)
+	prefix := ""
+	if t.Kind() == reflect.Slice {
+		prefix, t = "[]", t.Elem()
+	}
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	for name, schemaType := range schemaTypes {
+		if reflect.TypeOf(schemaType) == t {
+			return prefix + name
+		}
+	}
+	return prefix + t.String()
+}
+
+// documentedData names the schema of data in the first successful response.
+func documentedData(doc openAPIDoc, op openAPIOperation) string {
+	var statuses []string
+	for status := range op.Responses {
+		statuses = append(statuses, status)
+	}
+	slices.Sort(statuses)
+
+	media, ok := op.Responses[statuses[0]].Content["application/json"]
+	if !ok {
+		return ""
+	}
+	props, _ := doc.properties(media.Schema)
+	data := props["data"]
+	if data == nil || (data.Ref == "" && data.Items == nil) {
+		return ""
+	}
+	if data.Items != nil {
+		return "[]" + strings.TrimPrefix(data.Items.Ref, "#/components/schemas/")
+	}
+	return strings.TrimPrefix(data.Ref, "#/components/schemas/")
+}
+
+func TestOpenAPI_ErrorCodes(t *testing.T) {
+	doc := loadOpenAPI(t)
+
+	var codes []string
+	for c := errs.Unexpected; c.String() != "unknown_error"; c++ {
+		codes = append(codes, c.String())
+	}
+	assert.ElementsMatch(t, codes, doc.Components.Schemas["ErrorCode"].Enum)
+}
+
+func TestOpenAPI_ReferencesResolve(t *testing.T) {
+	var doc any
+	require.NoError(t, yaml.Unmarshal(docs.YAML(), &doc))
+
+	var walk func(node any)
+	walk = func(node any) {
+		switch n := node.(type) {
+		case map[string]any:
+			if ref, ok := n["$ref"].(string); ok {
+				target := doc
+				for _, key := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+					m, _ := target.(map[string]any)
+					target = m[key]
+				}
+				assert.NotNil(t, target, "unresolved $ref %s", ref)
+			}
+			for _, child := range n {
+				walk(child)
+			}
+		case []any:
+			for _, child := range n {
+				walk(child)
+			}
+		}
+	}
+	walk(doc)
+}
+
+func TestDocs_Served(t *testing.T) {
+	r := newDocumentedRouter()
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/docs", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Header().Get("Content-Type"), "text/html")
+	assert.NotContains(t, rec.Body.String(), "https://", "the viewer is bundled, not loaded from a CDN")
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/docs/openapi.json", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var spec struct {
+		OpenAPI string `json:"openapi"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &spec))
+	assert.Equal(t, "3.0.3", spec.OpenAPI)
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/docs/openapi.yaml", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, docs.YAML(), rec.Body.Bytes())
+}
@@ -4,51 +4,156 @@ import (
 	"log/slog"
 
 	"github.com/dvvnFrtn/capstone-backend/internal/handler/middleware"
+	"github.com/dvvnFrtn/capstone-backend/internal/policy"
 	"github.com/gin-gonic/gin"
 )
 
-func Register(r *gin.Engine, logger *slog.Logger, uh UserHandler, fb middleware.Firebase) {
+func Register(r *gin.Engine, logger *slog.Logger, uh UserHandler, oh OutboxHandler, rh RoleHandler, ah AuditHandler, au middleware.Auth, rl middleware.RateLimiter, im middleware.Idempotency) {
+	// Lets services read values such as the request ID from the *gin.Context
+	// they are handed, through the request's own context.
+	r.ContextWithFallback = true
+
 	// Auth
 	r.POST(
 		"/api/auth/signup",
-		middleware.RequestContext(),
+		rl.For("signup"),
+		im.Handler(),
 		uh.AdminSignup,
 	)
 
 	// Users
 	r.POST(
 		"/api/users",
-		middleware.RequestContext(),
-		fb.MustAuthenticated(logger),
-		middleware.MustHaveRole(logger, "admin"),
+		au.MustAuthenticated(logger),
+		rl.For("user", "community"),
+		middleware.RequirePermission(logger, policy.UsersCreate),
+		im.Handler(),
 		uh.AdminCreateUser,
 	)
 	r.GET(
 		"/api/users",
-		middleware.RequestContext(),
-		fb.MustAuthenticated(logger),
-		middleware.MustHaveRole(logger, "admin", "pengurus"),
+		au.MustAuthenticated(logger),
+		rl.For("user", "community"),
+		middleware.RequirePermission(logger, policy.UsersRead),
 		uh.GetUsersCommunity,
 	)
+	r.GET(
+		"/api/users/search",
+		au.MustAuthenticated(logger),
+		rl.For("user", "community"),
+		middleware.RequirePermission(logger, policy.UsersRead),
+		uh.SearchUsers,
+	)
 	r.GET(
 		"/api/users/:userID",
-		middleware.RequestContext(),
-		fb.MustAuthenticated(logger),
-		middleware.MustHaveRole(logger, "admin", "pengurus", "warga"),
+		au.MustAuthenticated(logger),
+		rl.For("user", "community"),
+		middleware.RequirePermission(logger, policy.UsersRead, policy.UsersReadSelf),
 		uh.GetUser,
 	)
 	r.PATCH(
 		"/api/users/:userID",
-		middleware.RequestContext(),
-		fb.MustAuthenticated(logger),
-		middleware.MustHaveRole(logger, "admin"),
+		au.MustAuthenticated(logger),
+		rl.For("user", "community"),
+		middleware.RequirePermission(logger, policy.UsersUpdate),
 		uh.AdminUpdateUser,
 	)
 	r.DELETE(
 		"/api/users/:userID",
-		middleware.RequestContext(),
-		fb.MustAuthenticated(logger),
-		middleware.MustHaveRole(logger, "admin"),
+		au.MustAuthenticated(logger),
+		rl.For("user", "community"),
+		middleware.RequirePermission(logger, policy.UsersDelete),
 		uh.AdminDeleteUser,
 	)
+	r.POST(
+		"/api/users/:userID/restore",
+		au.MustAuthenticated(logger),
+		rl.For("user", "community"),
+		middleware.RequirePermission(logger, policy.UsersDelete),
+		im.Handler(),
+		uh.AdminRestoreUser,
+	)
+
+	// Outbox
+	r.GET(
+		"/api/outbox",
+		au.MustAuthenticated(logger),
+		rl.For("user", "community"),
+		middleware.RequirePermission(logger, policy.OutboxRead),
+		oh.GetStuckEvents,
+	)
+	r.POST(
+		"/api/outbox/:eventID/replay",
+		au.MustAuthenticated(logger),
+		rl.For("user", "community"),
+		middleware.RequirePermission(logger, policy.OutboxReplay),
+		im.Handler(),
+		oh.ReplayEvent,
+	)
+
+	// Roles
+	r.GET(
+		"/api/permissions",
+		au.MustAuthenticated(logger),
+		rl.For("user", "community"),
+		middleware.RequirePermission(logger, policy.RolesRead),
+		rh.GetPermissions,
+	)
+	r.GET(
+		"/api/roles",
+		au.MustAuthenticated(logger),
+		rl.For("user", "community"),
+		middleware.RequirePermission(logger, policy.RolesRead),
+		rh.GetRoles,
+	)
+	r.POST(
+		"/api/roles",
+		au.MustAuthenticated(logger),
+		rl.For("user", "community"),
+		middleware.RequirePermission(logger, policy.RolesManage),
+		im.Handler(),
+		rh.CreateRole,
+	)
+	r.PATCH(
+		"/api/roles/:roleID",
+		au.MustAuthenticated(logger),
+		rl.For("user", "community"),
+		middleware.RequirePermission(logger, policy.RolesManage),
+		rh.UpdateRole,
+	)
+	r.DELETE(
+		"/api/roles/:roleID",
+		au.MustAuthenticated(logger),
+		rl.For("user", "community"),
+		middleware.RequirePermission(logger, policy.RolesManage),
+		rh.DeleteRole,
+	)
+
+	// Audit
+	r.GET(
+		"/api/audit",
+		au.MustAuthenticated(logger),
+		rl.For("user", "community"),
+		middleware.RequirePermission(logger, policy.AuditRead),
+		ah.GetEvents,
+	)
+}
+
+func RegisterLocalAuth(r *gin.Engine, lh LocalAuthHandler, rl middleware.RateLimiter) {
+	r.POST(
+		"/api/auth/local/signin",
+		rl.For("signin"),
+		lh.SignIn,
+	)
+}
+
+func RegisterDocs(r *gin.Engine, dh DocsHandler) {
+	r.GET("/api/docs", dh.Page)
+	r.GET("/api/docs/openapi.yaml", dh.YAML)
+	r.GET("/api/docs/openapi.json", dh.JSON)
+}
+
+func RegisterHealth(r *gin.Engine, hh HealthHandler) {
+	r.GET("/healthz", hh.Live)
+	r.GET("/readyz", hh.Ready)
 }
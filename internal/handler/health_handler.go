@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/dvvnFrtn/capstone-backend/internal/handler/response"
+	"github.com/dvvnFrtn/capstone-backend/internal/health"
+	"github.com/gin-gonic/gin"
+)
+
+type HealthHandler struct {
+	logger  *slog.Logger
+	checker *health.Checker
+}
+
+func NewHealthHandler(logger *slog.Logger, checker *health.Checker) HealthHandler {
+	return HealthHandler{logger: logger, checker: checker}
+}
+
+// Live only tells that the process is serving requests; it never checks
+// dependencies, so a database outage does not get the process restarted.
+func (h *HealthHandler) Live(ctx *gin.Context) {
+	response.SendRESTSuccess(ctx, http.StatusOK, "Layanan berjalan", nil)
+}
+
+func (h *HealthHandler) Ready(ctx *gin.Context) {
+	report := h.checker.Ready(ctx)
+	for name, res := range report.Checks {
+		if res.Status != health.StatusUp {
+			h.logger.WarnContext(ctx, "dependency not ready", "check", name, "err", res.Error)
+		}
+	}
+	if report.Status != health.StatusUp {
+		response.SendRESTSuccess(ctx, http.StatusServiceUnavailable, "Layanan belum siap", report)
+		return
+	}
+
+	response.SendRESTSuccess(ctx, http.StatusOK, "Layanan siap", report)
+}
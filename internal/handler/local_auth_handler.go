@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/dvvnFrtn/capstone-backend/internal/handler/response"
+	"github.com/dvvnFrtn/capstone-backend/pkg/authx"
+	"github.com/dvvnFrtn/capstone-backend/pkg/errs"
+	"github.com/gin-gonic/gin"
+)
+
+type LocalAuthHandler struct {
+	identity *authx.LocalIdentity
+	logger   *slog.Logger
+}
+
+func NewLocalAuthHandler(logger *slog.Logger, identity *authx.LocalIdentity) LocalAuthHandler {
+	return LocalAuthHandler{
+		identity: identity,
+		logger:   logger,
+	}
+}
+
+type LocalSignInRequest struct {
+	Login    string `json:"login" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+type LocalSignInResponse struct {
+	IDToken string `json:"id_token"`
+}
+
+func (h *LocalAuthHandler) SignIn(ctx *gin.Context) {
+	const op errs.Op = "handler.localauth.SignIn"
+
+	var req LocalSignInRequest
+	if err := bindJSON(ctx, op, &req); err != nil {
+		response.SendRESTError(ctx, err)
+		return
+	}
+
+	token, err := h.identity.SignIn(ctx, req.Login, req.Password)
+	if err != nil {
+		if errors.Is(err, authx.ErrInvalidCredentials) || errors.Is(err, authx.ErrAccountDisabled) {
+			response.SendRESTError(ctx, errs.New(op, errs.Unauthorize, errs.Msg("Login atau password salah"), err))
+			return
+		}
+		response.SendRESTError(ctx, errs.New(op, errs.Internal, err))
+		return
+	}
+
+	response.SendRESTSuccess(ctx, http.StatusOK, "Login berhasil", LocalSignInResponse{IDToken: token})
+}
@@ -1,12 +1,14 @@
 package middleware
 
 import (
+	"context"
 	"log/slog"
 	"slices"
 	"strings"
+	"time"
 
-	"firebase.google.com/go/v4/auth"
 	"github.com/dvvnFrtn/capstone-backend/internal/handler/response"
+	"github.com/dvvnFrtn/capstone-backend/internal/policy"
 	"github.com/dvvnFrtn/capstone-backend/pkg/errs"
 	"github.com/gin-gonic/gin"
 )
@@ -15,40 +17,95 @@ type UserClaims struct {
 	UID         string
 	Role        string
 	CommunityID string
+	IssuedAt    time.Time
+	Permissions []string
 }
 
-type Firebase struct {
-	Client *auth.Client
+func (c *UserClaims) HasPermission(action policy.Action) bool {
+	return c != nil && slices.Contains(c.Permissions, string(action))
 }
 
-func NewFirebaseAuthMiddleware(client *auth.Client) *Firebase {
-	return &Firebase{Client: client}
+func (c *UserClaims) Subject() policy.Subject {
+	if c == nil {
+		return policy.Subject{}
+	}
+	return policy.Subject{
+		UID:         c.UID,
+		CommunityID: c.CommunityID,
+		Permissions: c.Permissions,
+	}
+}
+
+type TokenVerifier interface {
+	VerifyToken(ctx context.Context, token string) (*UserClaims, error)
+}
+
+// UserAccess is what the database currently grants a user. ValidAfter is
+// the moment before which their tokens are no longer accepted, zero when
+// they were never revoked.
+type UserAccess struct {
+	Role        string
+	CommunityID string
+	ValidAfter  time.Time
+}
+
+// TokenRevocations looks up a user's current access, so tokens carrying
+// outdated claims can be rejected.
+type TokenRevocations interface {
+	UserAccess(ctx context.Context, uid string) (UserAccess, error)
+}
+
+// PermissionResolver maps a role within a community to the permissions
+// granted to it. Roles are stored per community, so the result may change
+// without the token being re-issued.
+type PermissionResolver interface {
+	RolePermissions(ctx context.Context, role, communityID string) ([]string, error)
+}
+
+type Auth struct {
+	Verifier    TokenVerifier
+	Revocations TokenRevocations
+	Permissions PermissionResolver
+}
+
+func NewAuthMiddleware(verifier TokenVerifier, revocations TokenRevocations, permissions PermissionResolver) *Auth {
+	return &Auth{Verifier: verifier, Revocations: revocations, Permissions: permissions}
 }
 
-func (f *Firebase) MustAuthenticated(logger *slog.Logger) gin.HandlerFunc {
+func (a *Auth) MustAuthenticated(logger *slog.Logger) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		const op errs.Op = "middleware.firebase.MustAuthenticated"
+		const op errs.Op = "middleware.auth.MustAuthenticated"
 
 		authHeader := ctx.GetHeader("Authorization")
 		if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
-			response.SendRESTError(ctx, logger, errs.New(op, errs.Unauthorize, "missing or invalid authorization header"))
+			response.SendRESTError(ctx, errs.New(op, errs.Unauthorize, "missing or invalid authorization header"))
 			ctx.Abort()
 			return
 		}
 
 		idToken := strings.TrimPrefix(authHeader, "Bearer ")
 
-		token, err := f.Client.VerifyIDToken(ctx, idToken)
+		userClaims, err := a.Verifier.VerifyToken(ctx, idToken)
 		if err != nil {
-			response.SendRESTError(ctx, logger, errs.New(op, errs.Unauthorize, "invalid token"))
+			response.SendRESTError(ctx, errs.New(op, errs.Unauthorize, "invalid token"))
 			ctx.Abort()
 			return
 		}
 
-		userClaims := &UserClaims{
-			UID:         token.UID,
-			Role:        toString(token.Claims["role"]),
-			CommunityID: toString(token.Claims["community_id"]),
+		if err := a.checkRevoked(ctx, userClaims); err != nil {
+			response.SendRESTError(ctx, errs.New(op, errs.Unauthorize, err))
+			ctx.Abort()
+			return
+		}
+
+		if a.Permissions != nil {
+			permissions, err := a.Permissions.RolePermissions(ctx, userClaims.Role, userClaims.CommunityID)
+			if err != nil {
+				response.SendRESTError(ctx, errs.New(op, err))
+				ctx.Abort()
+				return
+			}
+			userClaims.Permissions = permissions
 		}
 
 		ctx.Set("claims", userClaims)
@@ -56,17 +113,52 @@ func (f *Firebase) MustAuthenticated(logger *slog.Logger) gin.HandlerFunc {
 	}
 }
 
-func MustHaveRole(logger *slog.Logger, allowedRoles ...string) gin.HandlerFunc {
+// checkRevoked rejects tokens whose role or community no longer match the
+// database. Identity provider claims are updated asynchronously through the
+// outbox, so a token refreshed in between still carries the old role and
+// only this comparison catches it.
+//
+// It also rejects tokens issued up to the user's last revocation. Token
+// timestamps have second precision, so a token from the revocation second
+// itself is rejected as well. A zero IssuedAt means the verifier resolved the
+// claims live from the identity provider.
+func (a *Auth) checkRevoked(ctx context.Context, claims *UserClaims) error {
+	if a.Revocations == nil {
+		return nil
+	}
+
+	access, err := a.Revocations.UserAccess(ctx, claims.UID)
+	if err != nil {
+		return err
+	}
+
+	if access.Role != claims.Role || access.CommunityID != claims.CommunityID {
+		return errs.New(errs.Unauthorize, "token claims are out of date")
+	}
+	if claims.IssuedAt.IsZero() || access.ValidAfter.IsZero() {
+		return nil
+	}
+	if !claims.IssuedAt.After(access.ValidAfter.Truncate(time.Second)) {
+		return errs.New(errs.Unauthorize, "token has been revoked")
+	}
+	return nil
+}
+
+// RequirePermission lets the request through when the caller holds any of
+// the given permissions.
+func RequirePermission(logger *slog.Logger, actions ...policy.Action) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		const op errs.Op = "middleware.auth.MustHaveRole"
+		const op errs.Op = "middleware.auth.RequirePermission"
 		user := GetUserClaims(ctx)
 
-		if slices.Contains(allowedRoles, user.Role) {
-			ctx.Next()
-			return
+		for _, action := range actions {
+			if user.HasPermission(action) {
+				ctx.Next()
+				return
+			}
 		}
 
-		response.SendRESTError(ctx, logger, errs.New(op, errs.Forbidden, "insufficient role"))
+		response.SendRESTError(ctx, errs.New(op, errs.Forbidden, "insufficient permission"))
 		ctx.Abort()
 	}
 }
@@ -0,0 +1,148 @@
+package middleware_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dvvnFrtn/capstone-backend/internal/handler/middleware"
+	"github.com/dvvnFrtn/capstone-backend/internal/policy"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type staticVerifier struct {
+	claims *middleware.UserClaims
+}
+
+func (v staticVerifier) VerifyToken(ctx context.Context, token string) (*middleware.UserClaims, error) {
+	return v.claims, nil
+}
+
+type staticRevocations map[string]middleware.UserAccess
+
+func (r staticRevocations) UserAccess(ctx context.Context, uid string) (middleware.UserAccess, error) {
+	access, ok := r[uid]
+	if !ok {
+		return middleware.UserAccess{}, errors.New("user not found")
+	}
+	return access, nil
+}
+
+func serveAuthenticated(auth *middleware.Auth) int {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/", auth.MustAuthenticated(slog.Default()), func(ctx *gin.Context) {
+		ctx.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestAuth_RejectsRevokedTokens(t *testing.T) {
+	revokedAt := time.Date(2025, 5, 20, 7, 0, 0, 500, time.UTC)
+	revocations := staticRevocations{
+		"uid-1": {Role: "pengurus", CommunityID: "community-1", ValidAfter: revokedAt},
+		"uid-2": {Role: "warga", CommunityID: "community-1"},
+	}
+
+	tests := map[string]struct {
+		claims middleware.UserClaims
+		status int
+	}{
+		"issued before revocation": {
+			claims: middleware.UserClaims{UID: "uid-1", Role: "pengurus", CommunityID: "community-1", IssuedAt: revokedAt.Add(-time.Minute)},
+			status: http.StatusUnauthorized,
+		},
+		"issued in the revocation second": {
+			claims: middleware.UserClaims{UID: "uid-1", Role: "pengurus", CommunityID: "community-1", IssuedAt: revokedAt.Truncate(time.Second)},
+			status: http.StatusUnauthorized,
+		},
+		"issued after revocation": {
+			claims: middleware.UserClaims{UID: "uid-1", Role: "pengurus", CommunityID: "community-1", IssuedAt: revokedAt.Add(time.Second)},
+			status: http.StatusOK,
+		},
+		"refreshed before the new role reached the provider": {
+			claims: middleware.UserClaims{UID: "uid-1", Role: "admin", CommunityID: "community-1", IssuedAt: revokedAt.Add(time.Minute)},
+			status: http.StatusUnauthorized,
+		},
+		"other community": {
+			claims: middleware.UserClaims{UID: "uid-2", Role: "warga", CommunityID: "community-2", IssuedAt: revokedAt},
+			status: http.StatusUnauthorized,
+		},
+		"never revoked": {
+			claims: middleware.UserClaims{UID: "uid-2", Role: "warga", CommunityID: "community-1", IssuedAt: revokedAt},
+			status: http.StatusOK,
+		},
+		"unknown user": {
+			claims: middleware.UserClaims{UID: "uid-3", Role: "warga", CommunityID: "community-1", IssuedAt: revokedAt},
+			status: http.StatusUnauthorized,
+		},
+		"claims resolved live": {
+			claims: middleware.UserClaims{UID: "uid-1", Role: "pengurus", CommunityID: "community-1"},
+			status: http.StatusOK,
+		},
+		"stale claims resolved live": {
+			claims: middleware.UserClaims{UID: "uid-1", Role: "warga", CommunityID: "community-1"},
+			status: http.StatusUnauthorized,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			claims := tt.claims
+			auth := middleware.NewAuthMiddleware(staticVerifier{claims: &claims}, revocations, nil)
+			assert.Equal(t, tt.status, serveAuthenticated(auth))
+		})
+	}
+}
+
+type staticPermissions map[string][]string
+
+func (p staticPermissions) RolePermissions(ctx context.Context, role, communityID string) ([]string, error) {
+	return p[role], nil
+}
+
+func TestRequirePermission(t *testing.T) {
+	permissions := staticPermissions{
+		"admin":     {"users:read", "users:update"},
+		"bendahara": {"users:read:self"},
+	}
+
+	tests := map[string]struct {
+		role     string
+		required []policy.Action
+		status   int
+	}{
+		"granted":      {role: "admin", required: []policy.Action{policy.UsersUpdate}, status: http.StatusOK},
+		"missing":      {role: "bendahara", required: []policy.Action{policy.UsersUpdate}, status: http.StatusForbidden},
+		"any of":       {role: "bendahara", required: []policy.Action{policy.UsersRead, policy.UsersReadSelf}, status: http.StatusOK},
+		"unknown role": {role: "tamu", required: []policy.Action{policy.UsersRead}, status: http.StatusForbidden},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			auth := middleware.NewAuthMiddleware(staticVerifier{claims: &middleware.UserClaims{UID: "uid-1", Role: tt.role}}, nil, permissions)
+
+			r := gin.New()
+			r.GET("/", auth.MustAuthenticated(slog.Default()), middleware.RequirePermission(slog.Default(), tt.required...), func(ctx *gin.Context) {
+				ctx.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Authorization", "Bearer token")
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+			assert.Equal(t, tt.status, rec.Code)
+		})
+	}
+}
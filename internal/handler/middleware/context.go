@@ -6,10 +6,38 @@ import (
 	"github.com/google/uuid"
 )
 
+const RequestIDHeader = "X-Request-ID"
+
+// RequestContext takes the caller's X-Request-ID, or generates one, stores it
+// in the request context and echoes it back so clients can quote it.
 func RequestContext() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		ctx.Set(string(types.RequestIDKey), uuid.New())
+		id := ctx.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+
+		ctx.Request = ctx.Request.WithContext(types.WithRequestID(ctx.Request.Context(), id))
+		ctx.Set(string(types.RequestIDKey), id)
+		ctx.Header(RequestIDHeader, id)
 
 		ctx.Next()
 	}
 }
+
+// validRequestID accepts short IDs made of characters that are safe to echo
+// in a header and to write into logs.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
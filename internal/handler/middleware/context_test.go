@@ -0,0 +1,61 @@
+package middleware_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dvvnFrtn/capstone-backend/internal/handler/middleware"
+	"github.com/dvvnFrtn/capstone-backend/internal/handler/response"
+	"github.com/dvvnFrtn/capstone-backend/internal/types"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestContext_RequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.ContextWithFallback = true
+
+	var seen string
+	r.GET("/", middleware.RequestContext(), func(ctx *gin.Context) {
+		seen = types.RequestIDFrom(ctx)
+		response.SendRESTSuccess(ctx, http.StatusOK, "ok", nil)
+	})
+
+	tests := map[string]struct {
+		header   string
+		expected string
+	}{
+		"accepts caller id":     {header: "req-123", expected: "req-123"},
+		"generates when absent": {header: ""},
+		"replaces unsafe id":    {header: "bad id\r\nX-Injected: 1"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.header != "" {
+				req.Header.Set(middleware.RequestIDHeader, tc.header)
+			}
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			echoed := rec.Header().Get(middleware.RequestIDHeader)
+			if tc.expected != "" {
+				assert.Equal(t, tc.expected, echoed)
+			} else {
+				_, err := uuid.Parse(echoed)
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, echoed, seen)
+
+			var body response.RESTResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+			assert.Equal(t, echoed, body.RequestID)
+		})
+	}
+}
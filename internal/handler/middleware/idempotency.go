@@ -0,0 +1,172 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/dvvnFrtn/capstone-backend/internal/handler/response"
+	"github.com/dvvnFrtn/capstone-backend/pkg/errs"
+	"github.com/dvvnFrtn/capstone-backend/pkg/idempotency"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader is set on responses replayed from an earlier
+	// request with the same key.
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+	// maxIdempotentBodySize bounds the request body kept in memory to hash it.
+	maxIdempotentBodySize = 1 << 20
+)
+
+// Idempotency lets clients retry a request safely by sending the same
+// Idempotency-Key: the first response is stored and replayed to retries
+// within TTL. Requests without the header are handled as usual. The zero
+// value lets every request through.
+type Idempotency struct {
+	logger *slog.Logger
+	store  idempotency.Store
+	ttl    time.Duration
+	lease  time.Duration
+}
+
+// NewIdempotency remembers keys for ttl. lease bounds how long a request may
+// hold its key before a retry may take it over, and should exceed the time
+// a request can run.
+func NewIdempotency(logger *slog.Logger, store idempotency.Store, ttl, lease time.Duration) Idempotency {
+	return Idempotency{logger: logger, store: store, ttl: ttl, lease: lease}
+}
+
+// Handler must run after authentication, so keys of different users never
+// collide.
+func (i Idempotency) Handler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		const op errs.Op = "middleware.idempotency.Handler"
+
+		key := ctx.GetHeader(IdempotencyKeyHeader)
+		if key == "" || i.store == nil {
+			ctx.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			response.SendRESTError(ctx, errs.New(
+				op,
+				errs.BadRequest,
+				errs.Msg("Idempotency-Key tidak boleh lebih dari 255 karakter"),
+				"idempotency key too long",
+			))
+			ctx.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxIdempotentBodySize))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				response.SendRESTError(ctx, errs.New(op, errs.TooLarge, errs.Msg("Body tidak boleh lebih dari 1 MiB"), err))
+			} else {
+				response.SendRESTError(ctx, errs.New(op, errs.BadRequest, errs.Msg("Body tidak dapat dibaca"), err))
+			}
+			ctx.Abort()
+			return
+		}
+		ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		scope := ctx.Request.Method + " " + ctx.FullPath()
+		if claims := GetUserClaims(ctx); claims != nil {
+			scope += " " + claims.UID
+		}
+		claim := idempotency.Claim{
+			Key:         scope + " " + key,
+			RequestHash: idempotency.Hash(ctx.Request.Method, ctx.Request.URL.Path, body),
+			TTL:         i.ttl,
+			Lease:       i.lease,
+		}
+
+		rec, claimed, err := i.store.Begin(ctx, claim)
+		if err != nil {
+			// an unavailable store must not take the API down with it
+			i.logger.WarnContext(ctx, "idempotency store failed", "err", err)
+			ctx.Next()
+			return
+		}
+
+		if !claimed {
+			switch {
+			case rec.RequestHash != claim.RequestHash:
+				response.SendRESTError(ctx, errs.New(
+					op,
+					errs.Unprocessable,
+					errs.Msg("Idempotency-Key sudah digunakan untuk permintaan yang berbeda"),
+					"idempotency key reused with another request",
+				))
+			case !rec.Completed:
+				response.SendRESTError(ctx, errs.New(
+					op,
+					errs.Conflict,
+					errs.Msg("Permintaan dengan Idempotency-Key ini masih diproses"),
+					"idempotency key in use",
+				))
+			default:
+				ctx.Header(IdempotentReplayedHeader, "true")
+				ctx.Data(rec.Status, rec.ContentType, rec.Body)
+			}
+			ctx.Abort()
+			return
+		}
+
+		w := &recordingWriter{ResponseWriter: ctx.Writer}
+		ctx.Writer = w
+
+		// the outcome is stored even when the client has gone away, so its
+		// retry finds it
+		storeCtx := context.WithoutCancel(ctx.Request.Context())
+		done := false
+		defer func() {
+			if done {
+				return
+			}
+			// the handler panicked: let the key be retried
+			if err := i.store.Release(storeCtx, claim.Key, rec.ClaimedAt); err != nil {
+				i.logger.WarnContext(ctx, "idempotency release failed", "err", err)
+			}
+		}()
+
+		ctx.Next()
+		done = true
+
+		// server errors are not final, a retry may succeed
+		if w.Status() >= http.StatusInternalServerError {
+			if err := i.store.Release(storeCtx, claim.Key, rec.ClaimedAt); err != nil {
+				i.logger.WarnContext(ctx, "idempotency release failed", "err", err)
+			}
+			return
+		}
+		if err := i.store.Complete(storeCtx, claim.Key, rec.ClaimedAt, w.Status(), w.Header().Get("Content-Type"), w.body.Bytes()); err != nil {
+			i.logger.WarnContext(ctx, "idempotency complete failed", "err", err)
+		}
+	}
+}
+
+// recordingWriter keeps a copy of the response body.
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
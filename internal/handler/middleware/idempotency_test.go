@@ -0,0 +1,161 @@
+package middleware_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dvvnFrtn/capstone-backend/internal/handler/middleware"
+	"github.com/dvvnFrtn/capstone-backend/pkg/errs"
+	"github.com/dvvnFrtn/capstone-backend/pkg/idempotency"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type failingIdempotencyStore struct{}
+
+func (failingIdempotencyStore) Begin(ctx context.Context, claim idempotency.Claim) (idempotency.Record, bool, error) {
+	return idempotency.Record{}, false, errors.New("database unavailable")
+}
+
+func (failingIdempotencyStore) Complete(ctx context.Context, key string, claimedAt time.Time, status int, contentType string, body []byte) error {
+	return nil
+}
+
+func (failingIdempotencyStore) Release(ctx context.Context, key string, claimedAt time.Time) error {
+	return nil
+}
+
+// idempotentRouter counts the calls reaching the handler, which answers with
+// status.
+func idempotentRouter(im middleware.Idempotency, calls *int, status *int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/api/users", func(ctx *gin.Context) {
+		ctx.Set("claims", &middleware.UserClaims{UID: ctx.GetHeader("X-Test-UID")})
+	}, im.Handler(), func(ctx *gin.Context) {
+		*calls++
+		ctx.JSON(*status, gin.H{"call": *calls})
+	})
+	return r
+}
+
+func postIdempotent(r *gin.Engine, key, uid, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/users", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(middleware.IdempotencyKeyHeader, key)
+	}
+	req.Header.Set("X-Test-UID", uid)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestIdempotency_ReplaysResponse(t *testing.T) {
+	im := middleware.NewIdempotency(slog.New(slog.DiscardHandler), idempotency.NewMemoryStore(), time.Hour, time.Minute)
+	calls, status := 0, http.StatusCreated
+	r := idempotentRouter(im, &calls, &status)
+
+	first := postIdempotent(r, "k1", "uid-1", `{"fullname":"Budi"}`)
+	require.Equal(t, http.StatusCreated, first.Code)
+	assert.Empty(t, first.Header().Get(middleware.IdempotentReplayedHeader))
+
+	retry := postIdempotent(r, "k1", "uid-1", `{"fullname":"Budi"}`)
+	assert.Equal(t, http.StatusCreated, retry.Code)
+	assert.Equal(t, "true", retry.Header().Get(middleware.IdempotentReplayedHeader))
+	assert.Equal(t, first.Body.String(), retry.Body.String())
+	assert.Equal(t, first.Header().Get("Content-Type"), retry.Header().Get("Content-Type"))
+	assert.Equal(t, 1, calls)
+
+	// keys belong to the user sending them
+	assert.Equal(t, http.StatusCreated, postIdempotent(r, "k1", "uid-2", `{"fullname":"Budi"}`).Code)
+	assert.Equal(t, 2, calls)
+
+	// without a key every request is handled
+	postIdempotent(r, "", "uid-1", `{"fullname":"Budi"}`)
+	postIdempotent(r, "", "uid-1", `{"fullname":"Budi"}`)
+	assert.Equal(t, 4, calls)
+}
+
+func TestIdempotency_RejectsReusedKey(t *testing.T) {
+	im := middleware.NewIdempotency(slog.New(slog.DiscardHandler), idempotency.NewMemoryStore(), time.Hour, time.Minute)
+	calls, status := 0, http.StatusCreated
+	r := idempotentRouter(im, &calls, &status)
+
+	postIdempotent(r, "k1", "uid-1", `{"fullname":"Budi"}`)
+
+	rec := postIdempotent(r, "k1", "uid-1", `{"fullname":"Sari"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Contains(t, rec.Body.String(), errs.Unprocessable.String())
+	assert.Equal(t, 1, calls)
+
+	rec = postIdempotent(r, strings.Repeat("k", 256), "uid-1", `{}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestIdempotency_ServerErrorsAreRetried(t *testing.T) {
+	im := middleware.NewIdempotency(slog.New(slog.DiscardHandler), idempotency.NewMemoryStore(), time.Hour, time.Minute)
+	calls, status := 0, http.StatusInternalServerError
+	r := idempotentRouter(im, &calls, &status)
+
+	assert.Equal(t, http.StatusInternalServerError, postIdempotent(r, "k1", "uid-1", `{}`).Code)
+
+	status = http.StatusCreated
+	rec := postIdempotent(r, "k1", "uid-1", `{}`)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Empty(t, rec.Header().Get(middleware.IdempotentReplayedHeader))
+	assert.Equal(t, 2, calls)
+}
+
+func TestIdempotency_ConcurrentRetryConflicts(t *testing.T) {
+	im := middleware.NewIdempotency(slog.New(slog.DiscardHandler), idempotency.NewMemoryStore(), time.Hour, time.Minute)
+	entered, release := make(chan struct{}), make(chan struct{})
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/api/users", func(ctx *gin.Context) {
+		ctx.Set("claims", &middleware.UserClaims{UID: ctx.GetHeader("X-Test-UID")})
+	}, im.Handler(), func(ctx *gin.Context) {
+		entered <- struct{}{}
+		<-release
+		ctx.JSON(http.StatusCreated, gin.H{})
+	})
+
+	// the first request holds the key until it is released
+	first := make(chan *httptest.ResponseRecorder)
+	go func() { first <- postIdempotent(r, "k1", "uid-1", `{}`) }()
+	<-entered
+
+	rec := postIdempotent(r, "k1", "uid-1", `{}`)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	close(release)
+	assert.Equal(t, http.StatusCreated, (<-first).Code)
+}
+
+func TestIdempotency_RejectsLargeBodies(t *testing.T) {
+	calls, status := 0, http.StatusCreated
+	r := idempotentRouter(middleware.NewIdempotency(slog.New(slog.DiscardHandler), idempotency.NewMemoryStore(), time.Hour, time.Minute), &calls, &status)
+
+	rec := postIdempotent(r, "k1", "uid-1", `{"fullname":"`+strings.Repeat("a", 1<<20)+`"}`)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	assert.Equal(t, 0, calls)
+}
+
+func TestIdempotency_FailsOpen(t *testing.T) {
+	calls, status := 0, http.StatusCreated
+
+	r := idempotentRouter(middleware.NewIdempotency(slog.New(slog.DiscardHandler), failingIdempotencyStore{}, time.Hour, time.Minute), &calls, &status)
+	assert.Equal(t, http.StatusCreated, postIdempotent(r, "k1", "uid-1", `{}`).Code)
+
+	r = idempotentRouter(middleware.Idempotency{}, &calls, &status)
+	assert.Equal(t, http.StatusCreated, postIdempotent(r, "k1", "uid-1", `{}`).Code)
+	assert.Equal(t, 2, calls)
+}
@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/dvvnFrtn/capstone-backend/pkg/errs"
+	"github.com/gin-gonic/gin"
+)
+
+// AccessLog writes one record per request once it has been handled. Client
+// errors are logged as warnings and server errors as errors.
+func AccessLog(logger *slog.Logger) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+		attrs := []slog.Attr{
+			slog.String("method", ctx.Request.Method),
+			slog.String("route", ctx.FullPath()),
+			slog.Int("status", status),
+			slog.Duration("latency", time.Since(start)),
+		}
+		if claims := GetUserClaims(ctx); claims != nil {
+			attrs = append(attrs,
+				slog.String("uid", claims.UID),
+				slog.String("community_id", claims.CommunityID),
+			)
+		}
+
+		// the error is logged here only, response.SendRESTError just
+		// attaches it
+		if code := errorCode(ctx); code != "" {
+			err := ctx.Errors.Last().Err
+			attrs = append(attrs,
+				slog.String("code", code),
+				slog.Any("stack", errs.OpStack(err)),
+				slog.Any("err", err),
+			)
+		}
+
+		logger.LogAttrs(ctx.Request.Context(), statusLevel(status), "http request", attrs...)
+	}
+}
+
+func statusLevel(status int) slog.Level {
+	switch {
+	case status >= http.StatusInternalServerError:
+		return slog.LevelError
+	case status >= http.StatusBadRequest:
+		return slog.LevelWarn
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// errorCode returns the errs.Code of the error reported by
+// response.SendRESTError, or "" when the request did not fail.
+func errorCode(ctx *gin.Context) string {
+	last := ctx.Errors.Last()
+	if last == nil {
+		return ""
+	}
+
+	var apperr *errs.Error
+	if errors.As(last.Err, &apperr) {
+		return apperr.Code.String()
+	}
+	return errs.Internal.String()
+}
@@ -0,0 +1,77 @@
+package middleware_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dvvnFrtn/capstone-backend/internal/handler/middleware"
+	"github.com/dvvnFrtn/capstone-backend/internal/handler/response"
+	"github.com/dvvnFrtn/capstone-backend/pkg/errs"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccessLog(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	r := gin.New()
+	r.Use(middleware.AccessLog(logger))
+	r.GET("/users/:id", func(ctx *gin.Context) {
+		ctx.Set("claims", &middleware.UserClaims{UID: "uid-1", CommunityID: "community-1"})
+		ctx.Status(http.StatusOK)
+	})
+	r.GET("/missing", func(ctx *gin.Context) {
+		response.SendRESTError(ctx, errs.New(errs.NotFound, "not found"))
+	})
+	r.GET("/broken", func(ctx *gin.Context) {
+		response.SendRESTError(ctx, errs.New(errs.Internal, "boom"))
+	})
+
+	tests := map[string]struct {
+		path  string
+		level string
+		route string
+		code  string
+	}{
+		"success is info":          {path: "/users/42", level: "INFO", route: "/users/:id"},
+		"client error is warning":  {path: "/missing", level: "WARN", route: "/missing", code: errs.NotFound.String()},
+		"server error is an error": {path: "/broken", level: "ERROR", route: "/broken", code: errs.Internal.String()},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			buf.Reset()
+			r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			// one line per request, failures included
+			require.Equal(t, 1, bytes.Count(buf.Bytes(), []byte("\n")))
+			var record map[string]any
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+			assert.Equal(t, tc.level, record["level"])
+			assert.Equal(t, tc.route, record["route"])
+			assert.Equal(t, http.MethodGet, record["method"])
+			assert.Contains(t, record, "latency")
+			if tc.code != "" {
+				assert.Equal(t, tc.code, record["code"])
+				assert.Contains(t, record, "err")
+			} else {
+				assert.NotContains(t, record, "err")
+			}
+		})
+	}
+
+	buf.Reset()
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/42", nil))
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "uid-1", record["uid"])
+	assert.Equal(t, "community-1", record["community_id"])
+}
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/dvvnFrtn/capstone-backend/internal/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics counts and times requests by route template, so path parameters do
+// not blow up the number of series.
+func Metrics() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+
+		ctx.Next()
+
+		route := ctx.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		code := errorCode(ctx)
+		if code == "" {
+			code = "none"
+		}
+
+		metrics.HTTPRequests.WithLabelValues(ctx.Request.Method, route, strconv.Itoa(ctx.Writer.Status()), code).Inc()
+		metrics.HTTPDuration.WithLabelValues(ctx.Request.Method, route, code).Observe(time.Since(start).Seconds())
+	}
+}
@@ -0,0 +1,48 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dvvnFrtn/capstone-backend/internal/handler/middleware"
+	"github.com/dvvnFrtn/capstone-backend/internal/handler/response"
+	"github.com/dvvnFrtn/capstone-backend/internal/metrics"
+	"github.com/dvvnFrtn/capstone-backend/pkg/errs"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics_LabelsByRouteAndCode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.Metrics())
+	r.GET("/metrics-test/:id", func(ctx *gin.Context) {
+		if ctx.Param("id") == "missing" {
+			response.SendRESTError(ctx, errs.New(errs.NotFound, "not found"))
+			return
+		}
+		ctx.Status(http.StatusOK)
+	})
+
+	ok := metrics.HTTPRequests.WithLabelValues(http.MethodGet, "/metrics-test/:id", "200", "none")
+	notFound := metrics.HTTPRequests.WithLabelValues(http.MethodGet, "/metrics-test/:id", "404", errs.NotFound.String())
+	unmatched := metrics.HTTPRequests.WithLabelValues(http.MethodGet, "unmatched", "404", "none")
+	// the counters are process-wide, so compare against their values before
+	// the requests to stay correct under -count
+	okBefore, notFoundBefore, unmatchedBefore := testutil.ToFloat64(ok), testutil.ToFloat64(notFound), testutil.ToFloat64(unmatched)
+
+	for _, path := range []string{"/metrics-test/1", "/metrics-test/2", "/metrics-test/missing", "/nowhere"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(ok)-okBefore)
+	assert.Equal(t, 1.0, testutil.ToFloat64(notFound)-notFoundBefore)
+	assert.Equal(t, 1.0, testutil.ToFloat64(unmatched)-unmatchedBefore)
+
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.True(t, strings.Contains(rec.Body.String(), "capstone_http_request_duration_seconds"))
+}
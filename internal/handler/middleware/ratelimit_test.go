@@ -0,0 +1,68 @@
+package middleware_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dvvnFrtn/capstone-backend/internal/handler/middleware"
+	"github.com/dvvnFrtn/capstone-backend/pkg/errs"
+	"github.com/dvvnFrtn/capstone-backend/pkg/ratelimit"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type failingStore struct{}
+
+func (failingStore) Take(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Decision, error) {
+	return ratelimit.Decision{}, errors.New("database unavailable")
+}
+
+func serveLimited(rl middleware.RateLimiter, uid string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/", func(ctx *gin.Context) {
+		if uid != "" {
+			ctx.Set("claims", &middleware.UserClaims{UID: uid, CommunityID: "community-1"})
+		}
+	}, rl.For("user", "community"), func(ctx *gin.Context) {
+		ctx.Status(http.StatusOK)
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	return rec
+}
+
+func TestRateLimiter(t *testing.T) {
+	logger := slog.New(slog.DiscardHandler)
+	rl := middleware.NewRateLimiter(logger, ratelimit.NewMemoryStore(), map[string]middleware.RateRule{
+		"user":      {Limit: ratelimit.Limit{Requests: 2, Per: time.Minute}, Key: middleware.ByUID},
+		"community": {Limit: ratelimit.Limit{Requests: 3, Per: time.Minute}, Key: middleware.ByCommunity},
+	})
+
+	assert.Equal(t, http.StatusOK, serveLimited(rl, "uid-1").Code)
+	assert.Equal(t, http.StatusOK, serveLimited(rl, "uid-1").Code)
+
+	rec := serveLimited(rl, "uid-1")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "30", rec.Header().Get("Retry-After"))
+	assert.Contains(t, rec.Body.String(), errs.RateLimit.String())
+
+	// another user of the same community only has the community budget left
+	assert.Equal(t, http.StatusOK, serveLimited(rl, "uid-2").Code)
+	assert.Equal(t, http.StatusTooManyRequests, serveLimited(rl, "uid-3").Code)
+}
+
+func TestRateLimiter_FailsOpen(t *testing.T) {
+	rl := middleware.NewRateLimiter(slog.New(slog.DiscardHandler), failingStore{}, map[string]middleware.RateRule{
+		"user": {Limit: ratelimit.Limit{Requests: 1, Per: time.Minute}, Key: middleware.ByUID},
+	})
+
+	assert.Equal(t, http.StatusOK, serveLimited(rl, "uid-1").Code)
+	assert.Equal(t, http.StatusOK, serveLimited(middleware.RateLimiter{}, "uid-1").Code)
+}
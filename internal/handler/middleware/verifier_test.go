@@ -0,0 +1,159 @@
+package middleware_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dvvnFrtn/capstone-backend/internal/handler/middleware"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testIssuer struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+}
+
+func newTestIssuer(t *testing.T) *testIssuer {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	issuer := &testIssuer{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":   issuer.server.URL,
+			"jwks_uri": issuer.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			// no "alg": issuers often omit it, so the verifier has to pin
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "test",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	issuer.server = httptest.NewServer(mux)
+	t.Cleanup(issuer.server.Close)
+
+	return issuer
+}
+
+func (i *testIssuer) sign(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+	return i.signWith(t, jwt.SigningMethodRS256, claims)
+}
+
+func (i *testIssuer) signWith(t *testing.T, method jwt.SigningMethod, claims jwt.MapClaims) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = "test"
+	signed, err := token.SignedString(i.key)
+	require.NoError(t, err)
+	return signed
+}
+
+func TestOIDCVerifier_DiscoversKeysAndMapsClaims(t *testing.T) {
+	issuer := newTestIssuer(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	verifier, err := middleware.NewOIDCVerifier(ctx, middleware.OIDCConfig{
+		Issuer:         issuer.server.URL,
+		Audience:       "capstone",
+		RoleClaim:      "app_metadata.role",
+		CommunityClaim: "app_metadata.community_id",
+	})
+	require.NoError(t, err)
+
+	token := issuer.sign(t, jwt.MapClaims{
+		"iss": issuer.server.URL,
+		"aud": "capstone",
+		"sub": "user-1",
+		"exp": time.Now().Add(time.Hour).Unix(),
+		"app_metadata": map[string]interface{}{
+			"role":         "pengurus",
+			"community_id": "community-1",
+		},
+	})
+
+	claims, err := verifier.VerifyToken(ctx, token)
+	require.NoError(t, err)
+	assert.Equal(t, &middleware.UserClaims{UID: "user-1", Role: "pengurus", CommunityID: "community-1"}, claims)
+}
+
+func TestOIDCVerifier_RejectsInvalidTokens(t *testing.T) {
+	issuer := newTestIssuer(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	verifier, err := middleware.NewOIDCVerifier(ctx, middleware.OIDCConfig{
+		Issuer:   issuer.server.URL,
+		Audience: "capstone",
+	})
+	require.NoError(t, err)
+
+	base := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss": issuer.server.URL,
+			"aud": "capstone",
+			"sub": "user-1",
+			"exp": time.Now().Add(time.Hour).Unix(),
+		}
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(jwt.MapClaims)
+	}{
+		{name: "expired", mutate: func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() }},
+		{name: "wrong issuer", mutate: func(c jwt.MapClaims) { c["iss"] = "https://evil.example" }},
+		{name: "wrong audience", mutate: func(c jwt.MapClaims) { c["aud"] = "other" }},
+		{name: "missing subject", mutate: func(c jwt.MapClaims) { delete(c, "sub") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := base()
+			tt.mutate(claims)
+
+			_, err := verifier.VerifyToken(ctx, issuer.sign(t, claims))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestOIDCVerifier_RejectsUnpinnedAlgorithms(t *testing.T) {
+	issuer := newTestIssuer(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	verifier, err := middleware.NewOIDCVerifier(ctx, middleware.OIDCConfig{Issuer: issuer.server.URL})
+	require.NoError(t, err)
+
+	token := issuer.signWith(t, jwt.SigningMethodPS256, jwt.MapClaims{
+		"iss": issuer.server.URL,
+		"sub": "user-1",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+
+	_, err = verifier.VerifyToken(ctx, token)
+	assert.Error(t, err)
+}
@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/dvvnFrtn/capstone-backend/internal/handler/middleware"
+	"github.com/dvvnFrtn/capstone-backend/internal/handler/response"
+	"github.com/dvvnFrtn/capstone-backend/internal/service"
+	"github.com/dvvnFrtn/capstone-backend/pkg/errs"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type OutboxHandler struct {
+	outboxService service.OutboxService
+	logger        *slog.Logger
+}
+
+func NewOutboxHandler(logger *slog.Logger, os service.OutboxService) OutboxHandler {
+	return OutboxHandler{
+		outboxService: os,
+		logger:        logger,
+	}
+}
+
+func (h *OutboxHandler) GetStuckEvents(ctx *gin.Context) {
+	claims := middleware.GetUserClaims(ctx)
+
+	res, err := h.outboxService.GetStuckEvents(ctx, claims)
+	if err != nil {
+		response.SendRESTError(ctx, err)
+		return
+	}
+
+	response.SendRESTSuccess(ctx, http.StatusOK, "Event berhasil dimuat", res)
+}
+
+func (h *OutboxHandler) ReplayEvent(ctx *gin.Context) {
+	const op errs.Op = "handler.outbox.ReplayEvent"
+
+	eventID, err := uuid.Parse(ctx.Param("eventID"))
+	if err != nil {
+		response.SendRESTError(ctx, errs.New(op, errs.BadRequest, errs.Msg("Request tidak valid"), err))
+		return
+	}
+
+	claims := middleware.GetUserClaims(ctx)
+
+	res, err := h.outboxService.ReplayEvent(ctx, claims, eventID)
+	if err != nil {
+		response.SendRESTError(ctx, err)
+		return
+	}
+
+	response.SendRESTSuccess(ctx, http.StatusOK, "Event dijadwalkan ulang", res)
+}
@@ -2,36 +2,63 @@ package response
 
 import (
 	"errors"
-	"log/slog"
 	"net/http"
 
+	"github.com/dvvnFrtn/capstone-backend/internal/types"
 	"github.com/dvvnFrtn/capstone-backend/pkg/errs"
 	"github.com/gin-gonic/gin"
 )
 
 type RESTResponse struct {
-	Message string      `json:"message"`
-	Code    string      `json:"code,omitempty"`
-	Data    interface{} `json:"data,omitempty"`
+	Message   string            `json:"message"`
+	Code      string            `json:"code,omitempty"`
+	Errors    []errs.FieldError `json:"errors,omitempty"`
+	Data      interface{}       `json:"data,omitempty"`
+	Meta      *Meta             `json:"meta,omitempty"`
+	RequestID string            `json:"request_id,omitempty"`
+}
+
+// Meta describes a page of a listing. NextCursor is empty on the last page.
+type Meta struct {
+	Total      int64  `json:"total"`
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
 func SendRESTSuccess(ctx *gin.Context, status int, msg string, data interface{}) {
 	ctx.JSON(status, RESTResponse{
-		Message: msg,
-		Data:    data,
+		Message:   msg,
+		Data:      data,
+		RequestID: types.RequestIDFrom(ctx.Request.Context()),
+	})
+}
+
+func SendRESTPage(ctx *gin.Context, status int, msg string, data interface{}, meta Meta) {
+	ctx.JSON(status, RESTResponse{
+		Message:   msg,
+		Data:      data,
+		Meta:      &meta,
+		RequestID: types.RequestIDFrom(ctx.Request.Context()),
 	})
 }
 
-func SendRESTError(ctx *gin.Context, logger *slog.Logger, err error) {
+// SendRESTError writes err as the response and attaches it to ctx, where the
+// access log picks it up; it logs nothing itself.
+func SendRESTError(ctx *gin.Context, err error) {
 	var (
 		resp   RESTResponse
 		apperr *errs.Error
 		status int
 	)
 
+	resp.RequestID = types.RequestIDFrom(ctx.Request.Context())
+
 	if errors.As(err, &apperr) {
+		if apperr.RequestID == "" {
+			apperr.RequestID = resp.RequestID
+		}
 		resp.Message = string(apperr.Msg)
 		resp.Code = apperr.Code.String()
+		resp.Errors = apperr.Fields
 		status = mapAppError(apperr)
 		if resp.Message == "" && status != 500 {
 			resp.Message = apperr.Error()
@@ -42,7 +69,7 @@ func SendRESTError(ctx *gin.Context, logger *slog.Logger, err error) {
 		status = http.StatusInternalServerError
 	}
 
-	logger.Error(resp.Message, "code", resp.Code, "stack", errs.OpStack(err), "err", err)
+	_ = ctx.Error(err)
 	ctx.JSON(status, resp)
 }
 
@@ -54,6 +81,12 @@ func mapAppError(err *errs.Error) int {
 		return http.StatusBadRequest
 	case errs.Conflict:
 		return http.StatusConflict
+	case errs.Unprocessable:
+		return http.StatusUnprocessableEntity
+	case errs.PreconditionFailed:
+		return http.StatusPreconditionFailed
+	case errs.TooLarge:
+		return http.StatusRequestEntityTooLarge
 	case errs.Forbidden:
 		return http.StatusForbidden
 	case errs.NotFound:
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/dvvnFrtn/capstone-backend/internal/handler/middleware"
+	"github.com/dvvnFrtn/capstone-backend/internal/handler/response"
+	"github.com/dvvnFrtn/capstone-backend/internal/service"
+	"github.com/dvvnFrtn/capstone-backend/pkg/errs"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type RoleHandler struct {
+	roleService service.RoleService
+	logger      *slog.Logger
+}
+
+func NewRoleHandler(logger *slog.Logger, rs service.RoleService) RoleHandler {
+	return RoleHandler{
+		roleService: rs,
+		logger:      logger,
+	}
+}
+
+func (h *RoleHandler) GetPermissions(ctx *gin.Context) {
+	claims := middleware.GetUserClaims(ctx)
+
+	res, err := h.roleService.GetPermissions(ctx, claims)
+	if err != nil {
+		response.SendRESTError(ctx, err)
+		return
+	}
+
+	response.SendRESTSuccess(ctx, http.StatusOK, "Hak akses berhasil dimuat", res)
+}
+
+func (h *RoleHandler) GetRoles(ctx *gin.Context) {
+	claims := middleware.GetUserClaims(ctx)
+
+	res, err := h.roleService.GetRoles(ctx, claims)
+	if err != nil {
+		response.SendRESTError(ctx, err)
+		return
+	}
+
+	response.SendRESTSuccess(ctx, http.StatusOK, "Peran berhasil dimuat", res)
+}
+
+func (h *RoleHandler) CreateRole(ctx *gin.Context) {
+	const op errs.Op = "handler.role.CreateRole"
+
+	var req service.CreateRoleRequest
+	if err := bindJSON(ctx, op, &req); err != nil {
+		response.SendRESTError(ctx, err)
+		return
+	}
+
+	claims := middleware.GetUserClaims(ctx)
+
+	res, err := h.roleService.CreateRole(ctx, claims, req)
+	if err != nil {
+		response.SendRESTError(ctx, err)
+		return
+	}
+
+	response.SendRESTSuccess(ctx, http.StatusCreated, "Peran berhasil disimpan", res)
+}
+
+func (h *RoleHandler) UpdateRole(ctx *gin.Context) {
+	const op errs.Op = "handler.role.UpdateRole"
+
+	roleID, err := uuid.Parse(ctx.Param("roleID"))
+	if err != nil {
+		response.SendRESTError(ctx, errs.New(op, errs.BadRequest, errs.Msg("Request tidak valid"), err))
+		return
+	}
+
+	var req service.UpdateRoleRequest
+	if err := bindJSON(ctx, op, &req); err != nil {
+		response.SendRESTError(ctx, err)
+		return
+	}
+
+	claims := middleware.GetUserClaims(ctx)
+
+	if err := h.roleService.UpdateRole(ctx, claims, roleID, req); err != nil {
+		response.SendRESTError(ctx, err)
+		return
+	}
+
+	response.SendRESTSuccess(ctx, http.StatusOK, "Peran berhasil diperbarui", nil)
+}
+
+func (h *RoleHandler) DeleteRole(ctx *gin.Context) {
+	const op errs.Op = "handler.role.DeleteRole"
+
+	roleID, err := uuid.Parse(ctx.Param("roleID"))
+	if err != nil {
+		response.SendRESTError(ctx, errs.New(op, errs.BadRequest, errs.Msg("Request tidak valid"), err))
+		return
+	}
+
+	claims := middleware.GetUserClaims(ctx)
+
+	if err := h.roleService.DeleteRole(ctx, claims, roleID); err != nil {
+		response.SendRESTError(ctx, err)
+		return
+	}
+
+	response.SendRESTSuccess(ctx, http.StatusOK, "Peran berhasil dihapus", nil)
+}
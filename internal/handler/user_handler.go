@@ -28,14 +28,14 @@ func (h *UserHandler) AdminSignup(ctx *gin.Context) {
 	const op errs.Op = "handler.user.AdminSignup"
 
 	var req service.AdminRegistrationRequest
-	if err := ctx.ShouldBindJSON(&req); err != nil {
-		response.SendRESTError(ctx, h.logger, errs.New(op, errs.BadRequest, errs.Msg("Request tidak valid"), err))
+	if err := bindJSON(ctx, op, &req); err != nil {
+		response.SendRESTError(ctx, err)
 		return
 	}
 
 	res, err := h.userService.AdminRegistration(ctx, req)
 	if err != nil {
-		response.SendRESTError(ctx, h.logger, err)
+		response.SendRESTError(ctx, err)
 		return
 	}
 
@@ -46,8 +46,8 @@ func (h *UserHandler) AdminCreateUser(ctx *gin.Context) {
 	const op errs.Op = "handler.user.AdminCreateUser"
 
 	var req service.AdminCreateUserRequest
-	if err := ctx.ShouldBindJSON(&req); err != nil {
-		response.SendRESTError(ctx, h.logger, errs.New(op, errs.BadRequest, errs.Msg("Request tidak valid"), err))
+	if err := bindJSON(ctx, op, &req); err != nil {
+		response.SendRESTError(ctx, err)
 		return
 	}
 
@@ -55,7 +55,7 @@ func (h *UserHandler) AdminCreateUser(ctx *gin.Context) {
 
 	res, err := h.userService.AdminCreateUser(ctx, claims, req)
 	if err != nil {
-		response.SendRESTError(ctx, h.logger, err)
+		response.SendRESTError(ctx, err)
 		return
 	}
 
@@ -65,63 +65,125 @@ func (h *UserHandler) AdminCreateUser(ctx *gin.Context) {
 func (h *UserHandler) GetUser(ctx *gin.Context) {
 	const op errs.Op = "handler.user.GetUser"
 
-	userID := ctx.Param("userID")
+	userID, err := uuid.Parse(ctx.Param("userID"))
+	if err != nil {
+		response.SendRESTError(ctx, errs.New(op, errs.BadRequest, errs.Msg("Request tidak valid"), err))
+		return
+	}
 	claims := middleware.GetUserClaims(ctx)
 
-	res, err := h.userService.GetUser(ctx, claims, uuid.MustParse(userID))
+	res, err := h.userService.GetUser(ctx, claims, userID)
 	if err != nil {
-		response.SendRESTError(ctx, h.logger, err)
+		response.SendRESTError(ctx, err)
 		return
 	}
 
+	ctx.Header("ETag", userETag(res.Version))
 	response.SendRESTSuccess(ctx, http.StatusCreated, "Akun berhasil dimuat", res)
 }
 
 func (h *UserHandler) GetUsersCommunity(ctx *gin.Context) {
 	const op errs.Op = "handler.user.GetUsersCommunity"
 
+	var req service.ListUsersRequest
+	if err := bindQuery(ctx, op, &req); err != nil {
+		response.SendRESTError(ctx, err)
+		return
+	}
+
 	claims := middleware.GetUserClaims(ctx)
 
-	res, err := h.userService.GetUserFromCommunity(ctx, claims)
+	page, err := h.userService.GetUserFromCommunity(ctx, claims, req)
 	if err != nil {
-		response.SendRESTError(ctx, h.logger, err)
+		response.SendRESTError(ctx, err)
 		return
 	}
 
-	response.SendRESTSuccess(ctx, http.StatusCreated, "Akun berhasil dimuat", res)
+	response.SendRESTPage(ctx, http.StatusOK, "Akun berhasil dimuat", page.Users, response.Meta{
+		Total:      page.Total,
+		NextCursor: page.NextCursor,
+	})
+}
+
+func (h *UserHandler) SearchUsers(ctx *gin.Context) {
+	const op errs.Op = "handler.user.SearchUsers"
+
+	var req service.SearchUsersRequest
+	if err := bindQuery(ctx, op, &req); err != nil {
+		response.SendRESTError(ctx, err)
+		return
+	}
+
+	claims := middleware.GetUserClaims(ctx)
+
+	res, err := h.userService.SearchUsers(ctx, claims, req)
+	if err != nil {
+		response.SendRESTError(ctx, err)
+		return
+	}
+
+	response.SendRESTSuccess(ctx, http.StatusOK, "Pencarian berhasil", res)
 }
 
 func (h *UserHandler) AdminUpdateUser(ctx *gin.Context) {
 	const op errs.Op = "handler.user.AdminUpdateUser"
 
 	var req service.AdminUpdateUserRequest
-	if err := ctx.ShouldBindJSON(&req); err != nil {
-		response.SendRESTError(ctx, h.logger, errs.New(op, errs.BadRequest, errs.Msg("Request tidak valid"), err))
+	if err := bindJSON(ctx, op, &req); err != nil {
+		response.SendRESTError(ctx, err)
 		return
 	}
 
-	userID := ctx.Param("userID")
+	userID, err := uuid.Parse(ctx.Param("userID"))
+	if err != nil {
+		response.SendRESTError(ctx, errs.New(op, errs.BadRequest, errs.Msg("Request tidak valid"), err))
+		return
+	}
 	claims := middleware.GetUserClaims(ctx)
 
-	res, err := h.userService.AdminUpdateUser(ctx, claims, uuid.MustParse(userID), req)
+	res, err := h.userService.AdminUpdateUser(ctx, claims, userID, req, ifMatch(ctx))
 	if err != nil {
-		response.SendRESTError(ctx, h.logger, err)
+		response.SendRESTError(ctx, err)
 		return
 	}
 
+	ctx.Header("ETag", userETag(res.Version))
 	response.SendRESTSuccess(ctx, http.StatusCreated, "Akun berhasil diperbarui", res)
 }
 
 func (h *UserHandler) AdminDeleteUser(ctx *gin.Context) {
 	const op errs.Op = "handler.user.AdminDeleteUser"
 
-	userID := ctx.Param("userID")
+	userID, err := uuid.Parse(ctx.Param("userID"))
+	if err != nil {
+		response.SendRESTError(ctx, errs.New(op, errs.BadRequest, errs.Msg("Request tidak valid"), err))
+		return
+	}
 	claims := middleware.GetUserClaims(ctx)
 
-	if err := h.userService.AdminDeleteUser(ctx, claims, uuid.MustParse(userID)); err != nil {
-		response.SendRESTError(ctx, h.logger, err)
+	if err := h.userService.AdminDeleteUser(ctx, claims, userID, ifMatch(ctx)); err != nil {
+		response.SendRESTError(ctx, err)
 		return
 	}
 
 	response.SendRESTSuccess(ctx, http.StatusOK, "Akun berhasil dihapus", nil)
 }
+
+func (h *UserHandler) AdminRestoreUser(ctx *gin.Context) {
+	const op errs.Op = "handler.user.AdminRestoreUser"
+
+	userID, err := uuid.Parse(ctx.Param("userID"))
+	if err != nil {
+		response.SendRESTError(ctx, errs.New(op, errs.BadRequest, errs.Msg("Request tidak valid"), err))
+		return
+	}
+	claims := middleware.GetUserClaims(ctx)
+
+	res, err := h.userService.AdminRestoreUser(ctx, claims, userID)
+	if err != nil {
+		response.SendRESTError(ctx, err)
+		return
+	}
+
+	response.SendRESTSuccess(ctx, http.StatusOK, "Akun berhasil dipulihkan", res)
+}
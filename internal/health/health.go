@@ -0,0 +1,150 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Check probes one dependency. Run must honour the context deadline.
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+type Status string
+
+const (
+	StatusUp   Status = "up"
+	StatusDown Status = "down"
+)
+
+// Result is served on the public readiness endpoint, so Error is kept out of
+// its JSON and only logged.
+type Result struct {
+	Status    Status    `json:"status"`
+	Error     string    `json:"-"`
+	LatencyMS int64     `json:"latency_ms"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+type Report struct {
+	Status Status            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+// Checker runs the readiness checks, caching each result for ttl so frequent
+// probes do not hammer the dependencies.
+type Checker struct {
+	checks  []Check
+	ttl     time.Duration
+	timeout time.Duration
+
+	draining atomic.Bool
+
+	mu    sync.Mutex
+	cache map[string]Result
+}
+
+func NewChecker(ttl, timeout time.Duration, checks ...Check) *Checker {
+	return &Checker{
+		checks:  checks,
+		ttl:     ttl,
+		timeout: timeout,
+		cache:   make(map[string]Result, len(checks)),
+	}
+}
+
+// Drain makes every following readiness report fail, so load balancers stop
+// routing traffic while the server shuts down.
+func (c *Checker) Drain() {
+	c.draining.Store(true)
+}
+
+func (c *Checker) Ready(ctx context.Context) Report {
+	report := Report{Status: StatusUp, Checks: make(map[string]Result, len(c.checks))}
+
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+	for _, check := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res := c.run(ctx, check)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[check.Name] = res
+			if res.Status != StatusUp {
+				report.Status = StatusDown
+			}
+		}()
+	}
+	wg.Wait()
+
+	if c.draining.Load() {
+		report.Status = StatusDown
+	}
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, check Check) Result {
+	c.mu.Lock()
+	cached, ok := c.cache[check.Name]
+	c.mu.Unlock()
+	if ok && time.Since(cached.CheckedAt) < c.ttl {
+		return cached
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.Run(ctx)
+	res := Result{
+		Status:    StatusUp,
+		LatencyMS: time.Since(start).Milliseconds(),
+		CheckedAt: start,
+	}
+	if err != nil {
+		res.Status = StatusDown
+		res.Error = err.Error()
+	}
+
+	c.mu.Lock()
+	c.cache[check.Name] = res
+	c.mu.Unlock()
+	return res
+}
+
+// HTTP checks that url answers without a server error.
+func HTTP(name, url string, header http.Header) Check {
+	return Check{
+		Name: name,
+		Run: func(ctx context.Context) error {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+			if err != nil {
+				return err
+			}
+			for key, values := range header {
+				req.Header[key] = values
+			}
+
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				return err
+			}
+			defer res.Body.Close()
+
+			if res.StatusCode >= http.StatusInternalServerError {
+				return fmt.Errorf("%s answered %s", url, res.Status)
+			}
+			return nil
+		},
+	}
+}
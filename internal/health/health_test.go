@@ -0,0 +1,69 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dvvnFrtn/capstone-backend/internal/health"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecker_Ready(t *testing.T) {
+	var calls atomic.Int32
+	up := health.Check{Name: "up", Run: func(context.Context) error {
+		calls.Add(1)
+		return nil
+	}}
+	down := health.Check{Name: "down", Run: func(context.Context) error {
+		return errors.New("connection refused")
+	}}
+
+	checker := health.NewChecker(time.Minute, time.Second, up)
+	assert.Equal(t, health.StatusUp, checker.Ready(context.Background()).Status)
+	assert.Equal(t, health.StatusUp, checker.Ready(context.Background()).Status)
+	assert.Equal(t, int32(1), calls.Load(), "result should be cached")
+
+	report := health.NewChecker(time.Minute, time.Second, up, down).Ready(context.Background())
+	assert.Equal(t, health.StatusDown, report.Status)
+	assert.Equal(t, health.StatusUp, report.Checks["up"].Status)
+	assert.Equal(t, "connection refused", report.Checks["down"].Error)
+
+	body, err := json.Marshal(report)
+	require.NoError(t, err)
+	assert.NotContains(t, string(body), "connection refused", "errors are not served publicly")
+
+	checker.Drain()
+	assert.Equal(t, health.StatusDown, checker.Ready(context.Background()).Status)
+}
+
+func TestChecker_TimesOutSlowChecks(t *testing.T) {
+	slow := health.Check{Name: "slow", Run: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}}
+
+	report := health.NewChecker(0, 10*time.Millisecond, slow).Ready(context.Background())
+	assert.Equal(t, health.StatusDown, report.Status)
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks["slow"].Error)
+}
+
+func TestHTTP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Apikey") != "key" {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	assert.NoError(t, health.HTTP("idp", srv.URL, http.Header{"Apikey": {"key"}}).Run(context.Background()))
+	assert.Error(t, health.HTTP("idp", srv.URL, nil).Run(context.Background()))
+}
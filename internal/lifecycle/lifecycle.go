@@ -0,0 +1,95 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+type hook struct {
+	name string
+	stop func(ctx context.Context) error
+}
+
+// Manager stops the application's components in the reverse order they were
+// started, like deferred calls: whatever depends on the database is stopped
+// before the pool is closed.
+type Manager struct {
+	logger *slog.Logger
+
+	mu    sync.Mutex
+	hooks []hook
+
+	failed   chan error
+	failOnce sync.Once
+}
+
+func New(logger *slog.Logger) *Manager {
+	return &Manager{logger: logger, failed: make(chan error, 1)}
+}
+
+// Fail reports that a component stopped on its own, e.g. a listener that
+// could not bind, so the application should shut down. Only the first
+// failure is kept.
+func (m *Manager) Fail(name string, err error) {
+	m.failOnce.Do(func() {
+		m.failed <- fmt.Errorf("%s: %w", name, err)
+	})
+}
+
+// Failed delivers the first failure reported through Fail.
+func (m *Manager) Failed() <-chan error {
+	return m.failed
+}
+
+// OnShutdown registers stop to run during Shutdown.
+func (m *Manager) OnShutdown(name string, stop func(ctx context.Context) error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks = append(m.hooks, hook{name: name, stop: stop})
+}
+
+// Go runs worker in the background. On shutdown its context is cancelled and
+// the manager waits for it to return, so in-flight work is drained.
+func (m *Manager) Go(name string, worker func(ctx context.Context)) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		worker(ctx)
+	}()
+
+	m.OnShutdown(name, func(stopCtx context.Context) error {
+		cancel()
+		select {
+		case <-done:
+			return nil
+		case <-stopCtx.Done():
+			return fmt.Errorf("%s did not stop in time: %w", name, stopCtx.Err())
+		}
+	})
+}
+
+// Shutdown runs every hook, last registered first. A failing hook does not
+// prevent the others from running; all errors are returned together.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	hooks := m.hooks
+	m.hooks = nil
+	m.mu.Unlock()
+
+	var errList []error
+	for i := len(hooks) - 1; i >= 0; i-- {
+		h := hooks[i]
+		m.logger.InfoContext(ctx, "stopping component", "name", h.name)
+		if err := h.stop(ctx); err != nil {
+			m.logger.ErrorContext(ctx, "component stop failed", "name", h.name, "err", err)
+			errList = append(errList, fmt.Errorf("stop %s: %w", h.name, err))
+		}
+	}
+
+	return errors.Join(errList...)
+}
@@ -0,0 +1,70 @@
+package lifecycle_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/dvvnFrtn/capstone-backend/internal/lifecycle"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_ShutdownOrder(t *testing.T) {
+	lc := lifecycle.New(slog.New(slog.DiscardHandler))
+
+	var stopped []string
+	record := func(name string, err error) func(context.Context) error {
+		return func(context.Context) error {
+			stopped = append(stopped, name)
+			return err
+		}
+	}
+
+	lc.OnShutdown("database pool", record("database pool", nil))
+	lc.Go("worker", func(ctx context.Context) {
+		<-ctx.Done()
+		time.Sleep(10 * time.Millisecond)
+		stopped = append(stopped, "worker")
+	})
+	lc.OnShutdown("http server", record("http server", errors.New("boom")))
+
+	err := lc.Shutdown(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "http server")
+	assert.Equal(t, []string{"http server", "worker", "database pool"}, stopped)
+}
+
+func TestManager_WorkerDeadline(t *testing.T) {
+	lc := lifecycle.New(slog.New(slog.DiscardHandler))
+	release := make(chan struct{})
+	defer close(release)
+
+	lc.Go("stuck", func(context.Context) { <-release })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, lc.Shutdown(ctx), context.DeadlineExceeded)
+}
+
+func TestManager_Fail(t *testing.T) {
+	lc := lifecycle.New(slog.New(slog.DiscardHandler))
+
+	lc.Fail("http server", errors.New("address already in use"))
+	lc.Fail("metrics server", errors.New("address already in use"))
+
+	select {
+	case err := <-lc.Failed():
+		assert.EqualError(t, err, "http server: address already in use")
+	default:
+		t.Fatal("failure was not reported")
+	}
+
+	select {
+	case err := <-lc.Failed():
+		t.Fatalf("only the first failure is kept, got %v", err)
+	default:
+	}
+}
@@ -0,0 +1,92 @@
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+
+	"github.com/dvvnFrtn/capstone-backend/config"
+	"github.com/dvvnFrtn/capstone-backend/internal/types"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ComponentKey names the logger attribute that selects a per-component level
+// from config.Log.Levels, as in logger.With(logging.ComponentKey, "outbox").
+const ComponentKey = "component"
+
+func New(w io.Writer, cfg config.Log) *slog.Logger {
+	levels := make(map[string]slog.Level, len(cfg.Levels))
+	for component, name := range cfg.Levels {
+		levels[component] = parseLevel(name)
+	}
+
+	// the wrapped handler logs everything, levels are enforced by contextHandler
+	opts := &slog.HandlerOptions{Level: slog.Level(-8)}
+	var base slog.Handler = slog.NewTextHandler(w, opts)
+	if cfg.Format == "json" {
+		base = slog.NewJSONHandler(w, opts)
+	}
+
+	return slog.New(contextHandler{
+		Handler: base,
+		level:   parseLevel(cfg.Level),
+		levels:  levels,
+	})
+}
+
+func parseLevel(name string) slog.Level {
+	var level slog.Level
+	_ = level.UnmarshalText([]byte(name))
+	return level
+}
+
+// contextHandler adds the request ID and trace found in the context to every
+// record, so any *Context logging call can be correlated with its request.
+// It also switches to the component's own level once a component attribute
+// is set.
+type contextHandler struct {
+	slog.Handler
+	level  slog.Level
+	levels map[string]slog.Level
+}
+
+func NewContextHandler(h slog.Handler) slog.Handler {
+	return contextHandler{Handler: h, level: slog.Level(-8)}
+}
+
+func (h contextHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level && h.Handler.Enabled(ctx, level)
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := types.RequestIDFrom(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(
+			slog.String("trace_id", sc.TraceID().String()),
+			slog.String("span_id", sc.SpanID().String()),
+		)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	next := h
+	for _, attr := range attrs {
+		if attr.Key != ComponentKey {
+			continue
+		}
+		if level, ok := h.levels[attr.Value.String()]; ok {
+			next.level = level
+		}
+	}
+	next.Handler = h.Handler.WithAttrs(attrs)
+	return next
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	next := h
+	next.Handler = h.Handler.WithGroup(name)
+	return next
+}
@@ -0,0 +1,53 @@
+package logging_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/dvvnFrtn/capstone-backend/config"
+	"github.com/dvvnFrtn/capstone-backend/internal/logging"
+	"github.com/dvvnFrtn/capstone-backend/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew_AddsRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	logger := logging.New(&buf, config.Log{Level: "info", Format: "json"}).With("component", "test")
+
+	logger.InfoContext(types.WithRequestID(context.Background(), "req-1"), "with id")
+	logger.InfoContext(context.Background(), "without id")
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+
+	var first, second map[string]any
+	require.NoError(t, json.Unmarshal(lines[0], &first))
+	require.NoError(t, json.Unmarshal(lines[1], &second))
+
+	assert.Equal(t, "req-1", first["request_id"])
+	assert.Equal(t, "test", first["component"])
+	assert.NotContains(t, second, "request_id")
+}
+
+func TestNew_ComponentLevels(t *testing.T) {
+	var buf bytes.Buffer
+	logger := logging.New(&buf, config.Log{
+		Level:  "info",
+		Format: "text",
+		Levels: map[string]string{"db": "debug", "outbox": "error"},
+	})
+
+	logger.Debug("root debug")
+	logger.With(logging.ComponentKey, "db").Debug("db debug")
+	logger.With(logging.ComponentKey, "outbox").Warn("outbox warn")
+	logger.With(logging.ComponentKey, "outbox").Error("outbox error")
+
+	out := buf.String()
+	assert.NotContains(t, out, "root debug")
+	assert.Contains(t, out, "db debug")
+	assert.NotContains(t, out, "outbox warn")
+	assert.Contains(t, out, "outbox error")
+}
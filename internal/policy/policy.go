@@ -0,0 +1,106 @@
+package policy
+
+import (
+	"slices"
+
+	"github.com/dvvnFrtn/capstone-backend/pkg/errs"
+)
+
+type Action string
+
+const (
+	UsersCreate   Action = "users:create"
+	UsersRead     Action = "users:read"
+	UsersReadSelf Action = "users:read:self"
+	UsersUpdate   Action = "users:update"
+	UsersDelete   Action = "users:delete"
+	RolesRead     Action = "roles:read"
+	RolesManage   Action = "roles:manage"
+	OutboxRead    Action = "outbox:read"
+	OutboxReplay  Action = "outbox:replay"
+	AuditRead     Action = "audit:read"
+)
+
+const (
+	KindUser        = "user"
+	KindRole        = "role"
+	KindPermission  = "permission"
+	KindOutboxEvent = "outbox_event"
+	KindAuditEvent  = "audit_event"
+)
+
+// AdminRole is the system role only existing admins may hand out.
+const AdminRole = "admin"
+
+// SystemRoles mirrors the permissions seeded for the shared roles by the
+// roles migration.
+var SystemRoles = map[string][]Action{
+	AdminRole: {
+		UsersCreate, UsersRead, UsersReadSelf, UsersUpdate, UsersDelete,
+		RolesRead, RolesManage, OutboxRead, OutboxReplay, AuditRead,
+	},
+	"pengurus": {UsersRead, UsersReadSelf, RolesRead},
+	"warga":    {UsersReadSelf},
+}
+
+type Subject struct {
+	UID         string
+	CommunityID string
+	Permissions []string
+}
+
+func (s Subject) has(action Action) bool {
+	return slices.Contains(s.Permissions, string(action))
+}
+
+type Resource struct {
+	Kind        string
+	CommunityID string
+	OwnerID     string
+}
+
+// Community is a resource without an owner, such as a collection or a new
+// record about to be created in the community.
+func Community(kind, communityID string) Resource {
+	return Resource{Kind: kind, CommunityID: communityID}
+}
+
+func User(uID, communityID string) Resource {
+	return Resource{Kind: KindUser, CommunityID: communityID, OwnerID: uID}
+}
+
+// Can reports whether the subject may perform the action on the resource.
+// Every resource belongs to a community and only its members are considered.
+// Members are allowed when they hold the action's permission, or its ":self"
+// variant when they own the resource.
+func Can(subject Subject, action Action, resource Resource) bool {
+	if subject.UID == "" || subject.CommunityID == "" || subject.CommunityID != resource.CommunityID {
+		return false
+	}
+
+	if subject.has(action) {
+		return true
+	}
+
+	return resource.OwnerID != "" && resource.OwnerID == subject.UID && subject.has(action+":self")
+}
+
+// CanGrant reports whether the subject holds every permission, so a role
+// built from them never gives anyone more than its grantor already has.
+func CanGrant(subject Subject, permissions []string) bool {
+	for _, permission := range permissions {
+		if !subject.has(Action(permission)) {
+			return false
+		}
+	}
+	return true
+}
+
+func Authorize(subject Subject, action Action, resource Resource) error {
+	const op errs.Op = "policy.Authorize"
+
+	if Can(subject, action, resource) {
+		return nil
+	}
+	return errs.New(op, errs.Forbidden, errs.Msg("Anda tidak memiliki akses"), string(action)+" on "+resource.Kind)
+}
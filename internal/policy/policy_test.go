@@ -0,0 +1,143 @@
+package policy_test
+
+import (
+	"testing"
+
+	"github.com/dvvnFrtn/capstone-backend/internal/policy"
+	"github.com/dvvnFrtn/capstone-backend/pkg/errs"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	callerID    = "caller"
+	otherID     = "other"
+	communityID = "community-1"
+	foreignID   = "community-2"
+)
+
+func subjectFor(role string) policy.Subject {
+	var permissions []string
+	for _, action := range policy.SystemRoles[role] {
+		permissions = append(permissions, string(action))
+	}
+	return policy.Subject{UID: callerID, CommunityID: communityID, Permissions: permissions}
+}
+
+type endpoint struct {
+	action   policy.Action
+	resource policy.Resource
+}
+
+var endpoints = map[string]endpoint{
+	"POST /api/users":                   {policy.UsersCreate, policy.Community(policy.KindUser, communityID)},
+	"GET /api/users":                    {policy.UsersRead, policy.Community(policy.KindUser, communityID)},
+	"GET /api/users/:userID (self)":     {policy.UsersRead, policy.User(callerID, communityID)},
+	"GET /api/users/:userID (other)":    {policy.UsersRead, policy.User(otherID, communityID)},
+	"PATCH /api/users/:userID (self)":   {policy.UsersUpdate, policy.User(callerID, communityID)},
+	"PATCH /api/users/:userID (other)":  {policy.UsersUpdate, policy.User(otherID, communityID)},
+	"DELETE /api/users/:userID (other)": {policy.UsersDelete, policy.User(otherID, communityID)},
+	"POST /api/users/:userID/restore":   {policy.UsersDelete, policy.User(otherID, communityID)},
+	"GET /api/permissions":              {policy.RolesRead, policy.Community(policy.KindPermission, communityID)},
+	"GET /api/roles":                    {policy.RolesRead, policy.Community(policy.KindRole, communityID)},
+	"POST /api/roles":                   {policy.RolesManage, policy.Community(policy.KindRole, communityID)},
+	"PATCH /api/roles/:roleID":          {policy.RolesManage, policy.Community(policy.KindRole, communityID)},
+	"DELETE /api/roles/:roleID":         {policy.RolesManage, policy.Community(policy.KindRole, communityID)},
+	"GET /api/outbox":                   {policy.OutboxRead, policy.Community(policy.KindOutboxEvent, communityID)},
+	"POST /api/outbox/:eventID/replay":  {policy.OutboxReplay, policy.Community(policy.KindOutboxEvent, communityID)},
+	"GET /api/audit":                    {policy.AuditRead, policy.Community(policy.KindAuditEvent, communityID)},
+}
+
+func TestCan_RoleEndpointMatrix(t *testing.T) {
+	allowed := map[string][]string{
+		"admin": {
+			"POST /api/users",
+			"GET /api/users",
+			"GET /api/users/:userID (self)",
+			"GET /api/users/:userID (other)",
+			"PATCH /api/users/:userID (self)",
+			"PATCH /api/users/:userID (other)",
+			"DELETE /api/users/:userID (other)",
+			"POST /api/users/:userID/restore",
+			"GET /api/permissions",
+			"GET /api/roles",
+			"POST /api/roles",
+			"PATCH /api/roles/:roleID",
+			"DELETE /api/roles/:roleID",
+			"GET /api/outbox",
+			"POST /api/outbox/:eventID/replay",
+			"GET /api/audit",
+		},
+		"pengurus": {
+			"GET /api/users",
+			"GET /api/users/:userID (self)",
+			"GET /api/users/:userID (other)",
+			"GET /api/permissions",
+			"GET /api/roles",
+		},
+		"warga": {
+			"GET /api/users/:userID (self)",
+		},
+	}
+
+	for role := range policy.SystemRoles {
+		subject := subjectFor(role)
+
+		for name, e := range endpoints {
+			t.Run(role+" "+name, func(t *testing.T) {
+				want := false
+				for _, a := range allowed[role] {
+					want = want || a == name
+				}
+				assert.Equal(t, want, policy.Can(subject, e.action, e.resource))
+
+				foreign := e.resource
+				foreign.CommunityID = foreignID
+				assert.False(t, policy.Can(subject, e.action, foreign), "cross-community access must be denied")
+			})
+		}
+	}
+}
+
+func TestCan_CustomRole(t *testing.T) {
+	bendahara := policy.Subject{
+		UID:         callerID,
+		CommunityID: communityID,
+		Permissions: []string{"users:read:self", "users:update:self"},
+	}
+
+	assert.True(t, policy.Can(bendahara, policy.UsersUpdate, policy.User(callerID, communityID)))
+	assert.False(t, policy.Can(bendahara, policy.UsersUpdate, policy.User(otherID, communityID)))
+	assert.False(t, policy.Can(bendahara, policy.UsersUpdate, policy.Community(policy.KindUser, communityID)))
+}
+
+func TestCan_AnonymousSubject(t *testing.T) {
+	admin := subjectFor("admin")
+	admin.UID = ""
+	assert.False(t, policy.Can(admin, policy.UsersRead, policy.Community(policy.KindUser, communityID)))
+
+	admin = subjectFor("admin")
+	admin.CommunityID = ""
+	assert.False(t, policy.Can(admin, policy.UsersRead, policy.Community(policy.KindUser, "")))
+}
+
+func TestCanGrant(t *testing.T) {
+	pengurus := subjectFor("pengurus")
+
+	assert.True(t, policy.CanGrant(pengurus, nil))
+	assert.True(t, policy.CanGrant(pengurus, []string{"users:read", "roles:read"}))
+	assert.False(t, policy.CanGrant(pengurus, []string{"users:read", "roles:manage"}))
+	assert.False(t, policy.CanGrant(pengurus, []string{"outbox:replay"}))
+
+	var all []string
+	for _, action := range policy.SystemRoles[policy.AdminRole] {
+		all = append(all, string(action))
+	}
+	assert.True(t, policy.CanGrant(subjectFor(policy.AdminRole), all))
+}
+
+func TestAuthorize(t *testing.T) {
+	err := policy.Authorize(subjectFor("warga"), policy.UsersDelete, policy.User(otherID, communityID))
+	assert.True(t, errs.CodeIs(err, errs.Forbidden), "got %v", err)
+
+	assert.NoError(t, policy.Authorize(subjectFor("admin"), policy.UsersDelete, policy.User(otherID, communityID)))
+}
@@ -0,0 +1,266 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"reflect"
+	"time"
+
+	database "github.com/dvvnFrtn/capstone-backend/infra/db/sqlc"
+	"github.com/dvvnFrtn/capstone-backend/internal/handler/middleware"
+	"github.com/dvvnFrtn/capstone-backend/internal/policy"
+	"github.com/dvvnFrtn/capstone-backend/internal/types"
+	"github.com/dvvnFrtn/capstone-backend/pkg/errs"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	AuditUserCreate  = "user.create"
+	AuditUserUpdate  = "user.update"
+	AuditUserDelete  = "user.delete"
+	AuditUserRestore = "user.restore"
+	AuditUserPurge   = "user.purge"
+
+	// AuditRevertSuffix marks the event recorded when an action is rolled
+	// back because the identity provider rejected it, as in
+	// "user.create.revert".
+	AuditRevertSuffix = ".revert"
+
+	auditTargetUser = "user"
+)
+
+// AuditChange is one field of an audit event diff.
+type AuditChange struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// auditEntry describes a change to record. A zero Actor is the system
+// itself, such as the retention job.
+type auditEntry struct {
+	CommunityID uuid.UUID
+	Actor       uuid.UUID
+	Action      string
+	TargetID    uuid.UUID
+	Before      map[string]any
+	After       map[string]any
+}
+
+// recordAudit appends the entry within the caller's transaction, so the
+// event exists exactly when the change it describes was committed.
+func recordAudit(ctx context.Context, q *database.Queries, e auditEntry) error {
+	const op errs.Op = "service.audit.record"
+
+	diff, err := json.Marshal(auditDiff(e.Before, e.After))
+	if err != nil {
+		return errs.New(op, errs.Internal, err)
+	}
+
+	requestID := types.RequestIDFrom(ctx)
+	if err := q.InsertAuditEvent(ctx, database.InsertAuditEventParams{
+		ID:          uuid.New(),
+		CommunityID: e.CommunityID,
+		ActorUid:    pgtype.UUID{Bytes: e.Actor, Valid: e.Actor != uuid.Nil},
+		Action:      e.Action,
+		TargetType:  auditTargetUser,
+		TargetID:    e.TargetID,
+		Diff:        diff,
+		RequestID:   pgtype.Text{String: requestID, Valid: requestID != ""},
+	}); err != nil {
+		return errs.New(op, errs.Internal, err)
+	}
+	return nil
+}
+
+// auditDiff keeps the fields whose value differs between the snapshots.
+func auditDiff(before, after map[string]any) map[string]AuditChange {
+	diff := make(map[string]AuditChange)
+	keys := maps.Clone(before)
+	if keys == nil {
+		keys = make(map[string]any)
+	}
+	maps.Copy(keys, after)
+
+	for key := range keys {
+		if !reflect.DeepEqual(before[key], after[key]) {
+			diff[key] = AuditChange{Before: before[key], After: after[key]}
+		}
+	}
+	return diff
+}
+
+// userSnapshot lists the audited fields of a user. Passwords are never part
+// of it; callers record a password change as auditRedacted.
+func userSnapshot(row database.FindUserByIDRow) map[string]any {
+	return map[string]any{
+		"fullname": row.Fullname,
+		"email":    nullableText(row.Email),
+		"phone":    nullableText(row.Phone),
+		"address":  nullableText(row.Address),
+		"role":     row.Role,
+	}
+}
+
+func insertedUserSnapshot(params database.InsertUserParams) map[string]any {
+	return userSnapshot(database.FindUserByIDRow{
+		Fullname: params.Fullname,
+		Email:    params.Email,
+		Phone:    params.Phone,
+		Address:  params.Address,
+		Role:     params.Role,
+	})
+}
+
+func deletedSnapshot(deleted bool) map[string]any {
+	return map[string]any{"deleted": deleted}
+}
+
+func nullableText(t pgtype.Text) any {
+	if !t.Valid {
+		return nil
+	}
+	return t.String
+}
+
+const auditRedacted = "[redacted]"
+
+func actorOf(claims *middleware.UserClaims) uuid.UUID {
+	id, _ := uuid.Parse(claims.UID)
+	return id
+}
+
+type AuditService struct {
+	pool *pgxpool.Pool
+}
+
+func NewAuditService(pool *pgxpool.Pool) AuditService {
+	return AuditService{
+		pool: pool,
+	}
+}
+
+// ListAuditRequest filters the audit trail of a community, newest first.
+type ListAuditRequest struct {
+	Action      string    `form:"action" json:"action" binding:"omitempty,max=50"`
+	ActorID     string    `form:"actor_id" json:"actor_id" binding:"omitempty,uuid"`
+	TargetID    string    `form:"target_id" json:"target_id" binding:"omitempty,uuid"`
+	CreatedFrom time.Time `form:"created_from" json:"created_from" time_format:"2006-01-02"`
+	CreatedTo   time.Time `form:"created_to" json:"created_to" time_format:"2006-01-02" binding:"omitempty,gtefield=CreatedFrom"`
+	Limit       int32     `form:"limit" json:"limit" binding:"omitempty,min=1,max=100"`
+	Cursor      string    `form:"cursor" json:"cursor"`
+}
+
+type AuditEventResponse struct {
+	ID         uuid.UUID       `json:"id"`
+	ActorUID   *uuid.UUID      `json:"actor_uid"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"target_type"`
+	TargetID   uuid.UUID       `json:"target_id"`
+	Diff       json.RawMessage `json:"diff"`
+	RequestID  string          `json:"request_id,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+type AuditPage struct {
+	Events     []*AuditEventResponse
+	Total      int64
+	NextCursor string
+}
+
+type auditCursor struct {
+	CreatedAt time.Time `json:"c"`
+	ID        uuid.UUID `json:"i"`
+}
+
+func (service *AuditService) ListEvents(ctx context.Context, claims *middleware.UserClaims, req ListAuditRequest) (_ *AuditPage, err error) {
+	const op errs.Op = "service.audit.ListEvents"
+
+	ctx, span := startSpan(ctx, "AuditService.ListEvents")
+	defer func() { endSpan(span, err) }()
+
+	if err := authorize(op, claims, policy.AuditRead, policy.Community(policy.KindAuditEvent, claims.CommunityID)); err != nil {
+		return nil, err
+	}
+
+	if req.Limit == 0 {
+		req.Limit = DefaultUserPageSize
+	}
+	req.Limit = min(req.Limit, MaxUserPageSize)
+
+	filter := database.CountAuditEventsParams{
+		CommunityID: uuid.MustParse(claims.CommunityID),
+		Action:      pgtype.Text{String: req.Action, Valid: req.Action != ""},
+		CreatedFrom: pgtype.Timestamptz{Time: req.CreatedFrom, Valid: !req.CreatedFrom.IsZero()},
+		// created_to is inclusive, so the range ends at the start of the next day
+		CreatedTo: pgtype.Timestamptz{Time: req.CreatedTo.AddDate(0, 0, 1), Valid: !req.CreatedTo.IsZero()},
+	}
+	if req.ActorID != "" {
+		filter.ActorUid = pgtype.UUID{Bytes: uuid.MustParse(req.ActorID), Valid: true}
+	}
+	if req.TargetID != "" {
+		filter.TargetID = pgtype.UUID{Bytes: uuid.MustParse(req.TargetID), Valid: true}
+	}
+
+	params := database.ListAuditEventsParams{
+		CommunityID: filter.CommunityID,
+		Action:      filter.Action,
+		ActorUid:    filter.ActorUid,
+		TargetID:    filter.TargetID,
+		CreatedFrom: filter.CreatedFrom,
+		CreatedTo:   filter.CreatedTo,
+		// one extra row tells whether there is a next page
+		PageSize: req.Limit + 1,
+	}
+	if req.Cursor != "" {
+		var cursor auditCursor
+		if err := decodeCursor(req.Cursor, &cursor); err != nil {
+			return nil, errs.New(op, errs.BadRequest, errs.Msg("Cursor tidak valid"), err)
+		}
+		params.CursorCreatedAt = pgtype.Timestamptz{Time: cursor.CreatedAt, Valid: true}
+		params.CursorID = pgtype.UUID{Bytes: cursor.ID, Valid: true}
+	}
+
+	queries := database.New(service.pool)
+
+	total, err := queries.CountAuditEvents(ctx, filter)
+	if err != nil {
+		return nil, errs.New(op, errs.Internal, err)
+	}
+
+	rows, err := queries.ListAuditEvents(ctx, params)
+	if err != nil {
+		return nil, errs.New(op, errs.Internal, err)
+	}
+
+	page := &AuditPage{Events: make([]*AuditEventResponse, 0, len(rows)), Total: total}
+	if len(rows) > int(req.Limit) {
+		rows = rows[:req.Limit]
+		last := rows[len(rows)-1]
+		page.NextCursor = encodeCursor(auditCursor{CreatedAt: last.CreatedAt.Time, ID: last.ID})
+	}
+	for _, row := range rows {
+		page.Events = append(page.Events, toAuditEventResponse(row))
+	}
+
+	return page, nil
+}
+
+func toAuditEventResponse(row database.AuditEvent) *AuditEventResponse {
+	res := &AuditEventResponse{
+		ID:         row.ID,
+		Action:     row.Action,
+		TargetType: row.TargetType,
+		TargetID:   row.TargetID,
+		Diff:       row.Diff,
+		RequestID:  row.RequestID.String,
+		CreatedAt:  row.CreatedAt.Time,
+	}
+	if row.ActorUid.Valid {
+		actor := uuid.UUID(row.ActorUid.Bytes)
+		res.ActorUID = &actor
+	}
+	return res
+}
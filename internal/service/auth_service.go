@@ -2,16 +2,29 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	"firebase.google.com/go/v4/auth"
 	"github.com/dvvnFrtn/capstone-backend/pkg/errs"
 	"github.com/google/uuid"
 )
 
+// ErrUIDExists reports that an account with the requested UID exists already.
+// UIDs are generated by us, so it means an earlier attempt got that far.
+// Every AuthService wraps it from CreateAccount, whatever the provider
+// reports first.
+var ErrUIDExists = errors.New("account uid already exists")
+
 type AuthService interface {
 	CreateAccount(ctx context.Context, req CreateAccountInput, claims map[string]interface{}) error
 	DeleteAccount(ctx context.Context, uID uuid.UUID) error
 	UpdateAccount(ctx context.Context, req UpdateAccountInput) error
+	SetClaims(ctx context.Context, uID uuid.UUID, claims map[string]interface{}) error
+	RevokeSessions(ctx context.Context, uID uuid.UUID) error
+	// SetDisabled blocks or restores sign in, keeping the account and its
+	// credentials.
+	SetDisabled(ctx context.Context, uID uuid.UUID, disabled bool) error
 }
 
 type firebaseAuthService struct {
@@ -38,29 +51,45 @@ type UpdateAccountInput struct {
 func (s *firebaseAuthService) CreateAccount(ctx context.Context, req CreateAccountInput, claims map[string]interface{}) error {
 	const op errs.Op = "service.auth.CreateAccount"
 
-	params := (&auth.UserToCreate{}).PhoneNumber(req.Phone).Password(req.Password).UID(req.UID.String()).EmailVerified(false)
+	params := (&auth.UserToCreate{}).PhoneNumber(req.Phone).UID(req.UID.String()).EmailVerified(false)
 	if req.Email != "" {
 		params = params.Email(req.Email)
 	}
+	if req.Password != "" {
+		params = params.Password(req.Password)
+	}
 
 	user, err := s.client.CreateUser(ctx, params)
 	if err != nil {
-		return errs.New(op, err, errs.Internal)
+		if auth.IsUIDAlreadyExists(err) || s.uidTaken(ctx, req.UID, err) {
+			err = fmt.Errorf("%w: %w", ErrUIDExists, err)
+		}
+		return errs.New(op, err, firebaseErrorCode(err))
 	}
 
 	err = s.client.SetCustomUserClaims(ctx, user.UID, claims)
 	if err != nil {
-		return errs.New(op, err, errs.Internal)
+		return errs.New(op, err, firebaseErrorCode(err))
 	}
 
 	return nil
 }
 
+// uidTaken tells whether a create rejected for a taken email or phone hit an
+// account with the requested UID, which Firebase may report either way.
+func (s *firebaseAuthService) uidTaken(ctx context.Context, uID uuid.UUID, err error) bool {
+	if !auth.IsEmailAlreadyExists(err) && !auth.IsPhoneNumberAlreadyExists(err) {
+		return false
+	}
+	_, getErr := s.client.GetUser(ctx, uID.String())
+	return getErr == nil
+}
+
 func (s *firebaseAuthService) DeleteAccount(ctx context.Context, uID uuid.UUID) error {
 	const op errs.Op = "service.auth.DeleteAccount"
 
 	if err := s.client.DeleteUser(ctx, uID.String()); err != nil {
-		return errs.New(op, err, errs.Internal)
+		return errs.New(op, err, firebaseErrorCode(err))
 	} else {
 		return nil
 	}
@@ -84,8 +113,51 @@ func (s *firebaseAuthService) UpdateAccount(ctx context.Context, req UpdateAccou
 	}
 
 	if _, err := s.client.UpdateUser(ctx, req.UID.String(), params); err != nil {
-		return errs.New(op, err, errs.Internal)
+		return errs.New(op, err, firebaseErrorCode(err))
+	} else {
+		return nil
+	}
+}
+
+func (s *firebaseAuthService) SetClaims(ctx context.Context, uID uuid.UUID, claims map[string]interface{}) error {
+	const op errs.Op = "service.auth.SetClaims"
+
+	if err := s.client.SetCustomUserClaims(ctx, uID.String(), claims); err != nil {
+		return errs.New(op, err, firebaseErrorCode(err))
+	} else {
+		return nil
+	}
+}
+
+func (s *firebaseAuthService) RevokeSessions(ctx context.Context, uID uuid.UUID) error {
+	const op errs.Op = "service.auth.RevokeSessions"
+
+	if err := s.client.RevokeRefreshTokens(ctx, uID.String()); err != nil {
+		return errs.New(op, err, firebaseErrorCode(err))
+	} else {
+		return nil
+	}
+}
+
+func (s *firebaseAuthService) SetDisabled(ctx context.Context, uID uuid.UUID, disabled bool) error {
+	const op errs.Op = "service.auth.SetDisabled"
+
+	if _, err := s.client.UpdateUser(ctx, uID.String(), (&auth.UserToUpdate{}).Disabled(disabled)); err != nil {
+		return errs.New(op, err, firebaseErrorCode(err))
 	} else {
 		return nil
 	}
 }
+
+func firebaseErrorCode(err error) errs.Code {
+	switch {
+	case auth.IsEmailAlreadyExists(err), auth.IsPhoneNumberAlreadyExists(err), auth.IsUIDAlreadyExists(err):
+		return errs.Conflict
+	case auth.IsUserNotFound(err):
+		return errs.NotFound
+	case auth.IsInvalidEmail(err):
+		return errs.BadRequest
+	default:
+		return errs.Internal
+	}
+}
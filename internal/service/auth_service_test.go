@@ -0,0 +1,198 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dvvnFrtn/capstone-backend/internal/service"
+	"github.com/dvvnFrtn/capstone-backend/pkg/authx"
+	"github.com/dvvnFrtn/capstone-backend/pkg/errs"
+	"github.com/dvvnFrtn/capstone-backend/pkg/testutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type authServiceHarness struct {
+	service service.AuthService
+	claims  func(uID uuid.UUID) (map[string]interface{}, bool)
+}
+
+func localAuthHarness(t *testing.T) authServiceHarness {
+	store := authx.NewLocalMemoryStore()
+	identity := authx.NewLocalIdentity(store, "test-secret", time.Hour)
+
+	return authServiceHarness{
+		service: service.NewLocalAuthService(identity),
+		claims: func(uID uuid.UUID) (map[string]interface{}, bool) {
+			acc, err := store.Get(context.Background(), uID.String())
+			if err != nil {
+				return nil, false
+			}
+			return acc.Claims, true
+		},
+	}
+}
+
+func supabaseAuthHarness(t *testing.T) authServiceHarness {
+	stub := testutil.NewGoTrueStub("service-role-key")
+	t.Cleanup(stub.Close)
+
+	return authServiceHarness{
+		service: service.NewSupabaseAuthService(authx.SupabaseConfig{
+			AnonKey:        "anon-key",
+			ServiceRoleKey: stub.ServiceRoleKey,
+			AuthURL:        stub.Server.URL,
+		}),
+		claims: func(uID uuid.UUID) (map[string]interface{}, bool) {
+			user, ok := stub.User(uID)
+			return user.AppMetadata, ok
+		},
+	}
+}
+
+func firebaseAuthHarness(t *testing.T) authServiceHarness {
+	client := testutil.NewFirebaseEmulatorAuth(t)
+
+	return authServiceHarness{
+		service: service.NewFirebaseAuthService(client),
+		claims: func(uID uuid.UUID) (map[string]interface{}, bool) {
+			user, err := client.GetUser(context.Background(), uID.String())
+			if err != nil {
+				return nil, false
+			}
+			return user.CustomClaims, true
+		},
+	}
+}
+
+func TestAuthService_Conformance(t *testing.T) {
+	harnesses := map[string]func(t *testing.T) authServiceHarness{
+		"local":    localAuthHarness,
+		"supabase": supabaseAuthHarness,
+		"firebase": firebaseAuthHarness,
+	}
+
+	for name, newHarness := range harnesses {
+		t.Run(name, func(t *testing.T) {
+			t.Run("create stores claims", func(t *testing.T) {
+				h := newHarness(t)
+				uID := uuid.New()
+				communityID := uuid.NewString()
+
+				err := h.service.CreateAccount(context.Background(), service.CreateAccountInput{
+					UID:      uID,
+					Email:    "admin@test.com",
+					Phone:    "+6281111111111",
+					Password: "password123",
+				}, map[string]interface{}{"role": "admin", "community_id": communityID})
+				require.NoError(t, err)
+
+				claims, ok := h.claims(uID)
+				require.True(t, ok)
+				assert.Equal(t, "admin", claims["role"])
+				assert.Equal(t, communityID, claims["community_id"])
+			})
+
+			t.Run("create rejects duplicate phone", func(t *testing.T) {
+				h := newHarness(t)
+				input := service.CreateAccountInput{UID: uuid.New(), Phone: "+6281111111111", Password: "password123"}
+				require.NoError(t, h.service.CreateAccount(context.Background(), input, map[string]interface{}{"role": "warga"}))
+
+				input.UID = uuid.New()
+				err := h.service.CreateAccount(context.Background(), input, map[string]interface{}{"role": "warga"})
+				assert.True(t, errs.CodeIs(err, errs.Conflict), "got %v", err)
+				assert.NotErrorIs(t, err, service.ErrUIDExists)
+			})
+
+			t.Run("create reports existing uid", func(t *testing.T) {
+				h := newHarness(t)
+				input := service.CreateAccountInput{
+					UID: uuid.New(), Email: "warga@test.com", Phone: "+6281111111111", Password: "password123",
+				}
+				require.NoError(t, h.service.CreateAccount(context.Background(), input, map[string]interface{}{"role": "warga"}))
+
+				// a redelivered create carries the same uid and login
+				err := h.service.CreateAccount(context.Background(), input, map[string]interface{}{"role": "warga"})
+				assert.ErrorIs(t, err, service.ErrUIDExists)
+				assert.True(t, errs.CodeIs(err, errs.Conflict), "got %v", err)
+			})
+
+			t.Run("update changes login fields", func(t *testing.T) {
+				h := newHarness(t)
+				uID := uuid.New()
+				require.NoError(t, h.service.CreateAccount(context.Background(), service.CreateAccountInput{
+					UID: uID, Phone: "+6281111111111", Password: "password123",
+				}, map[string]interface{}{"role": "warga"}))
+
+				err := h.service.UpdateAccount(context.Background(), service.UpdateAccountInput{
+					CreateAccountInput: service.CreateAccountInput{UID: uID, Email: "warga@test.com"},
+				})
+				assert.NoError(t, err)
+			})
+
+			t.Run("update unknown account", func(t *testing.T) {
+				h := newHarness(t)
+
+				err := h.service.UpdateAccount(context.Background(), service.UpdateAccountInput{
+					CreateAccountInput: service.CreateAccountInput{UID: uuid.New(), Email: "nobody@test.com"},
+				})
+				assert.True(t, errs.CodeIs(err, errs.NotFound), "got %v", err)
+			})
+
+			t.Run("set claims replaces role", func(t *testing.T) {
+				h := newHarness(t)
+				uID := uuid.New()
+				communityID := uuid.NewString()
+				require.NoError(t, h.service.CreateAccount(context.Background(), service.CreateAccountInput{
+					UID: uID, Phone: "+6281111111111", Password: "password123",
+				}, map[string]interface{}{"role": "warga", "community_id": communityID}))
+
+				err := h.service.SetClaims(context.Background(), uID, map[string]interface{}{"role": "pengurus", "community_id": communityID})
+				require.NoError(t, err)
+				require.NoError(t, h.service.RevokeSessions(context.Background(), uID))
+
+				claims, ok := h.claims(uID)
+				require.True(t, ok)
+				assert.Equal(t, "pengurus", claims["role"])
+				assert.Equal(t, communityID, claims["community_id"])
+			})
+
+			t.Run("delete removes account", func(t *testing.T) {
+				h := newHarness(t)
+				uID := uuid.New()
+				require.NoError(t, h.service.CreateAccount(context.Background(), service.CreateAccountInput{
+					UID: uID, Phone: "+6281111111111", Password: "password123",
+				}, map[string]interface{}{"role": "warga"}))
+
+				require.NoError(t, h.service.DeleteAccount(context.Background(), uID))
+				_, ok := h.claims(uID)
+				assert.False(t, ok)
+
+				err := h.service.DeleteAccount(context.Background(), uID)
+				assert.True(t, errs.CodeIs(err, errs.NotFound), "got %v", err)
+			})
+		})
+	}
+}
+
+func TestSupabaseAuthService_HonoursContext(t *testing.T) {
+	h := supabaseAuthHarness(t)
+	uID := uuid.New()
+	require.NoError(t, h.service.CreateAccount(context.Background(), service.CreateAccountInput{
+		UID: uID, Phone: "+6281111111111", Password: "password123",
+	}, map[string]interface{}{"role": "warga"}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := h.service.UpdateAccount(ctx, service.UpdateAccountInput{
+		CreateAccountInput: service.CreateAccountInput{UID: uID, Email: "warga@test.com"},
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.ErrorIs(t, h.service.DeleteAccount(ctx, uID), context.Canceled)
+
+	_, ok := h.claims(uID)
+	assert.True(t, ok)
+}
@@ -0,0 +1,20 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+)
+
+// encodeCursor makes the position of a keyset page opaque to clients.
+func encodeCursor(v any) string {
+	raw, _ := json.Marshal(v)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(s string, v any) error {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, v)
+}
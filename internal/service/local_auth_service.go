@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/dvvnFrtn/capstone-backend/pkg/authx"
+	"github.com/dvvnFrtn/capstone-backend/pkg/errs"
+	"github.com/google/uuid"
+)
+
+type localAuthService struct {
+	identity *authx.LocalIdentity
+}
+
+func NewLocalAuthService(identity *authx.LocalIdentity) AuthService {
+	return &localAuthService{
+		identity: identity,
+	}
+}
+
+func (s *localAuthService) CreateAccount(ctx context.Context, req CreateAccountInput, claims map[string]interface{}) error {
+	const op errs.Op = "service.auth.local.CreateAccount"
+
+	if err := s.identity.CreateUser(ctx, req.UID.String(), req.Email, req.Phone, req.Password); err != nil {
+		if errors.Is(err, authx.ErrAccountUIDExists) {
+			err = fmt.Errorf("%w: %w", ErrUIDExists, err)
+		}
+		return errs.New(op, err, localErrorCode(err))
+	}
+
+	if err := s.identity.SetCustomUserClaims(ctx, req.UID.String(), claims); err != nil {
+		return errs.New(op, err, localErrorCode(err))
+	}
+
+	return nil
+}
+
+func (s *localAuthService) DeleteAccount(ctx context.Context, uID uuid.UUID) error {
+	const op errs.Op = "service.auth.local.DeleteAccount"
+
+	if err := s.identity.DeleteUser(ctx, uID.String()); err != nil {
+		return errs.New(op, err, localErrorCode(err))
+	} else {
+		return nil
+	}
+}
+
+func (s *localAuthService) UpdateAccount(ctx context.Context, req UpdateAccountInput) error {
+	const op errs.Op = "service.auth.local.UpdateAccount"
+
+	if err := s.identity.UpdateUser(ctx, req.UID.String(), authx.LocalUserToUpdate{
+		Email:    req.Email,
+		Phone:    req.Phone,
+		Password: req.Password,
+	}); err != nil {
+		return errs.New(op, err, localErrorCode(err))
+	} else {
+		return nil
+	}
+}
+
+func (s *localAuthService) SetClaims(ctx context.Context, uID uuid.UUID, claims map[string]interface{}) error {
+	const op errs.Op = "service.auth.local.SetClaims"
+
+	if err := s.identity.SetCustomUserClaims(ctx, uID.String(), claims); err != nil {
+		return errs.New(op, err, localErrorCode(err))
+	} else {
+		return nil
+	}
+}
+
+func (s *localAuthService) RevokeSessions(ctx context.Context, uID uuid.UUID) error {
+	const op errs.Op = "service.auth.local.RevokeSessions"
+
+	if err := s.identity.RevokeTokens(ctx, uID.String()); err != nil {
+		return errs.New(op, err, localErrorCode(err))
+	} else {
+		return nil
+	}
+}
+
+func (s *localAuthService) SetDisabled(ctx context.Context, uID uuid.UUID, disabled bool) error {
+	const op errs.Op = "service.auth.local.SetDisabled"
+
+	if err := s.identity.SetDisabled(ctx, uID.String(), disabled); err != nil {
+		return errs.New(op, err, localErrorCode(err))
+	} else {
+		return nil
+	}
+}
+
+func localErrorCode(err error) errs.Code {
+	switch {
+	case errors.Is(err, authx.ErrAccountExists):
+		return errs.Conflict
+	case errors.Is(err, authx.ErrAccountNotFound):
+		return errs.NotFound
+	default:
+		return errs.Internal
+	}
+}
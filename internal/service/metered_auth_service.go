@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/dvvnFrtn/capstone-backend/internal/metrics"
+	"github.com/dvvnFrtn/capstone-backend/pkg/errs"
+	"github.com/google/uuid"
+)
+
+type meteredAuthService struct {
+	next     AuthService
+	provider string
+}
+
+// NewMeteredAuthService wraps next to record the latency and failures of
+// every call to the identity provider.
+func NewMeteredAuthService(next AuthService, provider string) AuthService {
+	return &meteredAuthService{next: next, provider: provider}
+}
+
+func (s *meteredAuthService) observe(method string, start time.Time, err error) {
+	metrics.AuthDuration.WithLabelValues(s.provider, method).Observe(time.Since(start).Seconds())
+	if err == nil {
+		return
+	}
+
+	code := errs.Unexpected
+	var apperr *errs.Error
+	if errors.As(err, &apperr) {
+		code = apperr.Code
+	}
+	metrics.AuthFailures.WithLabelValues(s.provider, method, code.String()).Inc()
+}
+
+func (s *meteredAuthService) CreateAccount(ctx context.Context, req CreateAccountInput, claims map[string]interface{}) (err error) {
+	defer func(start time.Time) { s.observe("CreateAccount", start, err) }(time.Now())
+
+	return s.next.CreateAccount(ctx, req, claims)
+}
+
+func (s *meteredAuthService) DeleteAccount(ctx context.Context, uID uuid.UUID) (err error) {
+	defer func(start time.Time) { s.observe("DeleteAccount", start, err) }(time.Now())
+
+	return s.next.DeleteAccount(ctx, uID)
+}
+
+func (s *meteredAuthService) UpdateAccount(ctx context.Context, req UpdateAccountInput) (err error) {
+	defer func(start time.Time) { s.observe("UpdateAccount", start, err) }(time.Now())
+
+	return s.next.UpdateAccount(ctx, req)
+}
+
+func (s *meteredAuthService) SetClaims(ctx context.Context, uID uuid.UUID, claims map[string]interface{}) (err error) {
+	defer func(start time.Time) { s.observe("SetClaims", start, err) }(time.Now())
+
+	return s.next.SetClaims(ctx, uID, claims)
+}
+
+func (s *meteredAuthService) RevokeSessions(ctx context.Context, uID uuid.UUID) (err error) {
+	defer func(start time.Time) { s.observe("RevokeSessions", start, err) }(time.Now())
+
+	return s.next.RevokeSessions(ctx, uID)
+}
+
+func (s *meteredAuthService) SetDisabled(ctx context.Context, uID uuid.UUID, disabled bool) (err error) {
+	defer func(start time.Time) { s.observe("SetDisabled", start, err) }(time.Now())
+
+	return s.next.SetDisabled(ctx, uID, disabled)
+}
@@ -0,0 +1,349 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/dvvnFrtn/capstone-backend/config"
+	database "github.com/dvvnFrtn/capstone-backend/infra/db/sqlc"
+	"github.com/dvvnFrtn/capstone-backend/internal/handler/middleware"
+	"github.com/dvvnFrtn/capstone-backend/internal/policy"
+	"github.com/dvvnFrtn/capstone-backend/internal/types"
+	"github.com/dvvnFrtn/capstone-backend/pkg/errs"
+	"github.com/dvvnFrtn/capstone-backend/pkg/secretbox"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	OutboxCreateAccount  = "account.create"
+	OutboxUpdateAccount  = "account.update"
+	OutboxDeleteAccount  = "account.delete"
+	OutboxDisableAccount = "account.disable"
+	OutboxEnableAccount  = "account.enable"
+
+	outboxStatusPending = "pending"
+	outboxStatusDead    = "dead"
+)
+
+type OutboxService struct {
+	authService AuthService
+	pool        *pgxpool.Pool
+	cfg         config.Outbox
+	box         *secretbox.Box
+	logger      *slog.Logger
+}
+
+// NewOutboxService seals the passwords of queued events with box.
+func NewOutboxService(logger *slog.Logger, pool *pgxpool.Pool, as AuthService, cfg config.Outbox, box *secretbox.Box) OutboxService {
+	return OutboxService{
+		authService: as,
+		pool:        pool,
+		cfg:         cfg,
+		box:         box,
+		logger:      logger,
+	}
+}
+
+// accountEventPayload never stores Password in the clear: enqueue seals it
+// into SealedPassword.
+type accountEventPayload struct {
+	UID            uuid.UUID              `json:"uid"`
+	Email          string                 `json:"email,omitempty"`
+	Phone          string                 `json:"phone,omitempty"`
+	Password       string                 `json:"-"`
+	SealedPassword string                 `json:"sealed_password,omitempty"`
+	Claims         map[string]interface{} `json:"claims,omitempty"`
+	Revoke         bool                   `json:"revoke,omitempty"`
+}
+
+// enqueue stores the event inside the caller's transaction. The first delivery
+// attempt is made synchronously by the request, so the row is leased for one
+// lease period to keep the background dispatcher from racing it.
+func (s *OutboxService) enqueue(ctx context.Context, q *database.Queries, communityID uuid.UUID, eventType string, payload accountEventPayload) (database.OutboxEvent, error) {
+	const op errs.Op = "service.outbox.enqueue"
+
+	if payload.Password != "" {
+		sealed, err := s.box.Seal(payload.Password)
+		if err != nil {
+			return database.OutboxEvent{}, errs.New(op, errs.Internal, err)
+		}
+		payload.SealedPassword = sealed
+	}
+
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return database.OutboxEvent{}, errs.New(op, errs.Internal, err)
+	}
+
+	event, err := q.InsertOutboxEvent(ctx, database.InsertOutboxEventParams{
+		ID:           uuid.New(),
+		CommunityID:  communityID,
+		AggregateID:  payload.UID,
+		EventType:    eventType,
+		Payload:      raw,
+		DelaySeconds: s.cfg.Lease.Seconds(),
+	})
+	if err != nil {
+		return database.OutboxEvent{}, errs.New(op, errs.Internal, err)
+	}
+
+	return event, nil
+}
+
+func (s *OutboxService) discard(ctx context.Context, eventID uuid.UUID) error {
+	const op errs.Op = "service.outbox.discard"
+
+	if err := database.New(s.pool).DeleteOutboxEvent(ctx, eventID); err != nil {
+		return errs.New(op, errs.Internal, err)
+	}
+	return nil
+}
+
+// DeliverNow is the synchronous first attempt made by the request that wrote
+// the event. Rejections from the identity provider are returned so the caller
+// can roll back; transient failures are left to the background dispatcher.
+func (s *OutboxService) DeliverNow(ctx context.Context, event database.OutboxEvent) error {
+	const op errs.Op = "service.outbox.DeliverNow"
+
+	err := s.deliver(ctx, event)
+	if err != nil && isPermanentDeliveryError(err) {
+		return errs.New(op, err)
+	}
+
+	if recErr := s.record(ctx, event, err); recErr != nil {
+		if err == nil {
+			// The provider already holds the change, so failing here would
+			// roll back only the database. The event stays pending and the
+			// dispatcher records it on redelivery.
+			s.logger.ErrorContext(ctx, "outbox delivery not recorded", "event_id", event.ID, "event_type", event.EventType, "err", recErr)
+			return nil
+		}
+		return errs.New(op, recErr)
+	}
+
+	if err != nil {
+		s.logger.WarnContext(ctx, "outbox delivery deferred", "event_id", event.ID, "event_type", event.EventType, "err", err)
+	}
+
+	return nil
+}
+
+func (s *OutboxService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// each tick gets its own ID so its deliveries can be traced in the logs
+			tickCtx := types.WithRequestID(ctx, uuid.NewString())
+			if err := s.DispatchPending(tickCtx); err != nil {
+				s.logger.ErrorContext(tickCtx, "outbox dispatch failed", "stack", errs.OpStack(err), "err", err)
+			}
+		}
+	}
+}
+
+func (s *OutboxService) DispatchPending(ctx context.Context) error {
+	const op errs.Op = "service.outbox.DispatchPending"
+
+	events, err := database.New(s.pool).ClaimOutboxEvents(ctx, database.ClaimOutboxEventsParams{
+		LeaseSeconds: s.cfg.Lease.Seconds(),
+		BatchSize:    s.cfg.BatchSize,
+	})
+	if err != nil {
+		return errs.New(op, errs.Internal, err)
+	}
+
+	for _, event := range events {
+		if err := s.record(ctx, event, s.deliver(ctx, event)); err != nil {
+			return errs.New(op, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *OutboxService) deliver(ctx context.Context, event database.OutboxEvent) error {
+	const op errs.Op = "service.outbox.deliver"
+
+	var payload accountEventPayload
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		return errs.New(op, errs.BadRequest, err)
+	}
+	if payload.SealedPassword != "" {
+		password, err := s.box.Open(payload.SealedPassword)
+		if err != nil {
+			return errs.New(op, errs.BadRequest, err)
+		}
+		payload.Password = password
+	}
+
+	switch event.EventType {
+	case OutboxCreateAccount:
+		err := s.authService.CreateAccount(ctx, CreateAccountInput{
+			UID:      payload.UID,
+			Email:    payload.Email,
+			Phone:    payload.Phone,
+			Password: payload.Password,
+		}, payload.Claims)
+		if errors.Is(err, ErrUIDExists) {
+			// an earlier attempt created the account but failed afterwards,
+			// so only its claims may be missing
+			return s.authService.SetClaims(ctx, payload.UID, payload.Claims)
+		}
+		return err
+	case OutboxUpdateAccount:
+		if payload.Email != "" || payload.Phone != "" || payload.Password != "" {
+			if err := s.authService.UpdateAccount(ctx, UpdateAccountInput{
+				CreateAccountInput: CreateAccountInput{
+					UID:      payload.UID,
+					Email:    payload.Email,
+					Phone:    payload.Phone,
+					Password: payload.Password,
+				},
+			}); err != nil {
+				return err
+			}
+		}
+		if payload.Claims != nil {
+			if err := s.authService.SetClaims(ctx, payload.UID, payload.Claims); err != nil {
+				return err
+			}
+		}
+		if payload.Revoke {
+			return s.authService.RevokeSessions(ctx, payload.UID)
+		}
+		return nil
+	case OutboxDeleteAccount:
+		err := s.authService.DeleteAccount(ctx, payload.UID)
+		if errs.CodeIs(err, errs.NotFound) {
+			return nil
+		}
+		return err
+	case OutboxDisableAccount, OutboxEnableAccount:
+		return s.authService.SetDisabled(ctx, payload.UID, event.EventType == OutboxDisableAccount)
+	default:
+		return errs.New(op, errs.BadRequest, fmt.Sprintf("unknown outbox event type %q", event.EventType))
+	}
+}
+
+func (s *OutboxService) record(ctx context.Context, event database.OutboxEvent, deliveryErr error) error {
+	const op errs.Op = "service.outbox.record"
+
+	queries := database.New(s.pool)
+
+	if deliveryErr == nil {
+		if err := queries.MarkOutboxEventDelivered(ctx, event.ID); err != nil {
+			return errs.New(op, errs.Internal, err)
+		}
+		return nil
+	}
+
+	status := outboxStatusPending
+	if isPermanentDeliveryError(deliveryErr) || event.Attempts+1 >= s.cfg.MaxAttempts {
+		status = outboxStatusDead
+		s.logger.ErrorContext(ctx, "outbox event dead lettered", "event_id", event.ID, "event_type", event.EventType, "err", deliveryErr)
+	}
+
+	if err := queries.MarkOutboxEventFailed(ctx, database.MarkOutboxEventFailedParams{
+		ID:                event.ID,
+		Status:            status,
+		LastError:         deliveryErr.Error(),
+		RetryAfterSeconds: s.backoff(event.Attempts).Seconds(),
+	}); err != nil {
+		return errs.New(op, errs.Internal, err)
+	}
+
+	return nil
+}
+
+func (s *OutboxService) backoff(attempts int32) time.Duration {
+	delay := s.cfg.BaseBackoff
+	for i := int32(0); i < attempts && delay < s.cfg.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, s.cfg.MaxBackoff)
+}
+
+func isPermanentDeliveryError(err error) bool {
+	return errs.CodeIs(err, errs.Conflict) || errs.CodeIs(err, errs.BadRequest) || errs.CodeIs(err, errs.NotFound)
+}
+
+func (s *OutboxService) GetStuckEvents(ctx context.Context, claims *middleware.UserClaims) ([]*OutboxEventResponse, error) {
+	const op errs.Op = "service.outbox.GetStuckEvents"
+
+	if err := authorize(op, claims, policy.OutboxRead, policy.Community(policy.KindOutboxEvent, claims.CommunityID)); err != nil {
+		return nil, err
+	}
+
+	rows, err := database.New(s.pool).FindStuckOutboxEvents(ctx, uuid.MustParse(claims.CommunityID))
+	if err != nil {
+		return nil, errs.New(op, errs.Internal, err)
+	}
+
+	responses := make([]*OutboxEventResponse, 0, len(rows))
+	for _, row := range rows {
+		responses = append(responses, toOutboxEventResponse(row))
+	}
+
+	return responses, nil
+}
+
+func (s *OutboxService) ReplayEvent(ctx context.Context, claims *middleware.UserClaims, eventID uuid.UUID) (*OutboxEventResponse, error) {
+	const op errs.Op = "service.outbox.ReplayEvent"
+
+	if err := authorize(op, claims, policy.OutboxReplay, policy.Community(policy.KindOutboxEvent, claims.CommunityID)); err != nil {
+		return nil, err
+	}
+
+	row, err := database.New(s.pool).ReplayOutboxEvent(ctx, database.ReplayOutboxEventParams{
+		ID:          eventID,
+		CommunityID: uuid.MustParse(claims.CommunityID),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errs.New(op, errs.NotFound, "Event tidak dapat ditemukan")
+		}
+		return nil, errs.New(op, errs.Internal, err)
+	}
+
+	return toOutboxEventResponse(row), nil
+}
+
+type OutboxEventResponse struct {
+	ID            uuid.UUID  `json:"id"`
+	AggregateID   uuid.UUID  `json:"aggregate_id"`
+	EventType     string     `json:"event_type"`
+	Status        string     `json:"status"`
+	Attempts      int32      `json:"attempts"`
+	LastError     string     `json:"last_error,omitempty"`
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	DeliveredAt   *time.Time `json:"delivered_at,omitempty"`
+}
+
+func toOutboxEventResponse(row database.OutboxEvent) *OutboxEventResponse {
+	res := &OutboxEventResponse{
+		ID:            row.ID,
+		AggregateID:   row.AggregateID,
+		EventType:     row.EventType,
+		Status:        row.Status,
+		Attempts:      row.Attempts,
+		LastError:     row.LastError.String,
+		NextAttemptAt: row.NextAttemptAt.Time,
+		CreatedAt:     row.CreatedAt.Time,
+	}
+	if row.DeliveredAt.Valid {
+		res.DeliveredAt = &row.DeliveredAt.Time
+	}
+	return res
+}
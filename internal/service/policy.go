@@ -0,0 +1,14 @@
+package service
+
+import (
+	"github.com/dvvnFrtn/capstone-backend/internal/handler/middleware"
+	"github.com/dvvnFrtn/capstone-backend/internal/policy"
+	"github.com/dvvnFrtn/capstone-backend/pkg/errs"
+)
+
+func authorize(op errs.Op, claims *middleware.UserClaims, action policy.Action, resource policy.Resource) error {
+	if err := policy.Authorize(claims.Subject(), action, resource); err != nil {
+		return errs.New(op, err)
+	}
+	return nil
+}
@@ -0,0 +1,319 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/dvvnFrtn/capstone-backend/infra/db"
+	database "github.com/dvvnFrtn/capstone-backend/infra/db/sqlc"
+	"github.com/dvvnFrtn/capstone-backend/internal/handler/middleware"
+	"github.com/dvvnFrtn/capstone-backend/internal/policy"
+	"github.com/dvvnFrtn/capstone-backend/pkg/errs"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type RoleService struct {
+	pool *pgxpool.Pool
+}
+
+func NewRoleService(pool *pgxpool.Pool) RoleService {
+	return RoleService{
+		pool: pool,
+	}
+}
+
+func (service *RoleService) RolePermissions(ctx context.Context, role, communityID string) ([]string, error) {
+	const op errs.Op = "service.role.RolePermissions"
+
+	comID, err := uuid.Parse(communityID)
+	if err != nil {
+		return nil, errs.New(op, errs.Unauthorize, err)
+	}
+
+	permissions, err := database.New(service.pool).FindRolePermissions(ctx, database.FindRolePermissionsParams{
+		Name:        role,
+		CommunityID: pgtype.UUID{Bytes: comID, Valid: true},
+	})
+	if err != nil {
+		return nil, errs.New(op, errs.Internal, err)
+	}
+
+	return permissions, nil
+}
+
+func (service *RoleService) GetPermissions(ctx context.Context, claims *middleware.UserClaims) ([]*PermissionResponse, error) {
+	const op errs.Op = "service.role.GetPermissions"
+
+	if err := authorize(op, claims, policy.RolesRead, policy.Community(policy.KindPermission, claims.CommunityID)); err != nil {
+		return nil, err
+	}
+
+	rows, err := database.New(service.pool).FindPermissions(ctx)
+	if err != nil {
+		return nil, errs.New(op, errs.Internal, err)
+	}
+
+	var responses []*PermissionResponse
+	for _, row := range rows {
+		responses = append(responses, &PermissionResponse{Name: row.Name, Description: row.Description})
+	}
+
+	return responses, nil
+}
+
+func (service *RoleService) GetRoles(ctx context.Context, claims *middleware.UserClaims) ([]*RoleResponse, error) {
+	const op errs.Op = "service.role.GetRoles"
+
+	if err := authorize(op, claims, policy.RolesRead, policy.Community(policy.KindRole, claims.CommunityID)); err != nil {
+		return nil, err
+	}
+
+	communityID := pgtype.UUID{Bytes: uuid.MustParse(claims.CommunityID), Valid: true}
+	queries := database.New(service.pool)
+
+	roles, err := queries.FindRolesByCommunityID(ctx, communityID)
+	if err != nil {
+		return nil, errs.New(op, errs.Internal, err)
+	}
+
+	grants, err := queries.FindRolePermissionsByCommunityID(ctx, communityID)
+	if err != nil {
+		return nil, errs.New(op, errs.Internal, err)
+	}
+
+	permissions := make(map[uuid.UUID][]string)
+	for _, grant := range grants {
+		permissions[grant.RoleID] = append(permissions[grant.RoleID], grant.Permission)
+	}
+
+	var responses []*RoleResponse
+	for _, role := range roles {
+		responses = append(responses, toRoleResponse(role, permissions[role.ID]))
+	}
+
+	return responses, nil
+}
+
+func (service *RoleService) CreateRole(ctx context.Context, claims *middleware.UserClaims, req CreateRoleRequest) (*RoleResponse, error) {
+	const op errs.Op = "service.role.CreateRole"
+
+	if err := authorize(op, claims, policy.RolesManage, policy.Community(policy.KindRole, claims.CommunityID)); err != nil {
+		return nil, err
+	}
+
+	var (
+		name        = strings.ToLower(strings.TrimSpace(req.Name))
+		communityID = pgtype.UUID{Bytes: uuid.MustParse(claims.CommunityID), Valid: true}
+		created     database.Role
+	)
+
+	if err := db.RunTransaction(ctx, service.pool, func(q *database.Queries) error {
+		exists, err := q.IsRoleAssignable(ctx, database.IsRoleAssignableParams{Name: name, CommunityID: communityID})
+		if err != nil {
+			return errs.New(op, errs.Internal, err)
+		}
+		if exists {
+			return errs.New(op, errs.Conflict, errs.Msg("Peran sudah digunakan"))
+		}
+
+		created, err = q.InsertRole(ctx, database.InsertRoleParams{
+			CommunityID: communityID,
+			Name:        name,
+			Description: req.Description,
+		})
+		if err != nil {
+			return errs.New(op, errs.Internal, err)
+		}
+
+		return grantPermissions(ctx, op, q, claims, created.ID, req.Permissions)
+	}); err != nil {
+		return nil, errs.New(op, err)
+	}
+
+	return toRoleResponse(created, req.Permissions), nil
+}
+
+func (service *RoleService) UpdateRole(ctx context.Context, claims *middleware.UserClaims, roleID uuid.UUID, req UpdateRoleRequest) error {
+	const op errs.Op = "service.role.UpdateRole"
+
+	if err := db.RunTransaction(ctx, service.pool, func(q *database.Queries) error {
+		role, err := service.findCommunityRole(ctx, op, q, claims, roleID)
+		if err != nil {
+			return err
+		}
+		if err := authorize(op, claims, policy.RolesManage, policy.Community(policy.KindRole, uuid.UUID(role.CommunityID.Bytes).String())); err != nil {
+			return err
+		}
+
+		if err := q.UpdateRole(ctx, database.UpdateRoleParams{
+			ID:          role.ID,
+			Description: pgtype.Text{String: req.Description, Valid: req.Description != ""},
+		}); err != nil {
+			return errs.New(op, errs.Internal, err)
+		}
+
+		if req.Permissions == nil {
+			return nil
+		}
+		if err := q.DeleteRolePermissions(ctx, role.ID); err != nil {
+			return errs.New(op, errs.Internal, err)
+		}
+		return grantPermissions(ctx, op, q, claims, role.ID, req.Permissions)
+	}); err != nil {
+		return errs.New(op, err)
+	}
+
+	return nil
+}
+
+func (service *RoleService) DeleteRole(ctx context.Context, claims *middleware.UserClaims, roleID uuid.UUID) error {
+	const op errs.Op = "service.role.DeleteRole"
+
+	if err := db.RunTransaction(ctx, service.pool, func(q *database.Queries) error {
+		role, err := service.findCommunityRole(ctx, op, q, claims, roleID)
+		if err != nil {
+			return err
+		}
+		if err := authorize(op, claims, policy.RolesManage, policy.Community(policy.KindRole, uuid.UUID(role.CommunityID.Bytes).String())); err != nil {
+			return err
+		}
+
+		inUse, err := q.IsRoleInUse(ctx, database.IsRoleInUseParams{
+			CommunityID: uuid.MustParse(claims.CommunityID),
+			Role:        role.Name,
+		})
+		if err != nil {
+			return errs.New(op, errs.Internal, err)
+		}
+		if inUse {
+			return errs.New(op, errs.Conflict, errs.Msg("Peran masih digunakan oleh warga"))
+		}
+
+		if err := q.DeleteRole(ctx, role.ID); err != nil {
+			return errs.New(op, errs.Internal, err)
+		}
+		return nil
+	}); err != nil {
+		return errs.New(op, err)
+	}
+
+	return nil
+}
+
+// findCommunityRole only resolves roles owned by the caller's community, so
+// the shared system roles can never be changed through the API.
+func (service *RoleService) findCommunityRole(ctx context.Context, op errs.Op, q *database.Queries, claims *middleware.UserClaims, roleID uuid.UUID) (database.Role, error) {
+	role, err := q.FindRoleByID(ctx, database.FindRoleByIDParams{
+		ID:          roleID,
+		CommunityID: pgtype.UUID{Bytes: uuid.MustParse(claims.CommunityID), Valid: true},
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return database.Role{}, errs.New(op, errs.NotFound, errs.Msg("Peran tidak dapat ditemukan"))
+		}
+		return database.Role{}, errs.New(op, errs.Internal, err)
+	}
+	return role, nil
+}
+
+func grantPermissions(ctx context.Context, op errs.Op, q *database.Queries, claims *middleware.UserClaims, roleID uuid.UUID, permissions []string) error {
+	if len(permissions) == 0 {
+		return nil
+	}
+	if err := ensureGrantable(op, claims, permissions); err != nil {
+		return err
+	}
+
+	slices.Sort(permissions)
+	if err := q.InsertRolePermissions(ctx, database.InsertRolePermissionsParams{
+		RoleID:      roleID,
+		Permissions: slices.Compact(permissions),
+	}); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return errs.New(op, errs.BadRequest, errs.Msg("Hak akses tidak dikenal"), err)
+		}
+		return errs.New(op, errs.Internal, err)
+	}
+	return nil
+}
+
+func ensureGrantable(op errs.Op, claims *middleware.UserClaims, permissions []string) error {
+	if !policy.CanGrant(claims.Subject(), permissions) {
+		return errs.New(op, errs.Forbidden, errs.Msg("Tidak dapat memberikan hak akses yang tidak anda miliki"))
+	}
+	return nil
+}
+
+// ensureRoleAssignable rejects roles that are neither a system role nor a
+// custom role of the community, and roles the caller could not have built
+// themselves: admin stays reserved for admins and any other role may only
+// carry permissions the caller holds.
+func ensureRoleAssignable(ctx context.Context, op errs.Op, q *database.Queries, claims *middleware.UserClaims, role string, communityID uuid.UUID) error {
+	ok, err := q.IsRoleAssignable(ctx, database.IsRoleAssignableParams{
+		Name:        role,
+		CommunityID: pgtype.UUID{Bytes: communityID, Valid: true},
+	})
+	if err != nil {
+		return errs.New(op, errs.Internal, err)
+	}
+	if !ok {
+		return errs.New(op, errs.BadRequest, errs.Msg("Peran tidak dapat ditemukan"))
+	}
+
+	if role == policy.AdminRole && claims.Role != policy.AdminRole {
+		return errs.New(op, errs.Forbidden, errs.Msg("Hanya admin yang dapat memberikan peran admin"))
+	}
+
+	permissions, err := q.FindRolePermissions(ctx, database.FindRolePermissionsParams{
+		Name:        role,
+		CommunityID: pgtype.UUID{Bytes: communityID, Valid: true},
+	})
+	if err != nil {
+		return errs.New(op, errs.Internal, err)
+	}
+	return ensureGrantable(op, claims, permissions)
+}
+
+type CreateRoleRequest struct {
+	Name        string   `json:"name" binding:"required,role"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+type UpdateRoleRequest struct {
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+type PermissionResponse struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type RoleResponse struct {
+	ID          uuid.UUID `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	System      bool      `json:"system"`
+	Permissions []string  `json:"permissions"`
+}
+
+func toRoleResponse(row database.Role, permissions []string) *RoleResponse {
+	if permissions == nil {
+		permissions = []string{}
+	}
+	return &RoleResponse{
+		ID:          row.ID,
+		Name:        row.Name,
+		Description: row.Description,
+		System:      !row.CommunityID.Valid,
+		Permissions: permissions,
+	}
+}
@@ -0,0 +1,185 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/dvvnFrtn/capstone-backend/pkg/authx"
+	"github.com/dvvnFrtn/capstone-backend/pkg/errs"
+	"github.com/google/uuid"
+	"github.com/supabase-community/auth-go/types"
+)
+
+type supabaseAuthService struct {
+	cfg  authx.SupabaseConfig
+	http *http.Client
+}
+
+func NewSupabaseAuthService(cfg authx.SupabaseConfig) AuthService {
+	return &supabaseAuthService{
+		cfg:  cfg,
+		http: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// supabaseCreateUserRequest extends the GoTrue admin payload with "id". The
+// auth-go request type has no such field, and the account must share its ID
+// with the users row so token subjects map back to residents.
+type supabaseCreateUserRequest struct {
+	ID uuid.UUID `json:"id"`
+	types.AdminCreateUserRequest
+}
+
+func (s *supabaseAuthService) CreateAccount(ctx context.Context, req CreateAccountInput, claims map[string]interface{}) error {
+	const op errs.Op = "service.auth.supabase.CreateAccount"
+
+	var password *string
+	if req.Password != "" {
+		password = &req.Password
+	}
+	if err := s.admin(ctx, http.MethodPost, "/admin/users", supabaseCreateUserRequest{
+		ID: req.UID,
+		AdminCreateUserRequest: types.AdminCreateUserRequest{
+			Email:        req.Email,
+			Phone:        req.Phone,
+			Password:     password,
+			PhoneConfirm: true,
+			AppMetadata:  claims,
+		},
+	}); err != nil {
+		// GoTrue reports a taken email or phone before a taken id
+		if errs.CodeIs(err, errs.Conflict) && s.admin(ctx, http.MethodGet, "/admin/users/"+req.UID.String(), nil) == nil {
+			return errs.New(op, errs.Conflict, fmt.Errorf("%w: %w", ErrUIDExists, err))
+		}
+		return errs.New(op, err)
+	}
+
+	return nil
+}
+
+func (s *supabaseAuthService) DeleteAccount(ctx context.Context, uID uuid.UUID) error {
+	const op errs.Op = "service.auth.supabase.DeleteAccount"
+
+	if err := s.admin(ctx, http.MethodDelete, "/admin/users/"+uID.String(), nil); err != nil {
+		return errs.New(op, err)
+	}
+
+	return nil
+}
+
+func (s *supabaseAuthService) UpdateAccount(ctx context.Context, req UpdateAccountInput) error {
+	const op errs.Op = "service.auth.supabase.UpdateAccount"
+
+	if err := s.updateUser(ctx, types.AdminUpdateUserRequest{
+		UserID:   req.UID,
+		Email:    req.Email,
+		Phone:    req.Phone,
+		Password: req.Password,
+	}); err != nil {
+		return errs.New(op, err)
+	}
+
+	return nil
+}
+
+func (s *supabaseAuthService) SetClaims(ctx context.Context, uID uuid.UUID, claims map[string]interface{}) error {
+	const op errs.Op = "service.auth.supabase.SetClaims"
+
+	if err := s.updateUser(ctx, types.AdminUpdateUserRequest{
+		UserID:      uID,
+		AppMetadata: claims,
+	}); err != nil {
+		return errs.New(op, err)
+	}
+
+	return nil
+}
+
+// RevokeSessions is a no-op: GoTrue has no admin-side global sign out. The
+// auth middleware rejects stale access tokens instead, by comparing their
+// role with the database and their issue time with tokens_valid_after.
+func (s *supabaseAuthService) RevokeSessions(ctx context.Context, uID uuid.UUID) error {
+	return nil
+}
+
+// supabaseBanForever stands in for disabling, which GoTrue models as a ban.
+const supabaseBanForever = 100 * 365 * 24 * time.Hour
+
+func (s *supabaseAuthService) SetDisabled(ctx context.Context, uID uuid.UUID, disabled bool) error {
+	const op errs.Op = "service.auth.supabase.SetDisabled"
+
+	ban := types.BanDurationNone()
+	if disabled {
+		ban = types.BanDurationTime(supabaseBanForever)
+	}
+
+	if err := s.updateUser(ctx, types.AdminUpdateUserRequest{
+		UserID:      uID,
+		BanDuration: &ban,
+	}); err != nil {
+		return errs.New(op, err)
+	}
+
+	return nil
+}
+
+func (s *supabaseAuthService) updateUser(ctx context.Context, req types.AdminUpdateUserRequest) error {
+	return s.admin(ctx, http.MethodPut, "/admin/users/"+req.UserID.String(), req)
+}
+
+// admin calls the GoTrue admin API directly rather than through auth-go,
+// whose admin methods take no context and only report the status inside the
+// error text.
+func (s *supabaseAuthService) admin(ctx context.Context, method, path string, payload any) error {
+	const op errs.Op = "service.auth.supabase.admin"
+
+	var body io.Reader
+	if payload != nil {
+		b, err := json.Marshal(payload)
+		if err != nil {
+			return errs.New(op, errs.Internal, err)
+		}
+		body = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, s.cfg.URL()+path, body)
+	if err != nil {
+		return errs.New(op, errs.Internal, err)
+	}
+	req.Header.Set("apikey", s.cfg.AnonKey)
+	req.Header.Set("Authorization", "Bearer "+s.cfg.ServiceRoleKey)
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := s.http.Do(req)
+	if err != nil {
+		return errs.New(op, errs.Internal, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		msg, _ := io.ReadAll(res.Body)
+		return errs.New(op, supabaseErrorCode(res.StatusCode), fmt.Sprintf("response status code %d: %s", res.StatusCode, msg))
+	}
+
+	return nil
+}
+
+func supabaseErrorCode(status int) errs.Code {
+	switch status {
+	case http.StatusUnprocessableEntity, http.StatusConflict:
+		return errs.Conflict
+	case http.StatusNotFound:
+		return errs.NotFound
+	case http.StatusBadRequest:
+		return errs.BadRequest
+	default:
+		return errs.Internal
+	}
+}
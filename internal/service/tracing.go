@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/dvvnFrtn/capstone-backend/pkg/errs"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/dvvnFrtn/capstone-backend/internal/service")
+
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// endSpan ends span, marking it failed when err is set. Call it deferred
+// with the method's named error result.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		var apperr *errs.Error
+		if errors.As(err, &apperr) {
+			span.SetAttributes(attribute.String("error.code", apperr.Code.String()))
+		}
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+type tracedAuthService struct {
+	next     AuthService
+	provider string
+}
+
+// NewTracedAuthService wraps next so every call to the identity provider gets
+// its own span.
+func NewTracedAuthService(next AuthService, provider string) AuthService {
+	return &tracedAuthService{next: next, provider: provider}
+}
+
+func (s *tracedAuthService) start(ctx context.Context, method string, uID uuid.UUID) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "AuthService."+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("auth.provider", s.provider),
+			attribute.String("user.id", uID.String()),
+		),
+	)
+}
+
+func (s *tracedAuthService) CreateAccount(ctx context.Context, req CreateAccountInput, claims map[string]interface{}) (err error) {
+	ctx, span := s.start(ctx, "CreateAccount", req.UID)
+	defer func() { endSpan(span, err) }()
+
+	return s.next.CreateAccount(ctx, req, claims)
+}
+
+func (s *tracedAuthService) DeleteAccount(ctx context.Context, uID uuid.UUID) (err error) {
+	ctx, span := s.start(ctx, "DeleteAccount", uID)
+	defer func() { endSpan(span, err) }()
+
+	return s.next.DeleteAccount(ctx, uID)
+}
+
+func (s *tracedAuthService) UpdateAccount(ctx context.Context, req UpdateAccountInput) (err error) {
+	ctx, span := s.start(ctx, "UpdateAccount", req.UID)
+	defer func() { endSpan(span, err) }()
+
+	return s.next.UpdateAccount(ctx, req)
+}
+
+func (s *tracedAuthService) SetClaims(ctx context.Context, uID uuid.UUID, claims map[string]interface{}) (err error) {
+	ctx, span := s.start(ctx, "SetClaims", uID)
+	defer func() { endSpan(span, err) }()
+
+	return s.next.SetClaims(ctx, uID, claims)
+}
+
+func (s *tracedAuthService) RevokeSessions(ctx context.Context, uID uuid.UUID) (err error) {
+	ctx, span := s.start(ctx, "RevokeSessions", uID)
+	defer func() { endSpan(span, err) }()
+
+	return s.next.RevokeSessions(ctx, uID)
+}
+
+func (s *tracedAuthService) SetDisabled(ctx context.Context, uID uuid.UUID, disabled bool) (err error) {
+	ctx, span := s.start(ctx, "SetDisabled", uID)
+	defer func() { endSpan(span, err) }()
+
+	return s.next.SetDisabled(ctx, uID, disabled)
+}
@@ -0,0 +1,73 @@
+package service_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dvvnFrtn/capstone-backend/internal/metrics"
+	"github.com/dvvnFrtn/capstone-backend/internal/service"
+	"github.com/dvvnFrtn/capstone-backend/pkg/authx"
+	"github.com/dvvnFrtn/capstone-backend/pkg/errs"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// spanRecorder installs the global tracer provider once: the package tracer
+// binds to the first provider set, so a fresh one per run records nothing.
+var spanRecorder = sync.OnceValue(func() *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	return recorder
+})
+
+func TestTracedAuthService_RecordsSpans(t *testing.T) {
+	recorder := spanRecorder()
+	before := len(recorder.Ended())
+
+	identity := authx.NewLocalIdentity(authx.NewLocalMemoryStore(), "test-secret", time.Hour)
+	as := service.NewTracedAuthService(service.NewLocalAuthService(identity), "local")
+
+	uID := uuid.New()
+	require.NoError(t, as.CreateAccount(context.Background(), service.CreateAccountInput{
+		UID:      uID,
+		Phone:    "+6281234567890",
+		Password: "password123",
+	}, nil))
+	require.Error(t, as.DeleteAccount(context.Background(), uuid.New()))
+
+	spans := recorder.Ended()[before:]
+	require.Len(t, spans, 2)
+
+	assert.Equal(t, "AuthService.CreateAccount", spans[0].Name())
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+
+	assert.Equal(t, "AuthService.DeleteAccount", spans[1].Name())
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+}
+
+func TestMeteredAuthService_CountsFailures(t *testing.T) {
+	identity := authx.NewLocalIdentity(authx.NewLocalMemoryStore(), "test-secret", time.Hour)
+	as := service.NewMeteredAuthService(service.NewLocalAuthService(identity), "metered-local")
+	failures := metrics.AuthFailures.WithLabelValues("metered-local", "DeleteAccount", errs.NotFound.String())
+	failuresBefore := testutil.ToFloat64(failures)
+
+	require.Error(t, as.DeleteAccount(context.Background(), uuid.New()))
+	require.NoError(t, as.CreateAccount(context.Background(), service.CreateAccountInput{
+		UID:      uuid.New(),
+		Phone:    "+6281234567891",
+		Password: "password123",
+	}, nil))
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(failures)-failuresBefore)
+	assert.Equal(t, 1, testutil.CollectAndCount(metrics.AuthFailures.MustCurryWith(prometheus.Labels{"provider": "metered-local"})))
+	assert.Equal(t, 2, testutil.CollectAndCount(metrics.AuthDuration.MustCurryWith(prometheus.Labels{"provider": "metered-local"})))
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	database "github.com/dvvnFrtn/capstone-backend/infra/db/sqlc"
+	"github.com/dvvnFrtn/capstone-backend/internal/handler/middleware"
+	"github.com/dvvnFrtn/capstone-backend/internal/policy"
+	"github.com/dvvnFrtn/capstone-backend/pkg/errs"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	DefaultUserPageSize = 20
+	MaxUserPageSize     = 100
+)
+
+// ListUsersRequest filters and pages the residents of a community. Sort is a
+// column name, prefixed with "-" for descending order. Cursor is the
+// next_cursor of the previous page and must be used with the same sort.
+type ListUsersRequest struct {
+	Role        string    `form:"role" json:"role" binding:"omitempty,role"`
+	Address     string    `form:"address" json:"address" binding:"omitempty,max=100"`
+	CreatedFrom time.Time `form:"created_from" json:"created_from" time_format:"2006-01-02"`
+	CreatedTo   time.Time `form:"created_to" json:"created_to" time_format:"2006-01-02" binding:"omitempty,gtefield=CreatedFrom"`
+	Sort        string    `form:"sort" json:"sort" binding:"omitempty,oneof=created_at -created_at fullname -fullname"`
+	Limit       int32     `form:"limit" json:"limit" binding:"omitempty,min=1,max=100"`
+	Cursor      string    `form:"cursor" json:"cursor"`
+}
+
+type UserPage struct {
+	Users []*UserResponse
+	// Total counts every user matching the filters, across all pages.
+	Total      int64
+	NextCursor string
+}
+
+// userCursor is the position after the last user of a page, in the order
+// given by Sort.
+type userCursor struct {
+	Sort      string    `json:"s"`
+	CreatedAt time.Time `json:"c,omitzero"`
+	Fullname  string    `json:"f,omitempty"`
+	ID        uuid.UUID `json:"i"`
+}
+
+func decodeUserCursor(s, sort string) (*userCursor, error) {
+	var c userCursor
+	if err := decodeCursor(s, &c); err != nil {
+		return nil, err
+	}
+	if c.Sort != sort {
+		return nil, errors.New("cursor was issued for another sort order")
+	}
+	return &c, nil
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func (service *UserService) GetUserFromCommunity(ctx context.Context, claims *middleware.UserClaims, req ListUsersRequest) (_ *UserPage, err error) {
+	const op errs.Op = "service.user.GetUserFromCommunity"
+
+	ctx, span := startSpan(ctx, "UserService.GetUserFromCommunity", attribute.String("users.sort", req.Sort))
+	defer func() { endSpan(span, err) }()
+
+	if err := authorize(op, claims, policy.UsersRead, policy.Community(policy.KindUser, claims.CommunityID)); err != nil {
+		return nil, err
+	}
+
+	if req.Sort == "" {
+		req.Sort = "created_at"
+	}
+	if req.Limit == 0 {
+		req.Limit = DefaultUserPageSize
+	}
+	req.Limit = min(req.Limit, MaxUserPageSize)
+
+	var cursor *userCursor
+	if req.Cursor != "" {
+		if cursor, err = decodeUserCursor(req.Cursor, req.Sort); err != nil {
+			return nil, errs.New(op, errs.BadRequest, errs.Msg("Cursor tidak valid"), err)
+		}
+	}
+
+	filter := database.CountCommunityUsersParams{
+		CommunityID: uuid.MustParse(claims.CommunityID),
+		Role:        pgtype.Text{String: req.Role, Valid: req.Role != ""},
+		Address:     pgtype.Text{String: likeEscaper.Replace(req.Address), Valid: req.Address != ""},
+		CreatedFrom: pgtype.Timestamp{Time: req.CreatedFrom, Valid: !req.CreatedFrom.IsZero()},
+		// created_to is inclusive, so the range ends at the start of the next day
+		CreatedTo: pgtype.Timestamp{Time: req.CreatedTo.AddDate(0, 0, 1), Valid: !req.CreatedTo.IsZero()},
+	}
+
+	queries := database.New(service.pool)
+
+	total, err := queries.CountCommunityUsers(ctx, filter)
+	if err != nil {
+		return nil, errs.New(op, errs.Internal, err)
+	}
+
+	// one extra row tells whether there is a next page
+	rows, err := service.listCommunityUsers(ctx, queries, filter, req.Sort, cursor, req.Limit+1)
+	if err != nil {
+		return nil, errs.New(op, errs.Internal, err)
+	}
+
+	page := &UserPage{Users: make([]*UserResponse, 0, len(rows)), Total: total}
+	if len(rows) > int(req.Limit) {
+		rows = rows[:req.Limit]
+		last := rows[len(rows)-1]
+		page.NextCursor = encodeCursor(userCursor{
+			Sort:      req.Sort,
+			CreatedAt: last.CreatedAt.Time,
+			Fullname:  last.Fullname,
+			ID:        last.ID,
+		})
+	}
+	for _, row := range rows {
+		page.Users = append(page.Users, toUserResponse(row))
+	}
+
+	return page, nil
+}
+
+// listCommunityUsers runs the keyset query matching sort, so every order is
+// served by its own index.
+func (service *UserService) listCommunityUsers(ctx context.Context, queries *database.Queries, filter database.CountCommunityUsersParams, sort string, cursor *userCursor, size int32) ([]database.FindUserByIDRow, error) {
+	byCreatedAt := database.ListCommunityUsersByCreatedAtParams{
+		CommunityID: filter.CommunityID,
+		Role:        filter.Role,
+		Address:     filter.Address,
+		CreatedFrom: filter.CreatedFrom,
+		CreatedTo:   filter.CreatedTo,
+		PageSize:    size,
+	}
+	byFullname := database.ListCommunityUsersByFullnameParams{
+		CommunityID: filter.CommunityID,
+		Role:        filter.Role,
+		Address:     filter.Address,
+		CreatedFrom: filter.CreatedFrom,
+		CreatedTo:   filter.CreatedTo,
+		PageSize:    size,
+	}
+	if cursor != nil {
+		byCreatedAt.CursorID = pgtype.UUID{Bytes: cursor.ID, Valid: true}
+		byCreatedAt.CursorCreatedAt = pgtype.Timestamp{Time: cursor.CreatedAt, Valid: true}
+		byFullname.CursorID = pgtype.UUID{Bytes: cursor.ID, Valid: true}
+		byFullname.CursorFullname = pgtype.Text{String: cursor.Fullname, Valid: true}
+	}
+
+	var result []database.FindUserByIDRow
+	switch sort {
+	case "-created_at":
+		rows, err := queries.ListCommunityUsersByCreatedAtDesc(ctx, database.ListCommunityUsersByCreatedAtDescParams(byCreatedAt))
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			result = append(result, database.FindUserByIDRow(row))
+		}
+	case "fullname":
+		rows, err := queries.ListCommunityUsersByFullname(ctx, byFullname)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			result = append(result, database.FindUserByIDRow(row))
+		}
+	case "-fullname":
+		rows, err := queries.ListCommunityUsersByFullnameDesc(ctx, database.ListCommunityUsersByFullnameDescParams(byFullname))
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			result = append(result, database.FindUserByIDRow(row))
+		}
+	default:
+		rows, err := queries.ListCommunityUsersByCreatedAt(ctx, byCreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			result = append(result, database.FindUserByIDRow(row))
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"html"
+	"strings"
+	"unicode"
+
+	database "github.com/dvvnFrtn/capstone-backend/infra/db/sqlc"
+	"github.com/dvvnFrtn/capstone-backend/internal/handler/middleware"
+	"github.com/dvvnFrtn/capstone-backend/internal/policy"
+	"github.com/dvvnFrtn/capstone-backend/pkg/errs"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	DefaultUserSearchSize = 20
+	MaxUserSearchSize     = 50
+)
+
+type SearchUsersRequest struct {
+	Query string `form:"q" json:"q" binding:"required,min=2,max=100"`
+	Limit int32  `form:"limit" json:"limit" binding:"omitempty,min=1,max=50"`
+}
+
+// UserSearchResult is a user with its relevance and the matched parts of its
+// fields wrapped in <mark>. Highlights are HTML escaped.
+type UserSearchResult struct {
+	*UserResponse
+	Rank      float64       `json:"rank"`
+	Highlight UserHighlight `json:"highlight"`
+}
+
+type UserHighlight struct {
+	Fullname string `json:"fullname"`
+	Phone    string `json:"phone,omitempty"`
+	Address  string `json:"address,omitempty"`
+}
+
+// The database wraps matches in these control characters rather than in
+// markup, so the text can be escaped before the markers become <mark> tags.
+const (
+	startSel = "\x02"
+	stopSel  = "\x03"
+)
+
+var highlighter = strings.NewReplacer(startSel, "<mark>", stopSel, "</mark>")
+
+func highlight(s string) string {
+	return highlighter.Replace(html.EscapeString(s))
+}
+
+// prefixQuery turns free text into a tsquery matching every word as a
+// prefix, so "bud san" finds "Budi Santoso". Anything but letters and digits
+// is dropped to keep tsquery operators out of user input.
+func prefixQuery(q string) string {
+	words := strings.FieldsFunc(q, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, word := range words {
+		words[i] = strings.ToLower(word) + ":*"
+	}
+	return strings.Join(words, " & ")
+}
+
+// phoneFragment extracts the digits of q in the +62 form phones are stored
+// in. Fragments shorter than three digits match too many numbers to be
+// useful and give "".
+func phoneFragment(q string) string {
+	digits := strings.Map(func(r rune) rune {
+		if r < '0' || r > '9' {
+			return -1
+		}
+		return r
+	}, q)
+	if strings.HasPrefix(digits, "0") {
+		digits = "62" + digits[1:]
+	}
+	if len(digits) < 3 {
+		return ""
+	}
+	return digits
+}
+
+func (service *UserService) SearchUsers(ctx context.Context, claims *middleware.UserClaims, req SearchUsersRequest) (_ []*UserSearchResult, err error) {
+	const op errs.Op = "service.user.SearchUsers"
+
+	ctx, span := startSpan(ctx, "UserService.SearchUsers")
+	defer func() { endSpan(span, err) }()
+
+	if err := authorize(op, claims, policy.UsersRead, policy.Community(policy.KindUser, claims.CommunityID)); err != nil {
+		return nil, err
+	}
+
+	if req.Limit == 0 {
+		req.Limit = DefaultUserSearchSize
+	}
+	req.Limit = min(req.Limit, MaxUserSearchSize)
+
+	phone := phoneFragment(req.Query)
+	rows, err := database.New(service.pool).SearchCommunityUsers(ctx, database.SearchCommunityUsersParams{
+		Tsquery:     prefixQuery(req.Query),
+		Term:        strings.TrimSpace(req.Query),
+		StartSel:    startSel,
+		StopSel:     stopSel,
+		CommunityID: uuid.MustParse(claims.CommunityID),
+		Phone:       pgtype.Text{String: phone, Valid: phone != ""},
+		PageSize:    req.Limit,
+	})
+	if err != nil {
+		return nil, errs.New(op, errs.Internal, err)
+	}
+
+	results := make([]*UserSearchResult, 0, len(rows))
+	for _, row := range rows {
+		result := &UserSearchResult{
+			UserResponse: toUserResponse(database.FindUserByIDRow{
+				ID:          row.ID,
+				Fullname:    row.Fullname,
+				Email:       row.Email,
+				Phone:       row.Phone,
+				Address:     row.Address,
+				Role:        row.Role,
+				CreatedAt:   row.CreatedAt,
+				Version:     row.Version,
+				CommunityID: row.CommunityID,
+				RtNumber:    row.RtNumber,
+				RwNumber:    row.RwNumber,
+				Subdistrict: row.Subdistrict,
+				District:    row.District,
+				City:        row.City,
+				Province:    row.Province,
+			}),
+			Rank: row.Rank,
+			Highlight: UserHighlight{
+				Fullname: highlight(row.FullnameHighlight),
+				Address:  highlight(row.AddressHighlight),
+			},
+		}
+		if i := strings.Index(row.Phone.String, phone); phone != "" && i >= 0 {
+			p := row.Phone.String
+			result.Highlight.Phone = highlight(p[:i] + startSel + phone + stopSel + p[i+len(phone):])
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
@@ -3,31 +3,42 @@ package service
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/dvvnFrtn/capstone-backend/infra/db"
 	database "github.com/dvvnFrtn/capstone-backend/infra/db/sqlc"
 	"github.com/dvvnFrtn/capstone-backend/internal/handler/middleware"
+	"github.com/dvvnFrtn/capstone-backend/internal/policy"
 	"github.com/dvvnFrtn/capstone-backend/pkg/errs"
+	"github.com/dvvnFrtn/capstone-backend/pkg/saga"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type UserService struct {
-	authService AuthService
-	conn        *pgx.Conn
+	outbox OutboxService
+	pool   *pgxpool.Pool
 }
 
-func NewUserService(conn *pgx.Conn, as AuthService) UserService {
+func NewUserService(pool *pgxpool.Pool, outbox OutboxService) UserService {
 	return UserService{
-		authService: as,
-		conn:        conn,
+		outbox: outbox,
+		pool:   pool,
 	}
 }
 
-func (service *UserService) EnsureEmailOrPhoneUnique(ctx context.Context, email, phone string) error {
+// EnsureEmailOrPhoneUnique also counts soft-deleted users, which hold their
+// email and phone until the retention purge so they can always be restored.
+func (service *UserService) EnsureEmailOrPhoneUnique(ctx context.Context, email, phone string) (err error) {
 	const op errs.Op = "service.user.EnsureEmailOrPhoneUnique"
-	queries := database.New(service.conn)
+
+	ctx, span := startSpan(ctx, "UserService.EnsureEmailOrPhoneUnique")
+	defer func() { endSpan(span, err) }()
+
+	queries := database.New(service.pool)
 
 	if email != "" {
 		emailExists, err := queries.IsEmailExists(ctx, pgtype.Text{String: email, Valid: true})
@@ -52,42 +63,89 @@ func (service *UserService) EnsureEmailOrPhoneUnique(ctx context.Context, email,
 	return nil
 }
 
-func (service *UserService) AdminRegistration(ctx context.Context, req AdminRegistrationRequest) (*AdminRegistrationResponse, error) {
+func (service *UserService) AdminRegistration(ctx context.Context, req AdminRegistrationRequest) (_ *AdminRegistrationResponse, err error) {
 	const op errs.Op = "service.user.AdminRegistration"
 
+	ctx, span := startSpan(ctx, "UserService.AdminRegistration")
+	defer func() { endSpan(span, err) }()
+
 	if err := service.EnsureEmailOrPhoneUnique(ctx, req.Email, req.Phone); err != nil {
 		return nil, errs.New(op, err)
 	}
 
-	admID, comID, err := service.createAdminCommunity(ctx, service.conn, uuid.New(), req)
-	if err != nil {
+	var (
+		admID = uuid.New()
+		comID uuid.UUID
+		event database.OutboxEvent
+	)
+
+	if err := saga.Run(ctx,
+		saga.Step{
+			Name: "db.createAdminCommunity",
+			Action: func(ctx context.Context) (err error) {
+				comID, event, err = service.createAdminCommunity(ctx, service.pool, admID, req)
+				return err
+			},
+			Compensate: func(ctx context.Context) error {
+				return service.deleteAdminCommunity(ctx, admID, comID, event.ID)
+			},
+		},
+		saga.Step{
+			Name: "outbox.DeliverNow",
+			Action: func(ctx context.Context) error {
+				return service.outbox.DeliverNow(ctx, event)
+			},
+		},
+	); err != nil {
 		return nil, errs.New(op, err)
 	}
 
-	metadata := map[string]interface{}{
-		"role":         "admin",
-		"community_id": comID,
+	return &AdminRegistrationResponse{
+		AdminID:     admID,
+		CommunityID: comID,
+		Email:       req.Email,
+	}, nil
+}
+
+func (service *UserService) UserAccess(ctx context.Context, uid string) (_ middleware.UserAccess, err error) {
+	const op errs.Op = "service.user.UserAccess"
+
+	ctx, span := startSpan(ctx, "UserService.UserAccess")
+	defer func() { endSpan(span, err) }()
+
+	id, err := uuid.Parse(uid)
+	if err != nil {
+		return middleware.UserAccess{}, errs.New(op, errs.Unauthorize, err)
 	}
 
-	err = service.authService.CreateAccount(ctx, CreateAccountInput{UID: admID, Email: req.Email, Phone: req.Phone, Password: req.Password}, metadata)
+	row, err := database.New(service.pool).FindUserAccess(ctx, id)
 	if err != nil {
-		return nil, errs.New(op, err)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return middleware.UserAccess{}, errs.New(op, errs.Unauthorize, "Pengguna tidak dapat ditemukan")
+		}
+		return middleware.UserAccess{}, errs.New(op, errs.Internal, err)
 	}
 
-	return &AdminRegistrationResponse{
-		AdminID:     admID,
-		CommunityID: comID,
-		Email:       req.Email,
+	return middleware.UserAccess{
+		Role:        row.Role,
+		CommunityID: row.CommunityID.String(),
+		ValidAfter:  row.TokensValidAfter.Time,
 	}, nil
 }
 
+// IsUserExists reports whether any user, soft-deleted or not, holds the id,
+// email or phone.
 func (s *UserService) IsUserExists(ctx context.Context, req IsUserExistsInput) bool {
-	queries := database.New(s.conn)
+	ctx, span := startSpan(ctx, "UserService.IsUserExists")
+	defer span.End()
+
+	queries := database.New(s.pool)
 
 	if _, err := queries.FindUserByID(ctx, database.FindUserByIDParams{
-		ID:    pgtype.UUID{Bytes: req.ID, Valid: req.ID != uuid.Nil},
-		Email: pgtype.Text{String: req.Email, Valid: req.Email != ""},
-		Phone: pgtype.Text{String: req.Phone, Valid: req.Phone != ""},
+		ID:          pgtype.UUID{Bytes: req.ID, Valid: req.ID != uuid.Nil},
+		Email:       pgtype.Text{String: req.Email, Valid: req.Email != ""},
+		Phone:       pgtype.Text{String: req.Phone, Valid: req.Phone != ""},
+		WithDeleted: pgtype.Bool{Bool: true, Valid: true},
 	}); err != nil {
 		return false
 	}
@@ -95,11 +153,11 @@ func (s *UserService) IsUserExists(ctx context.Context, req IsUserExistsInput) b
 	return true
 }
 
-func (s *UserService) createAdminCommunity(ctx context.Context, conn *pgx.Conn, admID uuid.UUID, req AdminRegistrationRequest) (adm uuid.UUID, com uuid.UUID, err error) {
+func (s *UserService) createAdminCommunity(ctx context.Context, pool *pgxpool.Pool, admID uuid.UUID, req AdminRegistrationRequest) (com uuid.UUID, event database.OutboxEvent, err error) {
 	const op errs.Op = "service.user.createAdminCommunity"
 
 	var comID uuid.UUID
-	err = db.RunTransaction(ctx, conn, func(queries *database.Queries) error {
+	err = db.RunTransaction(ctx, pool, func(queries *database.Queries) error {
 		comID, err = queries.InsertCommunity(ctx, database.InsertCommunityParams{
 			ID:          uuid.New(),
 			RtNumber:    req.RtNumber,
@@ -112,7 +170,7 @@ func (s *UserService) createAdminCommunity(ctx context.Context, conn *pgx.Conn,
 		if err != nil {
 			return errs.New(op, errs.Internal, err)
 		}
-		if _, err = queries.InsertUser(ctx, database.InsertUserParams{
+		admin := database.InsertUserParams{
 			ID:          admID,
 			CommunityID: comID,
 			Fullname:    req.Fullname,
@@ -120,209 +178,620 @@ func (s *UserService) createAdminCommunity(ctx context.Context, conn *pgx.Conn,
 			Phone:       pgtype.Text{String: req.Phone, Valid: true},
 			Address:     pgtype.Text{String: req.Address, Valid: true},
 			Role:        "admin",
-		}); err != nil {
+		}
+		if _, err = queries.InsertUser(ctx, admin); err != nil {
 			return errs.New(op, errs.Internal, err)
 		}
 
+		// the admin registers themselves, so they are also the actor
+		if err := recordAudit(ctx, queries, auditEntry{
+			CommunityID: comID,
+			Actor:       admID,
+			Action:      AuditUserCreate,
+			TargetID:    admID,
+			After:       insertedUserSnapshot(admin),
+		}); err != nil {
+			return errs.New(op, err)
+		}
+
+		event, err = s.outbox.enqueue(ctx, queries, comID, OutboxCreateAccount, accountEventPayload{
+			UID:      admID,
+			Email:    req.Email,
+			Phone:    req.Phone,
+			Password: req.Password,
+			Claims: map[string]interface{}{
+				"role":         "admin",
+				"community_id": comID,
+			},
+		})
+		if err != nil {
+			return errs.New(op, err)
+		}
+
 		return nil
 	})
 	if err != nil {
 		return
 	}
 
-	return admID, comID, nil
+	return comID, event, nil
 }
 
-func (service *UserService) AdminCreateUser(ctx context.Context, claims *middleware.UserClaims, req AdminCreateUserRequest) (*IDResponse, error) {
-	const op errs.Op = "service.user.AdminCreateUser"
-
-	if err := service.EnsureEmailOrPhoneUnique(ctx, "", req.Phone); err != nil {
-		return nil, errs.New(op, err)
-	}
+func (s *UserService) deleteAdminCommunity(ctx context.Context, admID uuid.UUID, comID uuid.UUID, eventID uuid.UUID) error {
+	const op errs.Op = "service.user.deleteAdminCommunity"
 
-	var createdID uuid.UUID
-	if err := db.RunTransaction(ctx, service.conn, func(q *database.Queries) error {
-		uID, err := q.InsertUser(ctx, database.InsertUserParams{
-			ID:          uuid.New(),
-			CommunityID: uuid.MustParse(claims.CommunityID),
-			Fullname:    req.Fullname,
-			Phone:       pgtype.Text{String: req.Phone, Valid: true},
-			Address:     pgtype.Text{String: req.Address, Valid: req.Address != ""},
-			Email:       pgtype.Text{String: req.Email, Valid: req.Email != ""},
-			Role:        req.Role,
-		})
-		if err != nil {
+	return db.RunTransaction(ctx, s.pool, func(q *database.Queries) error {
+		if err := q.DeleteOutboxEvent(ctx, eventID); err != nil {
 			return errs.New(op, errs.Internal, err)
 		}
-
-		metadata := map[string]interface{}{
-			"role":         req.Role,
-			"community_id": uuid.MustParse(claims.CommunityID),
+		if err := q.DeleteUser(ctx, admID); err != nil {
+			return errs.New(op, errs.Internal, err)
 		}
-
-		err = service.authService.CreateAccount(ctx, CreateAccountInput{UID: uID, Phone: req.Phone, Password: req.Password}, metadata)
-		if err != nil {
+		if err := q.DeleteCommunity(ctx, comID); err != nil {
+			return errs.New(op, errs.Internal, err)
+		}
+		if err := recordAudit(ctx, q, auditEntry{
+			CommunityID: comID,
+			Actor:       admID,
+			Action:      AuditUserCreate + AuditRevertSuffix,
+			TargetID:    admID,
+		}); err != nil {
 			return errs.New(op, err)
 		}
+		return nil
+	})
+}
 
-		createdID = uID
+func (service *UserService) AdminCreateUser(ctx context.Context, claims *middleware.UserClaims, req AdminCreateUserRequest) (_ *IDResponse, err error) {
+	const op errs.Op = "service.user.AdminCreateUser"
 
-		return nil
-	}); err != nil {
+	ctx, span := startSpan(ctx, "UserService.AdminCreateUser")
+	defer func() { endSpan(span, err) }()
+
+	if err := authorize(op, claims, policy.UsersCreate, policy.Community(policy.KindUser, claims.CommunityID)); err != nil {
 		return nil, err
 	}
 
+	if err := service.EnsureEmailOrPhoneUnique(ctx, "", req.Phone); err != nil {
+		return nil, errs.New(op, err)
+	}
+
+	var (
+		createdID   = uuid.New()
+		communityID = uuid.MustParse(claims.CommunityID)
+		created     database.InsertUserParams
+		event       database.OutboxEvent
+	)
+
+	if err := saga.Run(ctx,
+		saga.Step{
+			Name: "db.InsertUser",
+			Action: func(ctx context.Context) error {
+				return db.RunTransaction(ctx, service.pool, func(q *database.Queries) error {
+					if err := ensureRoleAssignable(ctx, op, q, claims, req.Role, communityID); err != nil {
+						return err
+					}
+
+					created = database.InsertUserParams{
+						ID:          createdID,
+						CommunityID: communityID,
+						Fullname:    req.Fullname,
+						Phone:       pgtype.Text{String: req.Phone, Valid: true},
+						Address:     pgtype.Text{String: req.Address, Valid: req.Address != ""},
+						Email:       pgtype.Text{String: req.Email, Valid: req.Email != ""},
+						Role:        req.Role,
+					}
+					if _, err := q.InsertUser(ctx, created); err != nil {
+						return errs.New(op, errs.Internal, err)
+					}
+
+					if err := recordAudit(ctx, q, auditEntry{
+						CommunityID: communityID,
+						Actor:       actorOf(claims),
+						Action:      AuditUserCreate,
+						TargetID:    createdID,
+						After:       insertedUserSnapshot(created),
+					}); err != nil {
+						return errs.New(op, err)
+					}
+
+					var err error
+					event, err = service.outbox.enqueue(ctx, q, communityID, OutboxCreateAccount, accountEventPayload{
+						UID:      createdID,
+						Phone:    req.Phone,
+						Password: req.Password,
+						Claims: map[string]interface{}{
+							"role":         req.Role,
+							"community_id": communityID,
+						},
+					})
+					if err != nil {
+						return errs.New(op, err)
+					}
+
+					return nil
+				})
+			},
+			Compensate: func(ctx context.Context) error {
+				return db.RunTransaction(ctx, service.pool, func(q *database.Queries) error {
+					if err := q.DeleteOutboxEvent(ctx, event.ID); err != nil {
+						return errs.New(op, errs.Internal, err)
+					}
+					if err := q.DeleteUser(ctx, createdID); err != nil {
+						return errs.New(op, errs.Internal, err)
+					}
+					if err := recordAudit(ctx, q, auditEntry{
+						CommunityID: communityID,
+						Actor:       actorOf(claims),
+						Action:      AuditUserCreate + AuditRevertSuffix,
+						TargetID:    createdID,
+						Before:      insertedUserSnapshot(created),
+					}); err != nil {
+						return errs.New(op, err)
+					}
+					return nil
+				})
+			},
+		},
+		saga.Step{
+			Name: "outbox.DeliverNow",
+			Action: func(ctx context.Context) error {
+				return service.outbox.DeliverNow(ctx, event)
+			},
+		},
+	); err != nil {
+		return nil, errs.New(op, err)
+	}
+
 	return &IDResponse{ID: createdID}, nil
 }
 
-func (service *UserService) GetUser(ctx context.Context, claims *middleware.UserClaims, uID uuid.UUID) (*UserResponse, error) {
+func (service *UserService) GetUser(ctx context.Context, claims *middleware.UserClaims, uID uuid.UUID) (_ *UserResponse, err error) {
 	const op errs.Op = "service.user.GetUser"
 
-	queries := database.New(service.conn)
+	ctx, span := startSpan(ctx, "UserService.GetUser", attribute.String("user.id", uID.String()))
+	defer func() { endSpan(span, err) }()
 
-	var result database.FindUserByIDRow
-	switch claims.Role {
-	case "admin":
-		row, err := queries.FindUserByID(ctx, database.FindUserByIDParams{
-			ID:          pgtype.UUID{Bytes: uID, Valid: true},
-			CommunityID: pgtype.UUID{Bytes: uuid.MustParse(claims.CommunityID), Valid: true},
-		})
-		if err != nil {
-			return nil, errs.New(op, errs.Internal, err)
-		} else {
-			result = row
-		}
-	case "warga":
-		if claims.UID != uID.String() {
-			return nil, errs.New(op, errs.Forbidden, "Tidak dapat mengambil data pengguna lain")
-		}
-		row, err := queries.FindUserByID(ctx, database.FindUserByIDParams{
-			ID: pgtype.UUID{Bytes: uID, Valid: true},
-		})
-		if err != nil {
-			return nil, errs.New(op, errs.Internal, err)
-		} else {
-			result = row
+	result, err := database.New(service.pool).FindUserByID(ctx, database.FindUserByIDParams{
+		ID:          pgtype.UUID{Bytes: uID, Valid: true},
+		CommunityID: pgtype.UUID{Bytes: uuid.MustParse(claims.CommunityID), Valid: true},
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errs.New(op, errs.NotFound, "Pengguna tidak dapat ditemukan")
 		}
+		return nil, errs.New(op, errs.Internal, err)
+	}
+
+	if err := authorize(op, claims, policy.UsersRead, policy.User(result.ID.String(), result.CommunityID.String())); err != nil {
+		return nil, err
 	}
 
 	return toUserResponse(result), nil
 }
 
-func (service *UserService) AdminUpdateUser(ctx context.Context, claims *middleware.UserClaims, uID uuid.UUID, req AdminUpdateUserRequest) (*IDResponse, error) {
+// AdminUpdateUser applies req to the user and returns the version it left the
+// user at. When ifMatch is not nil, the user must be at one of its versions,
+// or the update fails as PreconditionFailed and nothing changes.
+func (service *UserService) AdminUpdateUser(ctx context.Context, claims *middleware.UserClaims, uID uuid.UUID, req AdminUpdateUserRequest, ifMatch []int64) (_ *UserVersionResponse, err error) {
 	const op errs.Op = "service.user.AdminUpdateUser"
 
+	ctx, span := startSpan(ctx, "UserService.AdminUpdateUser", attribute.String("user.id", uID.String()))
+	defer func() { endSpan(span, err) }()
+
 	if err := service.EnsureEmailOrPhoneUnique(ctx, req.Email, req.Phone); err != nil {
 		return nil, errs.New(op, err)
 	}
 
-	var updatedID uuid.UUID
-	if err := db.RunTransaction(ctx, service.conn, func(q *database.Queries) error {
-		if _, err := q.FindUserByID(ctx, database.FindUserByIDParams{
-			ID:          pgtype.UUID{Bytes: uID, Valid: true},
-			CommunityID: pgtype.UUID{Bytes: uuid.MustParse(claims.CommunityID), Valid: true},
-		}); err != nil {
-			if errors.Is(err, pgx.ErrNoRows) {
-				return errs.New(op, errs.NotFound, "Pengguna tidak dapat ditemukan")
-			}
-			return errs.New(op, errs.Internal, err)
-		}
+	var (
+		previous database.FindUserByIDRow
+		changed  map[string]any
+		version  int64
+		event    database.OutboxEvent
+	)
+
+	if err := saga.Run(ctx,
+		saga.Step{
+			Name: "db.UpdateUser",
+			Action: func(ctx context.Context) error {
+				return db.RunTransaction(ctx, service.pool, func(q *database.Queries) error {
+					row, err := q.FindUserByID(ctx, database.FindUserByIDParams{
+						ID:          pgtype.UUID{Bytes: uID, Valid: true},
+						CommunityID: pgtype.UUID{Bytes: uuid.MustParse(claims.CommunityID), Valid: true},
+					})
+					if err != nil {
+						if errors.Is(err, pgx.ErrNoRows) {
+							return errs.New(op, errs.NotFound, "Pengguna tidak dapat ditemukan")
+						}
+						return errs.New(op, errs.Internal, err)
+					}
+					previous = row
+
+					if err := authorize(op, claims, policy.UsersUpdate, policy.User(row.ID.String(), row.CommunityID.String())); err != nil {
+						return err
+					}
+
+					// The current role must be one the caller could hand out
+					// too, otherwise they could edit or demote their superiors.
+					if err := ensureRoleAssignable(ctx, op, q, claims, row.Role, row.CommunityID); err != nil {
+						return err
+					}
+					if req.Role != "" && req.Role != row.Role {
+						if err := ensureRoleAssignable(ctx, op, q, claims, req.Role, row.CommunityID); err != nil {
+							return err
+						}
+					}
+
+					updatedRow, err := q.UpdateUser(ctx, database.UpdateUserParams{
+						ID:       uID,
+						Fullname: pgtype.Text{String: req.Fullname, Valid: req.Fullname != ""},
+						Email:    pgtype.Text{String: req.Email, Valid: req.Email != ""},
+						Phone:    pgtype.Text{String: req.Phone, Valid: req.Phone != ""},
+						Address:  pgtype.Text{String: req.Address, Valid: req.Address != ""},
+						Role:     pgtype.Text{String: req.Role, Valid: req.Role != ""},
+						Versions: ifMatch,
+					})
+					if err != nil {
+						if errors.Is(err, pgx.ErrNoRows) {
+							// the user changed or was deleted since it was read
+							if ifMatch != nil {
+								return errVersionMismatch(op)
+							}
+							return errs.New(op, errs.NotFound, "Pengguna tidak dapat ditemukan")
+						}
+						return errs.New(op, errs.Internal, err)
+					}
+					version = updatedRow.Version
+
+					updated, err := q.FindUserByID(ctx, database.FindUserByIDParams{ID: pgtype.UUID{Bytes: uID, Valid: true}})
+					if err != nil {
+						return errs.New(op, errs.Internal, err)
+					}
+					changed = userSnapshot(updated)
+					if req.Password != "" {
+						changed["password"] = auditRedacted
+					}
+					if err := recordAudit(ctx, q, auditEntry{
+						CommunityID: row.CommunityID,
+						Actor:       actorOf(claims),
+						Action:      AuditUserUpdate,
+						TargetID:    uID,
+						Before:      userSnapshot(row),
+						After:       changed,
+					}); err != nil {
+						return errs.New(op, err)
+					}
+
+					payload := accountEventPayload{
+						UID:      uID,
+						Email:    req.Email,
+						Phone:    req.Phone,
+						Password: req.Password,
+					}
+					if req.Role != "" && req.Role != row.Role {
+						if err := q.RevokeUserTokens(ctx, uID); err != nil {
+							return errs.New(op, errs.Internal, err)
+						}
+						payload.Claims = map[string]interface{}{
+							"role":         req.Role,
+							"community_id": row.CommunityID,
+						}
+						payload.Revoke = true
+					}
+
+					event, err = service.outbox.enqueue(ctx, q, row.CommunityID, OutboxUpdateAccount, payload)
+					if err != nil {
+						return errs.New(op, err)
+					}
+
+					return nil
+				})
+			},
+			Compensate: func(ctx context.Context) error {
+				return db.RunTransaction(ctx, service.pool, func(q *database.Queries) error {
+					if err := q.DeleteOutboxEvent(ctx, event.ID); err != nil {
+						return errs.New(op, errs.Internal, err)
+					}
+					if _, err := q.UpdateUser(ctx, database.UpdateUserParams{
+						ID:       uID,
+						Fullname: pgtype.Text{String: previous.Fullname, Valid: true},
+						Email:    previous.Email,
+						Phone:    previous.Phone,
+						Address:  previous.Address,
+						Role:     pgtype.Text{String: previous.Role, Valid: true},
+					}); err != nil {
+						return errs.New(op, errs.Internal, err)
+					}
+					if err := recordAudit(ctx, q, auditEntry{
+						CommunityID: previous.CommunityID,
+						Actor:       actorOf(claims),
+						Action:      AuditUserUpdate + AuditRevertSuffix,
+						TargetID:    uID,
+						Before:      changed,
+						After:       userSnapshot(previous),
+					}); err != nil {
+						return errs.New(op, err)
+					}
+					return nil
+				})
+			},
+		},
+		saga.Step{
+			Name: "outbox.DeliverNow",
+			Action: func(ctx context.Context) error {
+				return service.outbox.DeliverNow(ctx, event)
+			},
+		},
+	); err != nil {
+		return nil, errs.New(op, err)
+	}
 
-		uID, err := q.UpdateUser(ctx, database.UpdateUserParams{
-			ID:       uID,
-			Fullname: pgtype.Text{String: req.Fullname, Valid: req.Fullname != ""},
-			Email:    pgtype.Text{String: req.Email, Valid: req.Email != ""},
-			Phone:    pgtype.Text{String: req.Phone, Valid: req.Phone != ""},
-			Address:  pgtype.Text{String: req.Address, Valid: req.Address != ""},
-			Role:     pgtype.Text{String: req.Role, Valid: req.Role != ""},
-		})
-		if err != nil {
-			return errs.New(op, errs.Internal, err)
-		}
+	return &UserVersionResponse{ID: uID, Version: version}, nil
+}
 
-		if err = service.authService.UpdateAccount(ctx, UpdateAccountInput{
-			CreateAccountInput: CreateAccountInput{
-				UID:      uID,
-				Email:    req.Email,
-				Phone:    req.Phone,
-				Password: req.Password,
+func errVersionMismatch(op errs.Op) error {
+	return errs.New(op, errs.PreconditionFailed, errs.Msg("Data pengguna telah diubah oleh permintaan lain, muat ulang lalu coba lagi"))
+}
+
+// AdminDeleteUser soft deletes the user and disables its identity account.
+// Both are kept until PurgeDeletedUsers removes them, so the user can be
+// restored in the meantime.
+//
+// Like AdminUpdateUser, a non-nil ifMatch lists the versions the user may be
+// at.
+func (service *UserService) AdminDeleteUser(ctx context.Context, claims *middleware.UserClaims, uID uuid.UUID, ifMatch []int64) (err error) {
+	const op errs.Op = "service.user.AdminDeleteUser"
+
+	ctx, span := startSpan(ctx, "UserService.AdminDeleteUser", attribute.String("user.id", uID.String()))
+	defer func() { endSpan(span, err) }()
+
+	var event database.OutboxEvent
+
+	if err := saga.Run(ctx,
+		saga.Step{
+			Name: "db.SoftDeleteUser",
+			Action: func(ctx context.Context) error {
+				return db.RunTransaction(ctx, service.pool, func(q *database.Queries) error {
+					row, err := q.FindUserByID(ctx, database.FindUserByIDParams{
+						ID:          pgtype.UUID{Bytes: uID, Valid: true},
+						CommunityID: pgtype.UUID{Bytes: uuid.MustParse(claims.CommunityID), Valid: true},
+					})
+					if err != nil {
+						if errors.Is(err, pgx.ErrNoRows) {
+							return errs.New(op, errs.NotFound, "Pengguna tidak dapat ditemukan")
+						}
+						return errs.New(op, errs.Internal, err)
+					}
+
+					if err := authorize(op, claims, policy.UsersDelete, policy.User(row.ID.String(), row.CommunityID.String())); err != nil {
+						return err
+					}
+					if err := ensureRoleAssignable(ctx, op, q, claims, row.Role, row.CommunityID); err != nil {
+						return err
+					}
+
+					deleted, err := q.SoftDeleteUser(ctx, database.SoftDeleteUserParams{ID: uID, Versions: ifMatch})
+					if err != nil {
+						return errs.New(op, errs.Internal, err)
+					}
+					if deleted == 0 {
+						// the user changed since it was read
+						if ifMatch != nil {
+							return errVersionMismatch(op)
+						}
+						return errs.New(op, errs.NotFound, "Pengguna tidak dapat ditemukan")
+					}
+
+					if err := recordAudit(ctx, q, auditEntry{
+						CommunityID: row.CommunityID,
+						Actor:       actorOf(claims),
+						Action:      AuditUserDelete,
+						TargetID:    uID,
+						Before:      deletedSnapshot(false),
+						After:       deletedSnapshot(true),
+					}); err != nil {
+						return errs.New(op, err)
+					}
+
+					event, err = service.outbox.enqueue(ctx, q, row.CommunityID, OutboxDisableAccount, accountEventPayload{UID: uID})
+					if err != nil {
+						return errs.New(op, err)
+					}
+
+					return nil
+				})
 			},
-		}); err != nil {
-			return errs.New(op, err)
-		}
+			Compensate: func(ctx context.Context) error {
+				return db.RunTransaction(ctx, service.pool, func(q *database.Queries) error {
+					if err := q.DeleteOutboxEvent(ctx, event.ID); err != nil {
+						return errs.New(op, errs.Internal, err)
+					}
+					if _, err := q.RestoreUser(ctx, uID); err != nil {
+						return errs.New(op, errs.Internal, err)
+					}
+					if err := recordAudit(ctx, q, auditEntry{
+						CommunityID: event.CommunityID,
+						Actor:       actorOf(claims),
+						Action:      AuditUserDelete + AuditRevertSuffix,
+						TargetID:    uID,
+						Before:      deletedSnapshot(true),
+						After:       deletedSnapshot(false),
+					}); err != nil {
+						return errs.New(op, err)
+					}
+					return nil
+				})
+			},
+		},
+		saga.Step{
+			Name: "outbox.DeliverNow",
+			Action: func(ctx context.Context) error {
+				return service.outbox.DeliverNow(ctx, event)
+			},
+		},
+	); err != nil {
+		return errs.New(op, err)
+	}
 
-		updatedID = uID
+	return nil
+}
 
-		return nil
-	}); err != nil {
-		return nil, err
+// AdminRestoreUser undoes AdminDeleteUser for a user that is not purged yet.
+func (service *UserService) AdminRestoreUser(ctx context.Context, claims *middleware.UserClaims, uID uuid.UUID) (_ *IDResponse, err error) {
+	const op errs.Op = "service.user.AdminRestoreUser"
+
+	ctx, span := startSpan(ctx, "UserService.AdminRestoreUser", attribute.String("user.id", uID.String()))
+	defer func() { endSpan(span, err) }()
+
+	var event database.OutboxEvent
+
+	if err := saga.Run(ctx,
+		saga.Step{
+			Name: "db.RestoreUser",
+			Action: func(ctx context.Context) error {
+				return db.RunTransaction(ctx, service.pool, func(q *database.Queries) error {
+					row, err := q.FindUserByID(ctx, database.FindUserByIDParams{
+						ID:          pgtype.UUID{Bytes: uID, Valid: true},
+						CommunityID: pgtype.UUID{Bytes: uuid.MustParse(claims.CommunityID), Valid: true},
+						WithDeleted: pgtype.Bool{Bool: true, Valid: true},
+					})
+					if err != nil {
+						if errors.Is(err, pgx.ErrNoRows) {
+							return errs.New(op, errs.NotFound, "Pengguna tidak dapat ditemukan")
+						}
+						return errs.New(op, errs.Internal, err)
+					}
+
+					if err := authorize(op, claims, policy.UsersDelete, policy.User(row.ID.String(), row.CommunityID.String())); err != nil {
+						return err
+					}
+					if err := ensureRoleAssignable(ctx, op, q, claims, row.Role, row.CommunityID); err != nil {
+						return err
+					}
+
+					if !row.DeletedAt.Valid {
+						return errs.New(op, errs.Conflict, "Pengguna tidak dalam keadaan terhapus")
+					}
+
+					if _, err := q.RestoreUser(ctx, uID); err != nil {
+						return errs.New(op, errs.Internal, err)
+					}
+
+					if err := recordAudit(ctx, q, auditEntry{
+						CommunityID: row.CommunityID,
+						Actor:       actorOf(claims),
+						Action:      AuditUserRestore,
+						TargetID:    uID,
+						Before:      deletedSnapshot(true),
+						After:       deletedSnapshot(false),
+					}); err != nil {
+						return errs.New(op, err)
+					}
+
+					event, err = service.outbox.enqueue(ctx, q, row.CommunityID, OutboxEnableAccount, accountEventPayload{UID: uID})
+					if err != nil {
+						return errs.New(op, err)
+					}
+
+					return nil
+				})
+			},
+			Compensate: func(ctx context.Context) error {
+				return db.RunTransaction(ctx, service.pool, func(q *database.Queries) error {
+					if err := q.DeleteOutboxEvent(ctx, event.ID); err != nil {
+						return errs.New(op, errs.Internal, err)
+					}
+					if _, err := q.SoftDeleteUser(ctx, database.SoftDeleteUserParams{ID: uID}); err != nil {
+						return errs.New(op, errs.Internal, err)
+					}
+					if err := recordAudit(ctx, q, auditEntry{
+						CommunityID: event.CommunityID,
+						Actor:       actorOf(claims),
+						Action:      AuditUserRestore + AuditRevertSuffix,
+						TargetID:    uID,
+						Before:      deletedSnapshot(false),
+						After:       deletedSnapshot(true),
+					}); err != nil {
+						return errs.New(op, err)
+					}
+					return nil
+				})
+			},
+		},
+		saga.Step{
+			Name: "outbox.DeliverNow",
+			Action: func(ctx context.Context) error {
+				return service.outbox.DeliverNow(ctx, event)
+			},
+		},
+	); err != nil {
+		return nil, errs.New(op, err)
 	}
 
-	return &IDResponse{ID: updatedID}, nil
+	return &IDResponse{ID: uID}, nil
 }
 
-func (service *UserService) AdminDeleteUser(ctx context.Context, claims *middleware.UserClaims, uID uuid.UUID) error {
-	const op errs.Op = "service.user.AdminDeleteUser"
+// PurgeDeletedUsers removes up to batchSize users soft deleted before
+// deletedBefore, and queues the deletion of their identity accounts in the
+// same transaction. It returns how many users were removed.
+func (service *UserService) PurgeDeletedUsers(ctx context.Context, deletedBefore time.Time, batchSize int32) (_ int, err error) {
+	const op errs.Op = "service.user.PurgeDeletedUsers"
 
-	if err := db.RunTransaction(ctx, service.conn, func(q *database.Queries) error {
-		if _, err := q.FindUserByID(ctx, database.FindUserByIDParams{
-			ID:          pgtype.UUID{Bytes: uID, Valid: true},
-			CommunityID: pgtype.UUID{Bytes: uuid.MustParse(claims.CommunityID), Valid: true},
-		}); err != nil {
-			if errors.Is(err, pgx.ErrNoRows) {
-				return errs.New(op, errs.NotFound, "Pengguna tidak dapat ditemukan")
-			}
-			return errs.New(op, errs.Internal, err)
-		}
+	ctx, span := startSpan(ctx, "UserService.PurgeDeletedUsers")
+	defer func() { endSpan(span, err) }()
 
-		if err := q.DeleteUser(ctx, uID); err != nil {
+	var purged int
+	if err := db.RunTransaction(ctx, service.pool, func(q *database.Queries) error {
+		rows, err := q.PurgeDeletedUsers(ctx, database.PurgeDeletedUsersParams{
+			DeletedBefore: pgtype.Timestamptz{Time: deletedBefore, Valid: true},
+			BatchSize:     batchSize,
+		})
+		if err != nil {
 			return errs.New(op, errs.Internal, err)
 		}
 
-		if err := service.authService.DeleteAccount(ctx, uID); err != nil {
-			return errs.New(op, err)
+		for _, row := range rows {
+			if _, err := service.outbox.enqueue(ctx, q, row.CommunityID, OutboxDeleteAccount, accountEventPayload{UID: row.ID}); err != nil {
+				return errs.New(op, err)
+			}
+			// the retention job acts on its own, so the event has no actor
+			if err := recordAudit(ctx, q, auditEntry{
+				CommunityID: row.CommunityID,
+				Action:      AuditUserPurge,
+				TargetID:    row.ID,
+			}); err != nil {
+				return errs.New(op, err)
+			}
 		}
-
+		purged = len(rows)
 		return nil
 	}); err != nil {
-		return err
+		return 0, errs.New(op, err)
 	}
 
-	return nil
-}
-
-func (service *UserService) GetUserFromCommunity(ctx context.Context, claims *middleware.UserClaims) ([]*UserResponse, error) {
-	const op errs.Op = "service.user.GetUserFromCommunity"
-
-	queries := database.New(service.conn)
-
-	rows, err := queries.FindUserByCommunityID(ctx, uuid.MustParse(claims.CommunityID))
-	if err != nil {
-		return nil, errs.New(op, errs.Internal, err)
-	}
-
-	var responses []*UserResponse
-	for _, row := range rows {
-		rowx := database.FindUserByIDRow(row)
-		responses = append(responses, toUserResponse(rowx))
-	}
-
-	return responses, nil
+	return purged, nil
 }
 
 type AdminUpdateUserRequest struct {
 	Password string `json:"password"`
-	Phone    string `json:"phone"`
-	Email    string `json:"email"`
+	Phone    string `json:"phone" binding:"omitempty,phone_id"`
+	Email    string `json:"email" binding:"omitempty,email"`
 	Address  string `json:"address"`
 	Fullname string `json:"fullname"`
-	Role     string `json:"role"`
+	Role     string `json:"role" binding:"omitempty,role"`
 }
 
 type IDResponse struct {
 	ID uuid.UUID `json:"id"`
 }
 
+// UserVersionResponse is the version a change left the user at, sent as its
+// new ETag.
+type UserVersionResponse struct {
+	ID      uuid.UUID `json:"id"`
+	Version int64     `json:"version"`
+}
+
 type CommunityResponse struct {
 	ID          uuid.UUID `json:"id"`
 	RtNumber    int32     `json:"rt_number"`
@@ -334,23 +803,29 @@ type CommunityResponse struct {
 }
 
 type UserResponse struct {
-	ID        uuid.UUID         `json:"id"`
-	Fullname  string            `json:"fullname"`
-	Email     string            `json:"email"`
-	Phone     string            `json:"phone"`
-	Address   string            `json:"address"`
-	Role      string            `json:"role"`
+	ID        uuid.UUID `json:"id"`
+	Fullname  string    `json:"fullname"`
+	Email     string    `json:"email"`
+	Phone     string    `json:"phone"`
+	Address   string    `json:"address"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+	// Version changes with every update, and is sent as the ETag of the
+	// user.
+	Version   int64             `json:"version"`
 	Community CommunityResponse `json:"community"`
 }
 
 func toUserResponse(row database.FindUserByIDRow) *UserResponse {
 	return &UserResponse{
-		ID:       row.ID,
-		Fullname: row.Fullname,
-		Email:    row.Email.String,
-		Phone:    row.Phone.String,
-		Address:  row.Address.String,
-		Role:     row.Role,
+		ID:        row.ID,
+		Fullname:  row.Fullname,
+		Email:     row.Email.String,
+		Phone:     row.Phone.String,
+		Address:   row.Address.String,
+		Role:      row.Role,
+		CreatedAt: row.CreatedAt.Time,
+		Version:   row.Version,
 		Community: CommunityResponse{
 			ID:          row.CommunityID,
 			RtNumber:    row.RtNumber,
@@ -366,11 +841,11 @@ func toUserResponse(row database.FindUserByIDRow) *UserResponse {
 type AdminRegistrationRequest struct {
 	Email       string `json:"email" binding:"required,min=10"`
 	Password    string `json:"password" binding:"required"`
-	Phone       string `json:"phone" binding:"required"`
+	Phone       string `json:"phone" binding:"required,phone_id"`
 	Address     string `json:"address" binding:"required"`
 	Fullname    string `json:"fullname" binding:"required"`
-	RtNumber    int32  `json:"rt_number" binding:"required"`
-	RwNumber    int32  `json:"rw_number" binding:"required"`
+	RtNumber    int32  `json:"rt_number" binding:"required,rtrw"`
+	RwNumber    int32  `json:"rw_number" binding:"required,rtrw"`
 	Subdistrict string `json:"subdistrict" binding:"required"`
 	District    string `json:"district" binding:"required"`
 	City        string `json:"city" binding:"required"`
@@ -391,9 +866,9 @@ type IsUserExistsInput struct {
 
 type AdminCreateUserRequest struct {
 	Password string `json:"password" binding:"required"`
-	Phone    string `json:"phone" binding:"required"`
-	Email    string `json:"email"`
+	Phone    string `json:"phone" binding:"required,phone_id"`
+	Email    string `json:"email" binding:"omitempty,email"`
 	Address  string `json:"address"`
 	Fullname string `json:"fullname" binding:"required"`
-	Role     string `json:"role" binding:"required"`
+	Role     string `json:"role" binding:"required,role"`
 }
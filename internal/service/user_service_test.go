@@ -2,19 +2,30 @@ package service_test
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
 	"testing"
+	"time"
 
 	"github.com/dvvnFrtn/capstone-backend/config"
 	"github.com/dvvnFrtn/capstone-backend/infra/db"
+	database "github.com/dvvnFrtn/capstone-backend/infra/db/sqlc"
+	"github.com/dvvnFrtn/capstone-backend/internal/handler/middleware"
+	"github.com/dvvnFrtn/capstone-backend/internal/policy"
 	"github.com/dvvnFrtn/capstone-backend/internal/service"
 	"github.com/dvvnFrtn/capstone-backend/internal/types"
 	"github.com/dvvnFrtn/capstone-backend/pkg/authx"
+	"github.com/dvvnFrtn/capstone-backend/pkg/errs"
+	"github.com/dvvnFrtn/capstone-backend/pkg/secretbox"
 	"github.com/dvvnFrtn/capstone-backend/pkg/testutil"
 	"github.com/google/uuid"
-	"github.com/joho/godotenv"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/modules/postgres"
 )
 
@@ -25,14 +36,20 @@ type TestSuiteUserService struct {
 }
 
 func (ts *TestSuiteUserService) SetupSuite() {
-	err := godotenv.Load("../../.env")
-	require.NoError(ts.T(), err)
+	testcontainers.SkipIfProviderIsNotHealthy(ts.T())
 
 	cfg := config.Database()
+	cfg.User, cfg.Pass, cfg.Name = "test", "test", "test"
 	container, err := testutil.SetupTestDatabase(context.Background(), &cfg)
+	ts.container = container
 	require.NoError(ts.T(), err)
 	ts.cfg = &cfg
-	ts.container = container
+}
+
+func (ts *TestSuiteUserService) TearDownSuite() {
+	if ts.container != nil {
+		_ = ts.container.Terminate(context.Background())
+	}
 }
 
 func (ts *TestSuiteUserService) TearDownTest() {
@@ -62,22 +79,933 @@ func (ts *TestSuiteUserService) TestUserService_SignUp_Success() {
 		expectedPhone = "+6287819502098"
 	)
 
-	client, err := authx.InitFirebase(context.Background(), "../../firebase.json")
-	require.NoError(ts.T(), err)
-
-	conn, err := db.NewPostgreConn(ctx, ts.cfg)
+	pool, err := db.NewPostgrePool(ctx, ts.cfg)
 	require.NoError(ts.T(), err)
-	defer conn.Close(ctx)
+	defer pool.Close()
 
-	authService := service.NewFirebaseAuthService(client.Auth)
-	userService := service.NewUserService(conn, authService)
+	identity := authx.NewLocalIdentity(db.NewLocalAccountStore(pool), "test-secret", time.Hour)
+	authService := service.NewLocalAuthService(identity)
+	outboxService := ts.newOutboxService(pool, authService)
+	userService := service.NewUserService(pool, outboxService)
 
 	_, err = userService.AdminRegistration(
 		ctx,
 		dummyAdminRegistrationRequest(expectedEmail, expectedPhone, "password123"),
 	)
 
-	assert.NoError(ts.T(), err)
+	require.NoError(ts.T(), err)
+
+	idToken, err := identity.SignIn(ctx, expectedPhone, "password123")
+	require.NoError(ts.T(), err)
+
+	token, err := identity.VerifyIDToken(ctx, idToken)
+	require.NoError(ts.T(), err)
+	assert.Equal(ts.T(), "admin", token.Claims["role"])
+}
+
+func (ts *TestSuiteUserService) TestUserService_SignUp_Supabase() {
+	ctx := context.WithValue(context.Background(), types.RequestIDKey, uuid.New())
+
+	stub := testutil.NewGoTrueStub("service-role-key")
+	defer stub.Close()
+
+	pool, err := db.NewPostgrePool(ctx, ts.cfg)
+	require.NoError(ts.T(), err)
+	defer pool.Close()
+
+	authService := service.NewSupabaseAuthService(authx.SupabaseConfig{
+		AnonKey:        "anon-key",
+		ServiceRoleKey: stub.ServiceRoleKey,
+		AuthURL:        stub.Server.URL,
+	})
+	outboxService := ts.newOutboxService(pool, authService)
+	userService := service.NewUserService(pool, outboxService)
+
+	res, err := userService.AdminRegistration(ctx, dummyAdminRegistrationRequest("supabase@test.com", "+6287819502098", "password123"))
+	require.NoError(ts.T(), err)
+
+	user, ok := stub.User(res.AdminID)
+	require.True(ts.T(), ok)
+	assert.Equal(ts.T(), "admin", user.AppMetadata["role"])
+	assert.Equal(ts.T(), res.CommunityID.String(), user.AppMetadata["community_id"])
+}
+
+type fakeAuthService struct {
+	createErr  error
+	updateErr  error
+	deleteErr  error
+	disableErr error
+	accounts   map[uuid.UUID]service.CreateAccountInput
+	claims     map[uuid.UUID]map[string]interface{}
+	revoked    map[uuid.UUID]bool
+	disabled   map[uuid.UUID]bool
+}
+
+var (
+	errProviderRejected    = errs.New(errs.Op("fake.auth"), errs.Conflict, "identity provider rejected request")
+	errProviderUnavailable = errs.New(errs.Op("fake.auth"), errs.Internal, "identity provider unavailable")
+)
+
+func newFakeAuthService() *fakeAuthService {
+	return &fakeAuthService{
+		accounts: make(map[uuid.UUID]service.CreateAccountInput),
+		claims:   make(map[uuid.UUID]map[string]interface{}),
+		revoked:  make(map[uuid.UUID]bool),
+		disabled: make(map[uuid.UUID]bool),
+	}
+}
+
+func (f *fakeAuthService) CreateAccount(ctx context.Context, req service.CreateAccountInput, claims map[string]interface{}) error {
+	if f.createErr != nil {
+		return f.createErr
+	}
+	f.accounts[req.UID] = req
+	return nil
+}
+
+func (f *fakeAuthService) UpdateAccount(ctx context.Context, req service.UpdateAccountInput) error {
+	if f.updateErr != nil {
+		return f.updateErr
+	}
+	f.accounts[req.UID] = req.CreateAccountInput
+	return nil
+}
+
+func (f *fakeAuthService) DeleteAccount(ctx context.Context, uID uuid.UUID) error {
+	if f.deleteErr != nil {
+		return f.deleteErr
+	}
+	delete(f.accounts, uID)
+	return nil
+}
+
+func (f *fakeAuthService) SetClaims(ctx context.Context, uID uuid.UUID, claims map[string]interface{}) error {
+	if f.updateErr != nil {
+		return f.updateErr
+	}
+	f.claims[uID] = claims
+	return nil
+}
+
+func (f *fakeAuthService) RevokeSessions(ctx context.Context, uID uuid.UUID) error {
+	f.revoked[uID] = true
+	return nil
+}
+
+func (f *fakeAuthService) SetDisabled(ctx context.Context, uID uuid.UUID, disabled bool) error {
+	if f.disableErr != nil {
+		return f.disableErr
+	}
+	f.disabled[uID] = disabled
+	return nil
+}
+
+func (ts *TestSuiteUserService) newOutboxService(pool *pgxpool.Pool, authService service.AuthService) service.OutboxService {
+	box, err := secretbox.New("test-secret")
+	require.NoError(ts.T(), err)
+	return service.NewOutboxService(slog.Default(), pool, authService, config.Outbox{
+		PollInterval: time.Second,
+		Lease:        30 * time.Second,
+		BatchSize:    20,
+		MaxAttempts:  8,
+		BaseBackoff:  time.Second,
+		MaxBackoff:   time.Minute,
+	}, box)
+}
+
+// userFixture wires the services to a fake identity provider. Fixtures from
+// seedCommunity also hold a registered community and claims of its admin.
+type userFixture struct {
+	ts     *TestSuiteUserService
+	pool   *pgxpool.Pool
+	auth   *fakeAuthService
+	outbox service.OutboxService
+	users  service.UserService
+	reg    *service.AdminRegistrationResponse
+	admin  *middleware.UserClaims
+}
+
+func (ts *TestSuiteUserService) newUserFixture(ctx context.Context) *userFixture {
+	pool, err := db.NewPostgrePool(ctx, ts.cfg)
+	require.NoError(ts.T(), err)
+	ts.T().Cleanup(pool.Close)
+
+	authService := newFakeAuthService()
+	outboxService := ts.newOutboxService(pool, authService)
+	return &userFixture{
+		ts:     ts,
+		pool:   pool,
+		auth:   authService,
+		outbox: outboxService,
+		users:  service.NewUserService(pool, outboxService),
+	}
+}
+
+func (ts *TestSuiteUserService) seedCommunity(ctx context.Context) *userFixture {
+	f := ts.newUserFixture(ctx)
+	f.reg, f.admin = f.register(ctx, "admin@test.com", "+6281111111111")
+	return f
+}
+
+func (f *userFixture) register(ctx context.Context, email, phone string) (*service.AdminRegistrationResponse, *middleware.UserClaims) {
+	reg, err := f.users.AdminRegistration(ctx, dummyAdminRegistrationRequest(email, phone, "password123"))
+	require.NoError(f.ts.T(), err)
+	return reg, f.claimsIn(ctx, reg.AdminID, "admin", reg.CommunityID)
+}
+
+func (f *userFixture) claimsFor(ctx context.Context, uID uuid.UUID, role string) *middleware.UserClaims {
+	return f.claimsIn(ctx, uID, role, f.reg.CommunityID)
+}
+
+func (f *userFixture) claimsIn(ctx context.Context, uID uuid.UUID, role string, communityID uuid.UUID) *middleware.UserClaims {
+	roleService := service.NewRoleService(f.pool)
+	permissions, err := roleService.RolePermissions(ctx, role, communityID.String())
+	require.NoError(f.ts.T(), err)
+
+	return &middleware.UserClaims{
+		UID:         uID.String(),
+		Role:        role,
+		CommunityID: communityID.String(),
+		Permissions: permissions,
+	}
+}
+
+// failOutboxUpdates makes every update of outbox_events fail until the
+// returned func is called.
+func (f *userFixture) failOutboxUpdates(ctx context.Context) func() {
+	_, err := f.pool.Exec(ctx, `
+		create function fail_outbox_update() returns trigger language plpgsql as $$
+		begin
+			raise exception 'outbox unavailable';
+		end $$;
+		create trigger fail_outbox_update before update on outbox_events
+			for each row execute function fail_outbox_update();`)
+	require.NoError(f.ts.T(), err)
+
+	return func() {
+		_, err := f.pool.Exec(ctx, "drop trigger fail_outbox_update on outbox_events; drop function fail_outbox_update()")
+		require.NoError(f.ts.T(), err)
+	}
+}
+
+// createUser adds a member to the seeded community as its admin.
+func (f *userFixture) createUser(ctx context.Context, phone, role string) uuid.UUID {
+	res, err := f.users.AdminCreateUser(ctx, f.admin, service.AdminCreateUserRequest{
+		Password: "password123",
+		Phone:    phone,
+		Fullname: role + " test",
+		Role:     role,
+	})
+	require.NoError(f.ts.T(), err)
+	return res.ID
+}
+
+func (ts *TestSuiteUserService) TestUserService_SignUp_AuthFailureRollsBack() {
+	ctx := context.WithValue(context.Background(), types.RequestIDKey, uuid.New())
+	f := ts.newUserFixture(ctx)
+	f.auth.createErr = errProviderRejected
+
+	_, err := f.users.AdminRegistration(ctx, dummyAdminRegistrationRequest("saga@test.com", "+6281111111111", "password123"))
+	assert.Error(ts.T(), err)
+
+	exists, err := database.New(f.pool).IsEmailExists(ctx, pgtype.Text{String: "saga@test.com", Valid: true})
+	require.NoError(ts.T(), err)
+	assert.False(ts.T(), exists)
+
+	var communities int
+	require.NoError(ts.T(), f.pool.QueryRow(ctx, "select count(*) from communities").Scan(&communities))
+	assert.Zero(ts.T(), communities)
+}
+
+// sagaCase runs one account operation against target, a user prepared
+// before any failure is injected, and returns the user it changed.
+type sagaCase struct {
+	name    string
+	prepare func(ctx context.Context) uuid.UUID
+	act     func(ctx context.Context, target uuid.UUID) (uuid.UUID, error)
+	check   func(ctx context.Context, uID uuid.UUID)
+}
+
+func (ts *TestSuiteUserService) TestUserService_ProviderFailureRollsBack() {
+	ctx := context.WithValue(context.Background(), types.RequestIDKey, uuid.New())
+	f := ts.seedCommunity(ctx)
+
+	cases := []sagaCase{
+		{
+			name: "create",
+			act: func(ctx context.Context, _ uuid.UUID) (uuid.UUID, error) {
+				f.auth.createErr = errProviderRejected
+				res, err := f.users.AdminCreateUser(ctx, f.admin, service.AdminCreateUserRequest{
+					Password: "password123",
+					Phone:    "+6282222222222",
+					Fullname: "warga test",
+					Role:     "warga",
+				})
+				if err != nil {
+					return uuid.Nil, err
+				}
+				return res.ID, nil
+			},
+			check: func(ctx context.Context, _ uuid.UUID) {
+				exists, err := database.New(f.pool).IsPhoneExists(ctx, pgtype.Text{String: "+6282222222222", Valid: true})
+				require.NoError(ts.T(), err)
+				assert.False(ts.T(), exists)
+			},
+		},
+		{
+			name:    "update",
+			prepare: func(ctx context.Context) uuid.UUID { return f.createUser(ctx, "+6283333333333", "warga") },
+			act: func(ctx context.Context, target uuid.UUID) (uuid.UUID, error) {
+				f.auth.updateErr = errProviderRejected
+				_, err := f.users.AdminUpdateUser(ctx, f.admin, target, service.AdminUpdateUserRequest{
+					Fullname: "renamed",
+					Phone:    "+6284444444444",
+				}, nil)
+				return target, err
+			},
+			check: func(ctx context.Context, uID uuid.UUID) {
+				row, err := database.New(f.pool).FindUserByID(ctx, database.FindUserByIDParams{ID: pgtype.UUID{Bytes: uID, Valid: true}})
+				require.NoError(ts.T(), err)
+				assert.Equal(ts.T(), "warga test", row.Fullname)
+				assert.Equal(ts.T(), "+6283333333333", row.Phone.String)
+			},
+		},
+		{
+			name:    "delete",
+			prepare: func(ctx context.Context) uuid.UUID { return f.createUser(ctx, "+6285555555555", "warga") },
+			act: func(ctx context.Context, target uuid.UUID) (uuid.UUID, error) {
+				f.auth.disableErr = errProviderRejected
+				return target, f.users.AdminDeleteUser(ctx, f.admin, target, nil)
+			},
+			check: func(ctx context.Context, uID uuid.UUID) {
+				_, err := f.users.GetUser(ctx, f.admin, uID)
+				assert.NoError(ts.T(), err)
+				assert.False(ts.T(), f.auth.disabled[uID])
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		ts.Run(tc.name, func() {
+			var target uuid.UUID
+			if tc.prepare != nil {
+				target = tc.prepare(ctx)
+			}
+
+			uID, err := tc.act(ctx, target)
+			f.auth.createErr, f.auth.updateErr, f.auth.disableErr = nil, nil, nil
+			assert.Error(ts.T(), err)
+			tc.check(ctx, uID)
+		})
+	}
+}
+
+func (ts *TestSuiteUserService) TestUserService_DatabaseFailureAfterProviderKeepsChange() {
+	ctx := context.WithValue(context.Background(), types.RequestIDKey, uuid.New())
+	f := ts.seedCommunity(ctx)
+
+	cases := []sagaCase{
+		{
+			name: "registration",
+			act: func(ctx context.Context, _ uuid.UUID) (uuid.UUID, error) {
+				reg, err := f.users.AdminRegistration(ctx, dummyAdminRegistrationRequest("late@test.com", "+6289999999999", "password123"))
+				if err != nil {
+					return uuid.Nil, err
+				}
+				return reg.AdminID, nil
+			},
+			check: func(ctx context.Context, uID uuid.UUID) {
+				assert.Contains(ts.T(), f.auth.accounts, uID)
+				_, err := database.New(f.pool).FindUserByID(ctx, database.FindUserByIDParams{ID: pgtype.UUID{Bytes: uID, Valid: true}})
+				assert.NoError(ts.T(), err)
+			},
+		},
+		{
+			name: "create",
+			act: func(ctx context.Context, _ uuid.UUID) (uuid.UUID, error) {
+				res, err := f.users.AdminCreateUser(ctx, f.admin, service.AdminCreateUserRequest{
+					Password: "password123",
+					Phone:    "+6282222222222",
+					Fullname: "warga test",
+					Role:     "warga",
+				})
+				if err != nil {
+					return uuid.Nil, err
+				}
+				return res.ID, nil
+			},
+			check: func(ctx context.Context, uID uuid.UUID) {
+				assert.Contains(ts.T(), f.auth.accounts, uID)
+				_, err := f.users.GetUser(ctx, f.admin, uID)
+				assert.NoError(ts.T(), err)
+			},
+		},
+		{
+			name:    "update",
+			prepare: func(ctx context.Context) uuid.UUID { return f.createUser(ctx, "+6283333333333", "warga") },
+			act: func(ctx context.Context, target uuid.UUID) (uuid.UUID, error) {
+				_, err := f.users.AdminUpdateUser(ctx, f.admin, target, service.AdminUpdateUserRequest{Phone: "+6284444444444"}, nil)
+				return target, err
+			},
+			check: func(ctx context.Context, uID uuid.UUID) {
+				assert.Equal(ts.T(), "+6284444444444", f.auth.accounts[uID].Phone)
+				user, err := f.users.GetUser(ctx, f.admin, uID)
+				require.NoError(ts.T(), err)
+				assert.Equal(ts.T(), "+6284444444444", user.Phone)
+			},
+		},
+		{
+			name:    "delete",
+			prepare: func(ctx context.Context) uuid.UUID { return f.createUser(ctx, "+6285555555555", "warga") },
+			act: func(ctx context.Context, target uuid.UUID) (uuid.UUID, error) {
+				return target, f.users.AdminDeleteUser(ctx, f.admin, target, nil)
+			},
+			check: func(ctx context.Context, uID uuid.UUID) {
+				assert.True(ts.T(), f.auth.disabled[uID])
+				_, err := f.users.GetUser(ctx, f.admin, uID)
+				assert.True(ts.T(), errs.CodeIs(err, errs.NotFound), "got %v", err)
+			},
+		},
+	}
+
+	undelivered := func(uID uuid.UUID) int {
+		var n int
+		require.NoError(ts.T(), f.pool.QueryRow(ctx, "select count(*) from outbox_events where aggregate_id = $1 and status <> 'delivered'", uID).Scan(&n))
+		return n
+	}
+
+	for _, tc := range cases {
+		ts.Run(tc.name, func() {
+			var target uuid.UUID
+			if tc.prepare != nil {
+				target = tc.prepare(ctx)
+			}
+
+			// the provider call succeeds, recording its delivery does not
+			restore := f.failOutboxUpdates(ctx)
+			uID, err := tc.act(ctx, target)
+			restore()
+			require.NoError(ts.T(), err)
+			tc.check(ctx, uID)
+			assert.Equal(ts.T(), 1, undelivered(uID))
+
+			_, err = f.pool.Exec(ctx, "update outbox_events set next_attempt_at = current_timestamp")
+			require.NoError(ts.T(), err)
+			require.NoError(ts.T(), f.outbox.DispatchPending(ctx))
+			assert.Zero(ts.T(), undelivered(uID))
+			tc.check(ctx, uID)
+		})
+	}
+}
+
+func (ts *TestSuiteUserService) TestUserService_IfMatchRejectsStaleVersions() {
+	ctx := context.Background()
+	f := ts.seedCommunity(ctx)
+
+	wargaID := f.createUser(ctx, "+6282222222222", "warga")
+
+	user, err := f.users.GetUser(ctx, f.admin, wargaID)
+	require.NoError(ts.T(), err)
+	read := user.Version
+
+	updated, err := f.users.AdminUpdateUser(ctx, f.admin, wargaID, service.AdminUpdateUserRequest{Fullname: "warga satu"}, []int64{read})
+	require.NoError(ts.T(), err)
+
+	user, err = f.users.GetUser(ctx, f.admin, wargaID)
+	require.NoError(ts.T(), err)
+	assert.Equal(ts.T(), read+1, user.Version, "updates bump the version")
+	assert.Equal(ts.T(), user.Version, updated.Version)
+
+	// a role change also revokes the tokens, which is no edit of its own
+	updated, err = f.users.AdminUpdateUser(ctx, f.admin, wargaID, service.AdminUpdateUserRequest{Role: "pengurus"}, []int64{user.Version})
+	require.NoError(ts.T(), err)
+	assert.Equal(ts.T(), user.Version+1, updated.Version)
+	user, err = f.users.GetUser(ctx, f.admin, wargaID)
+	require.NoError(ts.T(), err)
+	assert.Equal(ts.T(), updated.Version, user.Version)
+
+	// a second client still holding the first version
+	_, err = f.users.AdminUpdateUser(ctx, f.admin, wargaID, service.AdminUpdateUserRequest{Fullname: "warga dua"}, []int64{read})
+	assert.True(ts.T(), errs.CodeIs(err, errs.PreconditionFailed), "got %v", err)
+	err = f.users.AdminDeleteUser(ctx, f.admin, wargaID, []int64{read})
+	assert.True(ts.T(), errs.CodeIs(err, errs.PreconditionFailed), "got %v", err)
+
+	unchanged, err := f.users.GetUser(ctx, f.admin, wargaID)
+	require.NoError(ts.T(), err)
+	assert.Equal(ts.T(), "warga satu", unchanged.Fullname)
+	assert.Equal(ts.T(), user.Version, unchanged.Version)
+
+	require.NoError(ts.T(), f.users.AdminDeleteUser(ctx, f.admin, wargaID, []int64{read, user.Version}))
+
+	_, err = f.users.AdminUpdateUser(ctx, f.admin, wargaID, service.AdminUpdateUserRequest{Fullname: "warga tiga"}, nil)
+	assert.True(ts.T(), errs.CodeIs(err, errs.NotFound), "got %v", err)
+}
+
+func (ts *TestSuiteUserService) TestUserService_AdminDeleteUser_SoftDeletesUntilPurge() {
+	ctx := context.Background()
+	f := ts.seedCommunity(ctx)
+
+	wargaID := f.createUser(ctx, "+6282222222222", "warga")
+
+	require.NoError(ts.T(), f.users.AdminDeleteUser(ctx, f.admin, wargaID, nil))
+	assert.True(ts.T(), f.auth.disabled[wargaID])
+	assert.Contains(ts.T(), f.auth.accounts, wargaID)
+
+	_, err := f.users.GetUser(ctx, f.admin, wargaID)
+	assert.True(ts.T(), errs.CodeIs(err, errs.NotFound), "got %v", err)
+	page, err := f.users.GetUserFromCommunity(ctx, f.admin, service.ListUsersRequest{})
+	require.NoError(ts.T(), err)
+	assert.Equal(ts.T(), int64(1), page.Total)
+
+	_, err = f.users.AdminRestoreUser(ctx, f.admin, wargaID)
+	require.NoError(ts.T(), err)
+	assert.False(ts.T(), f.auth.disabled[wargaID])
+	_, err = f.users.GetUser(ctx, f.admin, wargaID)
+	require.NoError(ts.T(), err)
+
+	_, err = f.users.AdminRestoreUser(ctx, f.admin, wargaID)
+	assert.True(ts.T(), errs.CodeIs(err, errs.Conflict), "got %v", err)
+
+	require.NoError(ts.T(), f.users.AdminDeleteUser(ctx, f.admin, wargaID, nil))
+
+	purged, err := f.users.PurgeDeletedUsers(ctx, time.Now().Add(-time.Hour), 10)
+	require.NoError(ts.T(), err)
+	assert.Zero(ts.T(), purged, "users within the retention period are kept")
+
+	purged, err = f.users.PurgeDeletedUsers(ctx, time.Now().Add(time.Minute), 10)
+	require.NoError(ts.T(), err)
+	assert.Equal(ts.T(), 1, purged)
+
+	_, err = f.users.AdminRestoreUser(ctx, f.admin, wargaID)
+	assert.True(ts.T(), errs.CodeIs(err, errs.NotFound), "got %v", err)
+}
+
+func (ts *TestSuiteUserService) TestUserService_DeletedUsersKeepIdentifiersUntilPurge() {
+	ctx := context.Background()
+	f := ts.seedCommunity(ctx)
+	roleService := service.NewRoleService(f.pool)
+
+	role, err := roleService.CreateRole(ctx, f.admin, service.CreateRoleRequest{
+		Name:        "bendahara",
+		Permissions: []string{"users:read:self"},
+	})
+	require.NoError(ts.T(), err)
+
+	const phone = "+6282222222222"
+	bendaharaID := f.createUser(ctx, phone, "bendahara")
+	require.NoError(ts.T(), f.users.AdminDeleteUser(ctx, f.admin, bendaharaID, nil))
+
+	_, err = f.users.AdminCreateUser(ctx, f.admin, service.AdminCreateUserRequest{
+		Password: "password123",
+		Phone:    phone,
+		Fullname: "warga test",
+		Role:     "warga",
+	})
+	assert.True(ts.T(), errs.CodeIs(err, errs.Conflict), "got %v", err)
+	assert.True(ts.T(), f.users.IsUserExists(ctx, service.IsUserExistsInput{Phone: phone}))
+
+	err = roleService.DeleteRole(ctx, f.admin, role.ID)
+	assert.True(ts.T(), errs.CodeIs(err, errs.Conflict), "got %v", err)
+
+	purged, err := f.users.PurgeDeletedUsers(ctx, time.Now().Add(time.Minute), 10)
+	require.NoError(ts.T(), err)
+	require.Equal(ts.T(), 1, purged)
+
+	assert.False(ts.T(), f.users.IsUserExists(ctx, service.IsUserExistsInput{Phone: phone}))
+	require.NoError(ts.T(), roleService.DeleteRole(ctx, f.admin, role.ID))
+	f.createUser(ctx, phone, "warga")
+}
+
+func (ts *TestSuiteUserService) TestUserService_AdminCreateUser_ProviderDownDefersToOutbox() {
+	ctx := context.WithValue(context.Background(), types.RequestIDKey, uuid.New())
+	f := ts.seedCommunity(ctx)
+
+	f.auth.createErr = errProviderUnavailable
+	wargaID := f.createUser(ctx, "+6282222222222", "warga")
+
+	exists, err := database.New(f.pool).IsPhoneExists(ctx, pgtype.Text{String: "+6282222222222", Valid: true})
+	require.NoError(ts.T(), err)
+	assert.True(ts.T(), exists)
+	assert.NotContains(ts.T(), f.auth.accounts, wargaID)
+
+	stuck, err := database.New(f.pool).FindStuckOutboxEvents(ctx, f.reg.CommunityID)
+	require.NoError(ts.T(), err)
+	require.Len(ts.T(), stuck, 1)
+	assert.Equal(ts.T(), wargaID, stuck[0].AggregateID)
+	assert.Equal(ts.T(), int32(1), stuck[0].Attempts)
+}
+
+func (ts *TestSuiteUserService) TestOutboxService_KeepsPasswordsOutOfPayloads() {
+	ctx := context.WithValue(context.Background(), types.RequestIDKey, uuid.New())
+	f := ts.seedCommunity(ctx)
+
+	f.auth.createErr = errProviderUnavailable
+	var created []uuid.UUID
+	for _, phone := range []string{"+6282222222222", "+6283333333333"} {
+		res, err := f.users.AdminCreateUser(ctx, f.admin, service.AdminCreateUserRequest{
+			Password: "secret-password",
+			Phone:    phone,
+			Fullname: "warga test",
+			Role:     "warga",
+		})
+		require.NoError(ts.T(), err)
+		created = append(created, res.ID)
+	}
+
+	payloads := func() map[uuid.UUID]string {
+		rows, err := f.pool.Query(ctx, "select aggregate_id, payload::text from outbox_events where event_type = $1", service.OutboxCreateAccount)
+		require.NoError(ts.T(), err)
+		defer rows.Close()
+
+		found := make(map[uuid.UUID]string)
+		for rows.Next() {
+			var (
+				id      uuid.UUID
+				payload string
+			)
+			require.NoError(ts.T(), rows.Scan(&id, &payload))
+			found[id] = payload
+		}
+		require.NoError(ts.T(), rows.Err())
+		return found
+	}
+	retry := func() {
+		_, err := f.pool.Exec(ctx, "update outbox_events set next_attempt_at = current_timestamp")
+		require.NoError(ts.T(), err)
+		require.NoError(ts.T(), f.outbox.DispatchPending(ctx))
+	}
+
+	for _, id := range created {
+		assert.NotContains(ts.T(), payloads()[id], "secret-password")
+		assert.Contains(ts.T(), payloads()[id], "sealed_password")
+	}
+
+	// the first account was created before the earlier attempt failed
+	f.auth.createErr = errs.New(errs.Op("fake.auth"), errs.Conflict, fmt.Errorf("%w: uid taken", service.ErrUIDExists))
+	_, err := f.pool.Exec(ctx, "update outbox_events set next_attempt_at = current_timestamp + interval '1 hour' where aggregate_id = $1", created[1])
+	require.NoError(ts.T(), err)
+	require.NoError(ts.T(), f.outbox.DispatchPending(ctx))
+
+	var status string
+	require.NoError(ts.T(), f.pool.QueryRow(ctx, "select status from outbox_events where aggregate_id = $1", created[0]).Scan(&status))
+	assert.Equal(ts.T(), "delivered", status)
+	assert.Equal(ts.T(), "warga", f.auth.claims[created[0]]["role"])
+	assert.NotContains(ts.T(), payloads()[created[0]], "sealed_password")
+
+	f.auth.createErr = errProviderRejected
+	retry()
+
+	require.NoError(ts.T(), f.pool.QueryRow(ctx, "select status from outbox_events where aggregate_id = $1", created[1]).Scan(&status))
+	assert.Equal(ts.T(), "dead", status)
+	assert.NotContains(ts.T(), payloads()[created[1]], "sealed_password")
+}
+
+func (ts *TestSuiteUserService) TestUserService_AdminUpdateUser_RoleChangeRevokesTokens() {
+	ctx := context.WithValue(context.Background(), types.RequestIDKey, uuid.New())
+	f := ts.seedCommunity(ctx)
+	wargaID := f.createUser(ctx, "+6282222222222", "warga")
+
+	access, err := f.users.UserAccess(ctx, wargaID.String())
+	require.NoError(ts.T(), err)
+	assert.True(ts.T(), access.ValidAfter.IsZero())
+	assert.Equal(ts.T(), "warga", access.Role)
+
+	_, err = f.users.AdminUpdateUser(ctx, f.admin, wargaID, service.AdminUpdateUserRequest{Role: "pengurus"}, nil)
+	require.NoError(ts.T(), err)
+
+	access, err = f.users.UserAccess(ctx, wargaID.String())
+	require.NoError(ts.T(), err)
+	assert.False(ts.T(), access.ValidAfter.IsZero())
+	assert.Equal(ts.T(), "pengurus", access.Role)
+	assert.Equal(ts.T(), f.reg.CommunityID.String(), access.CommunityID)
+	assert.Equal(ts.T(), "pengurus", f.auth.claims[wargaID]["role"])
+	assert.Equal(ts.T(), f.reg.CommunityID.String(), f.auth.claims[wargaID]["community_id"])
+	assert.True(ts.T(), f.auth.revoked[wargaID])
+}
+
+func (ts *TestSuiteUserService) TestUserService_CustomRolePermissions() {
+	ctx := context.WithValue(context.Background(), types.RequestIDKey, uuid.New())
+	f := ts.seedCommunity(ctx)
+	roleService := service.NewRoleService(f.pool)
+
+	_, err := roleService.CreateRole(ctx, f.admin, service.CreateRoleRequest{Name: "warga"})
+	assert.True(ts.T(), errs.CodeIs(err, errs.Conflict), "got %v", err)
+
+	_, err = roleService.CreateRole(ctx, f.admin, service.CreateRoleRequest{Name: "sekretaris", Permissions: []string{"users:fly"}})
+	assert.True(ts.T(), errs.CodeIs(err, errs.BadRequest), "got %v", err)
+
+	role, err := roleService.CreateRole(ctx, f.admin, service.CreateRoleRequest{
+		Name:        "Bendahara",
+		Permissions: []string{"users:read", "users:read:self"},
+	})
+	require.NoError(ts.T(), err)
+	assert.Equal(ts.T(), "bendahara", role.Name)
+
+	bendaharaID := f.createUser(ctx, "+6282222222222", "bendahara")
+
+	bendahara := f.claimsFor(ctx, bendaharaID, "bendahara")
+	assert.ElementsMatch(ts.T(), []string{"users:read", "users:read:self"}, bendahara.Permissions)
+
+	user, err := f.users.GetUser(ctx, bendahara, f.reg.AdminID)
+	require.NoError(ts.T(), err)
+	assert.Equal(ts.T(), f.reg.AdminID, user.ID)
+
+	_, err = f.users.AdminCreateUser(ctx, bendahara, service.AdminCreateUserRequest{
+		Password: "password123",
+		Phone:    "+6283333333333",
+		Fullname: "warga test",
+		Role:     "warga",
+	})
+	assert.True(ts.T(), errs.CodeIs(err, errs.Forbidden), "got %v", err)
+
+	_, err = f.users.AdminCreateUser(ctx, f.admin, service.AdminCreateUserRequest{
+		Password: "password123",
+		Phone:    "+6283333333333",
+		Fullname: "warga test",
+		Role:     "sekretaris",
+	})
+	assert.True(ts.T(), errs.CodeIs(err, errs.BadRequest), "got %v", err)
+
+	err = roleService.DeleteRole(ctx, f.admin, role.ID)
+	assert.True(ts.T(), errs.CodeIs(err, errs.Conflict), "got %v", err)
+}
+
+func (ts *TestSuiteUserService) TestUserService_RefusesPrivilegeEscalation() {
+	ctx := context.WithValue(context.Background(), types.RequestIDKey, uuid.New())
+	f := ts.seedCommunity(ctx)
+	roleService := service.NewRoleService(f.pool)
+
+	_, err := roleService.CreateRole(ctx, f.admin, service.CreateRoleRequest{
+		Name:        "sekretaris",
+		Permissions: []string{"users:read", "users:read:self", "users:update", "roles:read", "roles:manage"},
+	})
+	require.NoError(ts.T(), err)
+
+	sekretarisID := f.createUser(ctx, "+6282222222222", "sekretaris")
+	sekretaris := f.claimsFor(ctx, sekretarisID, "sekretaris")
+
+	wargaID := f.createUser(ctx, "+6283333333333", "warga")
+
+	for _, uID := range []uuid.UUID{sekretarisID, wargaID} {
+		_, err = f.users.AdminUpdateUser(ctx, sekretaris, uID, service.AdminUpdateUserRequest{Role: "admin"}, nil)
+		assert.True(ts.T(), errs.CodeIs(err, errs.Forbidden), "got %v", err)
+	}
+
+	_, err = f.users.AdminUpdateUser(ctx, sekretaris, f.reg.AdminID, service.AdminUpdateUserRequest{Role: "warga"}, nil)
+	assert.True(ts.T(), errs.CodeIs(err, errs.Forbidden), "got %v", err)
+
+	_, err = roleService.CreateRole(ctx, sekretaris, service.CreateRoleRequest{
+		Name:        "operator",
+		Permissions: []string{"users:read", "outbox:replay"},
+	})
+	assert.True(ts.T(), errs.CodeIs(err, errs.Forbidden), "got %v", err)
+
+	helper, err := roleService.CreateRole(ctx, sekretaris, service.CreateRoleRequest{
+		Name:        "pembantu",
+		Permissions: []string{"users:read", "users:update"},
+	})
+	require.NoError(ts.T(), err)
+
+	err = roleService.UpdateRole(ctx, sekretaris, helper.ID, service.UpdateRoleRequest{
+		Permissions: []string{"users:read", "users:delete"},
+	})
+	assert.True(ts.T(), errs.CodeIs(err, errs.Forbidden), "got %v", err)
+
+	_, err = f.users.AdminUpdateUser(ctx, sekretaris, wargaID, service.AdminUpdateUserRequest{Role: "pembantu"}, nil)
+	require.NoError(ts.T(), err)
+
+	_, err = roleService.CreateRole(ctx, f.admin, service.CreateRoleRequest{
+		Name:        "operator",
+		Permissions: []string{"users:read", "outbox:replay"},
+	})
+	require.NoError(ts.T(), err)
+
+	_, err = f.users.AdminUpdateUser(ctx, sekretaris, wargaID, service.AdminUpdateUserRequest{Role: "operator"}, nil)
+	assert.True(ts.T(), errs.CodeIs(err, errs.Forbidden), "got %v", err)
+}
+
+func (ts *TestSuiteUserService) TestUserService_ProtectsSuperiors() {
+	ctx := context.WithValue(context.Background(), types.RequestIDKey, uuid.New())
+	f := ts.seedCommunity(ctx)
+	roleService := service.NewRoleService(f.pool)
+
+	_, err := roleService.CreateRole(ctx, f.admin, service.CreateRoleRequest{
+		Name:        "pengelola",
+		Permissions: []string{"users:read", "users:read:self", "users:update", "users:delete"},
+	})
+	require.NoError(ts.T(), err)
+
+	pengelola := f.claimsFor(ctx, f.createUser(ctx, "+6282222222222", "pengelola"), "pengelola")
+	secondAdminID := f.createUser(ctx, "+6283333333333", "admin")
+	wargaID := f.createUser(ctx, "+6284444444444", "warga")
+
+	_, err = f.users.AdminUpdateUser(ctx, pengelola, f.reg.AdminID, service.AdminUpdateUserRequest{Password: "taken-over"}, nil)
+	assert.True(ts.T(), errs.CodeIs(err, errs.Forbidden), "got %v", err)
+
+	err = f.users.AdminDeleteUser(ctx, pengelola, f.reg.AdminID, nil)
+	assert.True(ts.T(), errs.CodeIs(err, errs.Forbidden), "got %v", err)
+
+	require.NoError(ts.T(), f.users.AdminDeleteUser(ctx, f.admin, secondAdminID, nil))
+	_, err = f.users.AdminRestoreUser(ctx, pengelola, secondAdminID)
+	assert.True(ts.T(), errs.CodeIs(err, errs.Forbidden), "got %v", err)
+
+	_, err = f.users.AdminUpdateUser(ctx, pengelola, wargaID, service.AdminUpdateUserRequest{Password: "new-password"}, nil)
+	require.NoError(ts.T(), err)
+	require.NoError(ts.T(), f.users.AdminDeleteUser(ctx, pengelola, wargaID, nil))
+	_, err = f.users.AdminRestoreUser(ctx, pengelola, wargaID)
+	require.NoError(ts.T(), err)
+}
+
+func (ts *TestSuiteUserService) TestUserService_GetUser_Pengurus() {
+	ctx := context.WithValue(context.Background(), types.RequestIDKey, uuid.New())
+	f := ts.seedCommunity(ctx)
+
+	pengurusID := f.createUser(ctx, "+6282222222222", "pengurus")
+
+	pengurus := f.claimsFor(ctx, pengurusID, "pengurus")
+	user, err := f.users.GetUser(ctx, pengurus, f.reg.AdminID)
+	require.NoError(ts.T(), err)
+	assert.Equal(ts.T(), "user test", user.Fullname)
+
+	warga := f.claimsFor(ctx, pengurusID, "warga")
+	_, err = f.users.GetUser(ctx, warga, f.reg.AdminID)
+	assert.True(ts.T(), errs.CodeIs(err, errs.Forbidden), "got %v", err)
+}
+
+func (ts *TestSuiteUserService) TestRoleService_SystemRolesMatchPolicy() {
+	ctx := context.Background()
+	pool, err := db.NewPostgrePool(ctx, ts.cfg)
+	require.NoError(ts.T(), err)
+	defer pool.Close()
+
+	roleService := service.NewRoleService(pool)
+	for role, actions := range policy.SystemRoles {
+		var want []string
+		for _, action := range actions {
+			want = append(want, string(action))
+		}
+
+		got, err := roleService.RolePermissions(ctx, role, uuid.NewString())
+		require.NoError(ts.T(), err)
+		assert.ElementsMatch(ts.T(), want, got, role)
+	}
+}
+
+func (ts *TestSuiteUserService) TestUserService_GetUserFromCommunity_Paginates() {
+	ctx := context.Background()
+	f := ts.seedCommunity(ctx)
+
+	for i, name := range []string{"warga d", "warga a", "warga c", "warga b"} {
+		_, err := f.users.AdminCreateUser(ctx, f.admin, service.AdminCreateUserRequest{
+			Password: "password123",
+			Phone:    fmt.Sprintf("+62822222222%02d", i),
+			Fullname: name,
+			Address:  "Jl. Mawar",
+			Role:     "warga",
+		})
+		require.NoError(ts.T(), err)
+	}
+
+	req := service.ListUsersRequest{Role: "warga", Sort: "fullname", Limit: 3}
+	page, err := f.users.GetUserFromCommunity(ctx, f.admin, req)
+	require.NoError(ts.T(), err)
+	assert.Equal(ts.T(), int64(4), page.Total)
+	require.NotEmpty(ts.T(), page.NextCursor)
+
+	req.Cursor = page.NextCursor
+	next, err := f.users.GetUserFromCommunity(ctx, f.admin, req)
+	require.NoError(ts.T(), err)
+	assert.Empty(ts.T(), next.NextCursor)
+
+	var names []string
+	for _, user := range append(page.Users, next.Users...) {
+		names = append(names, user.Fullname)
+	}
+	assert.Equal(ts.T(), []string{"warga a", "warga b", "warga c", "warga d"}, names)
+
+	all, err := f.users.GetUserFromCommunity(ctx, f.admin, service.ListUsersRequest{Address: "mawar"})
+	require.NoError(ts.T(), err)
+	assert.Equal(ts.T(), int64(4), all.Total)
+
+	req.Sort = "-created_at"
+	_, err = f.users.GetUserFromCommunity(ctx, f.admin, req)
+	assert.True(ts.T(), errs.CodeIs(err, errs.BadRequest), "got %v", err)
+}
+
+func (ts *TestSuiteUserService) TestUserService_SearchUsers() {
+	ctx := context.Background()
+	f := ts.seedCommunity(ctx)
+
+	_, err := f.users.AdminCreateUser(ctx, f.admin, service.AdminCreateUserRequest{
+		Password: "password123",
+		Phone:    "+6282233445566",
+		Fullname: "Budi Santoso",
+		Address:  "Jl. Melati <3>",
+		Role:     "warga",
+	})
+	require.NoError(ts.T(), err)
+
+	_, outsider := f.register(ctx, "other@test.com", "+6283333333333")
+
+	results, err := f.users.SearchUsers(ctx, f.admin, service.SearchUsersRequest{Query: "bud san"})
+	require.NoError(ts.T(), err)
+	require.NotEmpty(ts.T(), results)
+	assert.Equal(ts.T(), "Budi Santoso", results[0].Fullname)
+	assert.Equal(ts.T(), "<mark>Budi</mark> <mark>Santoso</mark>", results[0].Highlight.Fullname)
+
+	results, err = f.users.SearchUsers(ctx, f.admin, service.SearchUsersRequest{Query: "melati"})
+	require.NoError(ts.T(), err)
+	require.NotEmpty(ts.T(), results)
+	assert.Equal(ts.T(), "Jl. <mark>Melati</mark> &lt;3&gt;", results[0].Highlight.Address)
+
+	results, err = f.users.SearchUsers(ctx, f.admin, service.SearchUsersRequest{Query: "0822334"})
+	require.NoError(ts.T(), err)
+	require.Len(ts.T(), results, 1)
+	assert.Equal(ts.T(), "+<mark>62822334</mark>45566", results[0].Highlight.Phone)
+
+	results, err = f.users.SearchUsers(ctx, outsider, service.SearchUsersRequest{Query: "budi"})
+	require.NoError(ts.T(), err)
+	assert.Empty(ts.T(), results)
+}
+
+func (ts *TestSuiteUserService) TestAuditService_RecordsUserChanges() {
+	ctx := types.WithRequestID(context.Background(), "audit-test")
+	f := ts.seedCommunity(ctx)
+	auditService := service.NewAuditService(f.pool)
+
+	wargaID := f.createUser(ctx, "+6282222222222", "warga")
+	_, err := f.users.AdminUpdateUser(ctx, f.admin, wargaID, service.AdminUpdateUserRequest{Fullname: "warga baru", Password: "rahasia123"}, nil)
+	require.NoError(ts.T(), err)
+	require.NoError(ts.T(), f.users.AdminDeleteUser(ctx, f.admin, wargaID, nil))
+
+	page, err := auditService.ListEvents(ctx, f.admin, service.ListAuditRequest{TargetID: wargaID.String(), Limit: 2})
+	require.NoError(ts.T(), err)
+	assert.Equal(ts.T(), int64(3), page.Total)
+	require.Len(ts.T(), page.Events, 2)
+	assert.Equal(ts.T(), service.AuditUserDelete, page.Events[0].Action)
+	assert.Equal(ts.T(), service.AuditUserUpdate, page.Events[1].Action)
+	assert.Equal(ts.T(), f.reg.AdminID, *page.Events[1].ActorUID)
+	assert.Equal(ts.T(), "audit-test", page.Events[1].RequestID)
+
+	var diff map[string]service.AuditChange
+	require.NoError(ts.T(), json.Unmarshal(page.Events[1].Diff, &diff))
+	assert.Equal(ts.T(), map[string]service.AuditChange{
+		"fullname": {Before: "warga test", After: "warga baru"},
+		"password": {Before: nil, After: "[redacted]"},
+	}, diff)
+
+	next, err := auditService.ListEvents(ctx, f.admin, service.ListAuditRequest{TargetID: wargaID.String(), Cursor: page.NextCursor})
+	require.NoError(ts.T(), err)
+	require.Len(ts.T(), next.Events, 1)
+	assert.Equal(ts.T(), service.AuditUserCreate, next.Events[0].Action)
+	assert.Empty(ts.T(), next.NextCursor)
+
+	_, err = f.pool.Exec(ctx, "delete from audit_events")
+	assert.ErrorContains(ts.T(), err, "append-only")
+
+	warga := f.claimsFor(ctx, wargaID, "warga")
+	_, err = auditService.ListEvents(ctx, warga, service.ListAuditRequest{})
+	assert.True(ts.T(), errs.CodeIs(err, errs.Forbidden), "got %v", err)
 }
 
 func TestUserServiceSuite(t *testing.T) {
@@ -0,0 +1,45 @@
+package telemetry_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/dvvnFrtn/capstone-backend/config"
+	"github.com/dvvnFrtn/capstone-backend/internal/logging"
+	"github.com/dvvnFrtn/capstone-backend/internal/telemetry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+)
+
+func TestSetup_StdoutLinksLogsToTraces(t *testing.T) {
+	var spans, logs bytes.Buffer
+	shutdown, err := telemetry.Setup(context.Background(), config.Tracing{
+		Exporter:    "stdout",
+		ServiceName: "capstone-test",
+		SampleRatio: 1,
+	}, &spans)
+	require.NoError(t, err)
+
+	logger := logging.New(&logs, config.Log{Level: "info", Format: "json"})
+
+	ctx, span := otel.Tracer("test").Start(context.Background(), "operation")
+	logger.InfoContext(ctx, "inside span")
+	span.End()
+
+	require.NoError(t, shutdown(context.Background()))
+
+	var exported struct {
+		Name        string
+		SpanContext struct{ TraceID string }
+	}
+	require.NoError(t, json.Unmarshal(spans.Bytes(), &exported))
+	assert.Equal(t, "operation", exported.Name)
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(logs.Bytes(), &record))
+	assert.Equal(t, exported.SpanContext.TraceID, record["trace_id"])
+	assert.Equal(t, span.SpanContext().TraceID().String(), record["trace_id"])
+}
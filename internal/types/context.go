@@ -1,5 +1,27 @@
 package types
 
+import (
+	"context"
+	"fmt"
+)
+
 type ContextKey string
 
 const RequestIDKey ContextKey = "request_id"
+
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, RequestIDKey, id)
+}
+
+// RequestIDFrom returns the request ID carried by ctx, or "" when there is
+// none. IDs stored as a uuid.UUID or any other fmt.Stringer are accepted too.
+func RequestIDFrom(ctx context.Context) string {
+	switch id := ctx.Value(RequestIDKey).(type) {
+	case string:
+		return id
+	case fmt.Stringer:
+		return id.String()
+	default:
+		return ""
+	}
+}
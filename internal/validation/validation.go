@@ -0,0 +1,209 @@
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/dvvnFrtn/capstone-backend/pkg/errs"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/id"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	enTranslations "github.com/go-playground/validator/v10/translations/en"
+	idTranslations "github.com/go-playground/validator/v10/translations/id"
+)
+
+const (
+	LangID = "id"
+	LangEN = "en"
+)
+
+var (
+	// phonePattern accepts Indonesian mobile numbers in E.164, the format
+	// the identity providers store.
+	phonePattern = regexp.MustCompile(`^\+628[0-9]{7,11}$`)
+	rolePattern  = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]{1,49}$`)
+)
+
+type customRule struct {
+	tag      string
+	validate validator.Func
+	messages map[string]string
+}
+
+var customRules = []customRule{
+	{
+		tag: "phone_id",
+		validate: func(fl validator.FieldLevel) bool {
+			return phonePattern.MatchString(fl.Field().String())
+		},
+		messages: map[string]string{
+			LangID: "{0} harus berupa nomor ponsel Indonesia dengan format +628xxxxxxxx",
+			LangEN: "{0} must be an Indonesian mobile number formatted as +628xxxxxxxx",
+		},
+	},
+	{
+		tag: "rtrw",
+		validate: func(fl validator.FieldLevel) bool {
+			n := fl.Field().Int()
+			return n >= 1 && n <= 999
+		},
+		messages: map[string]string{
+			LangID: "{0} harus berupa nomor RT/RW antara 1 dan 999",
+			LangEN: "{0} must be an RT/RW number between 1 and 999",
+		},
+	},
+	{
+		tag: "role",
+		validate: func(fl validator.FieldLevel) bool {
+			return rolePattern.MatchString(fl.Field().String())
+		},
+		messages: map[string]string{
+			LangID: "{0} harus berupa nama peran dari huruf, angka, '-' atau '_'",
+			LangEN: "{0} must be a role name of letters, digits, '-' or '_'",
+		},
+	},
+}
+
+var (
+	setupOnce sync.Once
+	setupErr  error
+	uni       *ut.UniversalTranslator
+)
+
+// Setup registers the custom rules, JSON field names and translations on
+// gin's validator. It is safe to call more than once.
+func Setup() error {
+	setupOnce.Do(func() {
+		v, ok := binding.Validator.Engine().(*validator.Validate)
+		if !ok {
+			setupErr = errors.New("gin validator is not go-playground/validator")
+			return
+		}
+		setupErr = register(v)
+	})
+	return setupErr
+}
+
+func register(v *validator.Validate) error {
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+
+	idLocale := id.New()
+	uni = ut.New(idLocale, idLocale, en.New())
+
+	idTrans, _ := uni.GetTranslator(LangID)
+	if err := idTranslations.RegisterDefaultTranslations(v, idTrans); err != nil {
+		return err
+	}
+	enTrans, _ := uni.GetTranslator(LangEN)
+	if err := enTranslations.RegisterDefaultTranslations(v, enTrans); err != nil {
+		return err
+	}
+
+	for _, rule := range customRules {
+		if err := v.RegisterValidation(rule.tag, rule.validate); err != nil {
+			return err
+		}
+		for lang, trans := range map[string]ut.Translator{LangID: idTrans, LangEN: enTrans} {
+			if err := v.RegisterTranslation(rule.tag, trans, registerMessage(rule.tag, rule.messages[lang]), translate); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func registerMessage(tag, message string) validator.RegisterTranslationsFunc {
+	return func(trans ut.Translator) error {
+		return trans.Add(tag, message, true)
+	}
+}
+
+func translate(trans ut.Translator, fe validator.FieldError) string {
+	msg, err := trans.T(fe.Tag(), fe.Field())
+	if err != nil {
+		return fe.Error()
+	}
+	return msg
+}
+
+// Lang picks the message language from an Accept-Language header, defaulting
+// to Indonesian.
+func Lang(acceptLanguage string) string {
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, _, _ := strings.Cut(strings.TrimSpace(part), ";")
+		primary, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		switch primary {
+		case LangID, LangEN:
+			return primary
+		}
+	}
+	return LangID
+}
+
+// Fields describes every invalid field in a binding error, with messages in
+// lang. Errors that are not about a field, such as malformed JSON, give nil.
+func Fields(err error, lang string) []errs.FieldError {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return []errs.FieldError{typeMismatch(typeErr, lang)}
+	}
+
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return nil
+	}
+
+	var trans ut.Translator
+	if uni != nil {
+		trans, _ = uni.GetTranslator(lang)
+	}
+
+	fields := make([]errs.FieldError, 0, len(verrs))
+	for _, fe := range verrs {
+		msg := fe.Error()
+		if trans != nil {
+			msg = fe.Translate(trans)
+		}
+		fields = append(fields, errs.FieldError{
+			Field:   fieldPath(fe),
+			Rule:    fe.Tag(),
+			Message: msg,
+		})
+	}
+	return fields
+}
+
+// fieldPath drops the struct name from the namespace, leaving the JSON path
+// such as "permissions[0]".
+func fieldPath(fe validator.FieldError) string {
+	_, path, ok := strings.Cut(fe.Namespace(), ".")
+	if !ok {
+		return fe.Field()
+	}
+	return path
+}
+
+func typeMismatch(err *json.UnmarshalTypeError, lang string) errs.FieldError {
+	msg := fmt.Sprintf("%s harus bertipe %s", err.Field, err.Type.String())
+	if lang == LangEN {
+		msg = fmt.Sprintf("%s must be of type %s", err.Field, err.Type.String())
+	}
+	return errs.FieldError{Field: err.Field, Rule: "type", Message: msg}
+}
@@ -0,0 +1,66 @@
+package validation_test
+
+import (
+	"testing"
+
+	"github.com/dvvnFrtn/capstone-backend/internal/validation"
+	"github.com/dvvnFrtn/capstone-backend/pkg/errs"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type request struct {
+	Phone    string `json:"phone" binding:"required,phone_id"`
+	RtNumber int32  `json:"rt_number" binding:"required,rtrw"`
+	Role     string `json:"role" binding:"required,role"`
+	Email    string `json:"email" binding:"omitempty,email"`
+}
+
+func TestFields(t *testing.T) {
+	require.NoError(t, validation.Setup())
+
+	valid := request{Phone: "+6281234567890", RtNumber: 12, Role: "bendahara"}
+	require.NoError(t, binding.Validator.ValidateStruct(valid))
+
+	invalid := request{Phone: "081234", RtNumber: 1000, Role: "9 lives", Email: "nope"}
+	err := binding.Validator.ValidateStruct(invalid)
+	require.Error(t, err)
+
+	tests := map[string]struct {
+		lang     string
+		expected []errs.FieldError
+	}{
+		"indonesian": {
+			lang: validation.LangID,
+			expected: []errs.FieldError{
+				{Field: "phone", Rule: "phone_id", Message: "phone harus berupa nomor ponsel Indonesia dengan format +628xxxxxxxx"},
+				{Field: "rt_number", Rule: "rtrw", Message: "rt_number harus berupa nomor RT/RW antara 1 dan 999"},
+				{Field: "role", Rule: "role", Message: "role harus berupa nama peran dari huruf, angka, '-' atau '_'"},
+				{Field: "email", Rule: "email", Message: "email harus berupa alamat email yang valid"},
+			},
+		},
+		"english": {
+			lang: validation.LangEN,
+			expected: []errs.FieldError{
+				{Field: "phone", Rule: "phone_id", Message: "phone must be an Indonesian mobile number formatted as +628xxxxxxxx"},
+				{Field: "rt_number", Rule: "rtrw", Message: "rt_number must be an RT/RW number between 1 and 999"},
+				{Field: "role", Rule: "role", Message: "role must be a role name of letters, digits, '-' or '_'"},
+				{Field: "email", Rule: "email", Message: "email must be a valid email address"},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, validation.Fields(err, tc.lang))
+		})
+	}
+}
+
+func TestLang(t *testing.T) {
+	assert.Equal(t, validation.LangEN, validation.Lang("en-US,en;q=0.9"))
+	assert.Equal(t, validation.LangID, validation.Lang("id-ID"))
+	assert.Equal(t, validation.LangEN, validation.Lang("fr-FR, en;q=0.5"))
+	assert.Equal(t, validation.LangID, validation.Lang(""))
+}
@@ -16,24 +16,25 @@ type FirebaseClients struct {
 
 var (
 	instance *FirebaseClients
+	initErr  error
 	once     sync.Once
 )
 
+// InitFirebase creates the clients on the first call; later calls return the
+// same clients, or the error the first call failed with.
 func InitFirebase(ctx context.Context, credentialsPath string) (*FirebaseClients, error) {
-	var err error
-
 	once.Do(func() {
 		opt := option.WithCredentialsFile(credentialsPath)
 
-		app, appErr := firebase.NewApp(ctx, nil, opt)
-		if appErr != nil {
-			err = appErr
+		app, err := firebase.NewApp(ctx, nil, opt)
+		if err != nil {
+			initErr = err
 			return
 		}
 
-		authClient, authErr := app.Auth(ctx)
-		if authErr != nil {
-			err = authErr
+		authClient, err := app.Auth(ctx)
+		if err != nil {
+			initErr = err
 			return
 		}
 
@@ -43,5 +44,5 @@ func InitFirebase(ctx context.Context, credentialsPath string) (*FirebaseClients
 		}
 	})
 
-	return instance, err
+	return instance, initErr
 }
@@ -0,0 +1,98 @@
+package authx
+
+import (
+	"context"
+	"maps"
+	"sync"
+)
+
+type localMemoryStore struct {
+	mu       sync.RWMutex
+	accounts map[string]LocalAccount
+}
+
+func NewLocalMemoryStore() LocalStore {
+	return &localMemoryStore{accounts: make(map[string]LocalAccount)}
+}
+
+func (s *localMemoryStore) Create(ctx context.Context, acc LocalAccount) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.accounts[acc.UID]; ok {
+		return ErrAccountExists
+	}
+	if s.loginTaken(acc) {
+		return ErrAccountExists
+	}
+
+	s.accounts[acc.UID] = cloneAccount(acc)
+	return nil
+}
+
+func (s *localMemoryStore) Update(ctx context.Context, acc LocalAccount) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.accounts[acc.UID]; !ok {
+		return ErrAccountNotFound
+	}
+	if s.loginTaken(acc) {
+		return ErrAccountExists
+	}
+
+	s.accounts[acc.UID] = cloneAccount(acc)
+	return nil
+}
+
+func (s *localMemoryStore) Delete(ctx context.Context, uid string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.accounts[uid]; !ok {
+		return ErrAccountNotFound
+	}
+
+	delete(s.accounts, uid)
+	return nil
+}
+
+func (s *localMemoryStore) Get(ctx context.Context, uid string) (LocalAccount, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	acc, ok := s.accounts[uid]
+	if !ok {
+		return LocalAccount{}, ErrAccountNotFound
+	}
+	return cloneAccount(acc), nil
+}
+
+func (s *localMemoryStore) FindByLogin(ctx context.Context, login string) (LocalAccount, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, acc := range s.accounts {
+		if (acc.Email != "" && acc.Email == login) || (acc.Phone != "" && acc.Phone == login) {
+			return cloneAccount(acc), nil
+		}
+	}
+	return LocalAccount{}, ErrAccountNotFound
+}
+
+func (s *localMemoryStore) loginTaken(acc LocalAccount) bool {
+	for uid, other := range s.accounts {
+		if uid == acc.UID {
+			continue
+		}
+		if (acc.Email != "" && other.Email == acc.Email) || (acc.Phone != "" && other.Phone == acc.Phone) {
+			return true
+		}
+	}
+	return false
+}
+
+func cloneAccount(acc LocalAccount) LocalAccount {
+	acc.Claims = maps.Clone(acc.Claims)
+	return acc
+}
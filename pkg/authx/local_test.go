@@ -0,0 +1,152 @@
+package authx_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dvvnFrtn/capstone-backend/pkg/authx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newLocalIdentity(t *testing.T) *authx.LocalIdentity {
+	t.Helper()
+
+	identity := authx.NewLocalIdentity(authx.NewLocalMemoryStore(), "test-secret", time.Hour)
+	require.NoError(t, identity.CreateUser(context.Background(), "uid-1", "warga@test.com", "+6281111111111", "password123"))
+	require.NoError(t, identity.SetCustomUserClaims(context.Background(), "uid-1", map[string]interface{}{
+		"role":         "warga",
+		"community_id": "community-1",
+	}))
+
+	return identity
+}
+
+func TestLocalIdentity_SignInAndVerify(t *testing.T) {
+	ctx := context.Background()
+	identity := newLocalIdentity(t)
+
+	for _, login := range []string{"warga@test.com", "+6281111111111"} {
+		idToken, err := identity.SignIn(ctx, login, "password123")
+		require.NoError(t, err)
+
+		token, err := identity.VerifyIDToken(ctx, idToken)
+		require.NoError(t, err)
+		assert.Equal(t, "uid-1", token.UID)
+		assert.Equal(t, "warga", token.Claims["role"])
+		assert.Equal(t, "community-1", token.Claims["community_id"])
+	}
+}
+
+func TestLocalIdentity_SignInWrongPassword(t *testing.T) {
+	identity := newLocalIdentity(t)
+
+	_, err := identity.SignIn(context.Background(), "warga@test.com", "wrong")
+	assert.ErrorIs(t, err, authx.ErrInvalidCredentials)
+
+	_, err = identity.SignIn(context.Background(), "nobody@test.com", "password123")
+	assert.ErrorIs(t, err, authx.ErrInvalidCredentials)
+}
+
+func TestLocalIdentity_SignInWithoutPassword(t *testing.T) {
+	ctx := context.Background()
+	identity := newLocalIdentity(t)
+	require.NoError(t, identity.CreateUser(ctx, "uid-2", "", "+6282222222222", ""))
+
+	_, err := identity.SignIn(ctx, "+6282222222222", "")
+	assert.ErrorIs(t, err, authx.ErrInvalidCredentials)
+}
+
+func TestLocalIdentity_DuplicateLogin(t *testing.T) {
+	identity := newLocalIdentity(t)
+
+	err := identity.CreateUser(context.Background(), "uid-2", "", "+6281111111111", "password123")
+	assert.ErrorIs(t, err, authx.ErrAccountExists)
+}
+
+func TestLocalIdentity_UpdateUser(t *testing.T) {
+	ctx := context.Background()
+	identity := newLocalIdentity(t)
+
+	require.NoError(t, identity.UpdateUser(ctx, "uid-1", authx.LocalUserToUpdate{Password: "changed"}))
+
+	_, err := identity.SignIn(ctx, "warga@test.com", "password123")
+	assert.ErrorIs(t, err, authx.ErrInvalidCredentials)
+
+	_, err = identity.SignIn(ctx, "warga@test.com", "changed")
+	assert.NoError(t, err)
+}
+
+func TestLocalIdentity_VerifyRejectsForeignTokens(t *testing.T) {
+	ctx := context.Background()
+	identity := newLocalIdentity(t)
+	other := authx.NewLocalIdentity(authx.NewLocalMemoryStore(), "other-secret", time.Hour)
+	require.NoError(t, other.CreateUser(ctx, "uid-1", "warga@test.com", "", "password123"))
+
+	foreign, err := other.IssueIDToken(ctx, "uid-1")
+	require.NoError(t, err)
+
+	_, err = identity.VerifyIDToken(ctx, foreign)
+	assert.ErrorIs(t, err, authx.ErrInvalidLocalIDToken)
+
+	_, err = identity.VerifyIDToken(ctx, "not-a-token")
+	assert.ErrorIs(t, err, authx.ErrInvalidLocalIDToken)
+}
+
+func TestLocalIdentity_VerifyRejectsExpiredTokens(t *testing.T) {
+	ctx := context.Background()
+	identity := authx.NewLocalIdentity(authx.NewLocalMemoryStore(), "test-secret", -time.Minute)
+	require.NoError(t, identity.CreateUser(ctx, "uid-1", "warga@test.com", "", "password123"))
+
+	idToken, err := identity.IssueIDToken(ctx, "uid-1")
+	require.NoError(t, err)
+
+	_, err = identity.VerifyIDToken(ctx, idToken)
+	assert.ErrorIs(t, err, authx.ErrInvalidLocalIDToken)
+}
+
+func TestLocalIdentity_VerifyRejectsRevokedTokens(t *testing.T) {
+	ctx := context.Background()
+	store := authx.NewLocalMemoryStore()
+	identity := authx.NewLocalIdentity(store, "test-secret", time.Hour)
+	require.NoError(t, identity.CreateUser(ctx, "uid-1", "warga@test.com", "", "password123"))
+
+	idToken, err := identity.IssueIDToken(ctx, "uid-1")
+	require.NoError(t, err)
+
+	// Revoking within the second the token was issued still revokes it.
+	require.NoError(t, identity.RevokeTokens(ctx, "uid-1"))
+	acc, err := store.Get(ctx, "uid-1")
+	require.NoError(t, err)
+	assert.False(t, acc.ValidAfter.IsZero())
+
+	_, err = identity.VerifyIDToken(ctx, idToken)
+	assert.ErrorIs(t, err, authx.ErrInvalidLocalIDToken)
+
+	acc.ValidAfter = acc.ValidAfter.Add(-time.Hour)
+	require.NoError(t, store.Update(ctx, acc))
+
+	_, err = identity.VerifyIDToken(ctx, idToken)
+	assert.NoError(t, err)
+}
+
+func TestLocalIdentity_SetDisabled(t *testing.T) {
+	ctx := context.Background()
+	identity := newLocalIdentity(t)
+
+	idToken, err := identity.SignIn(ctx, "warga@test.com", "password123")
+	require.NoError(t, err)
+
+	require.NoError(t, identity.SetDisabled(ctx, "uid-1", true))
+
+	_, err = identity.SignIn(ctx, "warga@test.com", "password123")
+	assert.ErrorIs(t, err, authx.ErrAccountDisabled)
+	_, err = identity.VerifyIDToken(ctx, idToken)
+	assert.ErrorIs(t, err, authx.ErrInvalidLocalIDToken)
+
+	require.NoError(t, identity.SetDisabled(ctx, "uid-1", false))
+
+	_, err = identity.SignIn(ctx, "warga@test.com", "password123")
+	assert.NoError(t, err)
+}
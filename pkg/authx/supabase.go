@@ -1,11 +1,29 @@
 package authx
 
 import (
-	"os"
+	"fmt"
 
 	"github.com/supabase-community/auth-go"
 )
 
-func NewSupabase() auth.Client {
-	return auth.New(os.Getenv("SUPABASE_PROJECT_REFERENCE"), os.Getenv("SUPABASE_ANON_KEY"))
+type SupabaseConfig struct {
+	ProjectReference string
+	AnonKey          string
+	ServiceRoleKey   string
+	AuthURL          string
+}
+
+func (cfg SupabaseConfig) URL() string {
+	if cfg.AuthURL != "" {
+		return cfg.AuthURL
+	}
+	return fmt.Sprintf("https://%s.supabase.co/auth/v1", cfg.ProjectReference)
+}
+
+func NewSupabase(cfg SupabaseConfig) auth.Client {
+	return auth.New(cfg.ProjectReference, cfg.AnonKey).WithCustomAuthURL(cfg.URL())
+}
+
+func NewSupabaseAdmin(cfg SupabaseConfig) auth.Client {
+	return NewSupabase(cfg).WithToken(cfg.ServiceRoleKey)
 }
@@ -23,6 +23,9 @@ const (
 	Forbidden
 	Unauthorize
 	Internal
+	Unprocessable
+	PreconditionFailed
+	TooLarge
 )
 
 func (c Code) String() string {
@@ -45,16 +48,32 @@ func (c Code) String() string {
 		return "resource_not_found"
 	case RateLimit:
 		return "too_many_request"
+	case Unprocessable:
+		return "unprocessable_request"
+	case PreconditionFailed:
+		return "precondition_failed"
+	case TooLarge:
+		return "payload_too_large"
 	default:
 		return "unknown_error"
 	}
 }
 
+// FieldError points at one invalid field of a request, so clients can
+// highlight it.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
 type Error struct {
-	Op   Op
-	Code Code
-	Msg  Msg
-	Err  error
+	Op        Op
+	Code      Code
+	Msg       Msg
+	Fields    []FieldError
+	RequestID string
+	Err       error
 }
 
 func (e *Error) Error() string {
@@ -89,6 +108,8 @@ func New(args ...any) error {
 			err.Code = arg
 		case Msg:
 			err.Msg = arg
+		case []FieldError:
+			err.Fields = arg
 		case string:
 			err.Err = errors.New(arg)
 		case *Error:
@@ -116,9 +137,31 @@ func New(args ...any) error {
 		err.Msg = prev.Msg
 	}
 
+	if len(prev.Fields) > 0 {
+		err.Fields = prev.Fields
+	}
+
+	if err.RequestID == "" {
+		err.RequestID = prev.RequestID
+	}
+
 	return err
 }
 
+// WithRequestID returns a copy of an *Error carrying the request ID it failed
+// under. Any other error is returned as is, so its code and message are not
+// hidden behind a new *Error.
+func WithRequestID(err error, id string) error {
+	ce, ok := err.(*Error)
+	if !ok || id == "" || ce.RequestID != "" {
+		return err
+	}
+
+	errcopy := *ce
+	errcopy.RequestID = id
+	return &errcopy
+}
+
 func OpStack(err error) []string {
 	type o struct {
 		Op    string
@@ -0,0 +1,36 @@
+package errs_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/dvvnFrtn/capstone-backend/pkg/errs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithRequestID(t *testing.T) {
+	appErr := errs.New(errs.Op("test"), errs.NotFound, errs.Msg("Pengguna tidak dapat ditemukan"), "no rows")
+
+	err := errs.WithRequestID(appErr, "req-1")
+	var got *errs.Error
+	require.ErrorAs(t, err, &got)
+	assert.Equal(t, "req-1", got.RequestID)
+	assert.Equal(t, errs.NotFound, got.Code)
+	assert.Equal(t, errs.Msg("Pengguna tidak dapat ditemukan"), got.Msg)
+	assert.Empty(t, appErr.(*errs.Error).RequestID, "the original is left untouched")
+
+	assert.Equal(t, "req-1", errs.WithRequestID(errs.WithRequestID(appErr, "req-1"), "req-2").(*errs.Error).RequestID)
+}
+
+func TestWithRequestID_LeavesOtherErrorsAlone(t *testing.T) {
+	plain := errors.New("boom")
+	assert.Same(t, plain, errs.WithRequestID(plain, "req-1"))
+
+	wrapped := fmt.Errorf("context: %w", errs.New(errs.Conflict, "taken"))
+	assert.Same(t, wrapped, errs.WithRequestID(wrapped, "req-1"))
+	assert.True(t, errs.CodeIs(errs.WithRequestID(wrapped, "req-1"), errs.Conflict))
+
+	assert.Nil(t, errs.WithRequestID(nil, "req-1"))
+}
@@ -0,0 +1,59 @@
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// Claim reserves Key for a request whose content hashes to RequestHash. The
+// key is remembered for TTL. A claim still pending after Lease is considered
+// abandoned, so a retry may take it over.
+type Claim struct {
+	Key         string
+	RequestHash string
+	TTL         time.Duration
+	Lease       time.Duration
+}
+
+// Record is what is known about a key claimed earlier.
+type Record struct {
+	// ClaimedAt identifies the claim holding the key. Complete and Release
+	// apply to that claim only, so a request whose key was taken over after
+	// its lease cannot overwrite the retry holding it now.
+	ClaimedAt   time.Time
+	RequestHash string
+	// Completed is false while the first request is still being handled.
+	Completed   bool
+	Status      int
+	ContentType string
+	Body        []byte
+}
+
+// Store remembers keys and the responses given to them. Begin must be atomic
+// per key: of concurrent claims, only one is granted.
+type Store interface {
+	// Begin claims the key, or returns the record of the earlier request
+	// holding it with claimed false. Either way rec.ClaimedAt identifies the
+	// claim holding the key.
+	Begin(ctx context.Context, claim Claim) (rec Record, claimed bool, err error)
+	// Complete stores the response to the claim made at claimedAt, to be
+	// replayed.
+	Complete(ctx context.Context, key string, claimedAt time.Time, status int, contentType string, body []byte) error
+	// Release drops the claim made at claimedAt whose request failed, so the
+	// key may be retried.
+	Release(ctx context.Context, key string, claimedAt time.Time) error
+}
+
+// Hash identifies the content of a request, so a key reused for another
+// request can be told apart from a retry.
+func Hash(method, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method))
+	h.Write([]byte{0})
+	h.Write([]byte(path))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
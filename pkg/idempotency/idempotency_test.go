@@ -0,0 +1,84 @@
+package idempotency_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dvvnFrtn/capstone-backend/pkg/idempotency"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore_ReplaysCompletedKey(t *testing.T) {
+	store := idempotency.NewMemoryStore()
+	claim := idempotency.Claim{Key: "POST /api/users:k1", RequestHash: "h1", TTL: time.Hour, Lease: time.Minute}
+
+	held, claimed, err := store.Begin(context.Background(), claim)
+	require.NoError(t, err)
+	assert.True(t, claimed)
+
+	rec, claimed, err := store.Begin(context.Background(), claim)
+	require.NoError(t, err)
+	assert.False(t, claimed, "a key is claimed once")
+	assert.False(t, rec.Completed)
+
+	require.NoError(t, store.Complete(context.Background(), claim.Key, held.ClaimedAt, 201, "application/json", []byte(`{"id":1}`)))
+
+	rec, claimed, err = store.Begin(context.Background(), claim)
+	require.NoError(t, err)
+	assert.False(t, claimed)
+	assert.Equal(t, idempotency.Record{
+		ClaimedAt:   held.ClaimedAt,
+		RequestHash: "h1",
+		Completed:   true,
+		Status:      201,
+		ContentType: "application/json",
+		Body:        []byte(`{"id":1}`),
+	}, rec)
+}
+
+func TestMemoryStore_ReleaseAndExpiry(t *testing.T) {
+	store := idempotency.NewMemoryStore()
+	claim := idempotency.Claim{Key: "k", RequestHash: "h", TTL: 20 * time.Millisecond, Lease: time.Minute}
+
+	held, claimed, _ := store.Begin(context.Background(), claim)
+	require.True(t, claimed)
+	require.NoError(t, store.Release(context.Background(), claim.Key, held.ClaimedAt))
+
+	held, claimed, _ = store.Begin(context.Background(), claim)
+	assert.True(t, claimed, "a released key can be claimed again")
+	require.NoError(t, store.Complete(context.Background(), claim.Key, held.ClaimedAt, 200, "", nil))
+
+	time.Sleep(25 * time.Millisecond)
+	_, claimed, _ = store.Begin(context.Background(), claim)
+	assert.True(t, claimed, "an expired key can be claimed again")
+}
+
+func TestMemoryStore_AbandonedClaimIsTakenOver(t *testing.T) {
+	store := idempotency.NewMemoryStore()
+	claim := idempotency.Claim{Key: "k", RequestHash: "h", TTL: time.Hour, Lease: 20 * time.Millisecond}
+
+	abandoned, claimed, _ := store.Begin(context.Background(), claim)
+	require.True(t, claimed)
+
+	time.Sleep(25 * time.Millisecond)
+	held, claimed, _ := store.Begin(context.Background(), claim)
+	assert.True(t, claimed)
+
+	// the request that lost its claim finishes late
+	require.NoError(t, store.Complete(context.Background(), claim.Key, abandoned.ClaimedAt, 201, "", nil))
+	require.NoError(t, store.Release(context.Background(), claim.Key, abandoned.ClaimedAt))
+
+	rec, claimed, _ := store.Begin(context.Background(), claim)
+	assert.False(t, claimed)
+	assert.False(t, rec.Completed)
+	assert.Equal(t, held.ClaimedAt, rec.ClaimedAt)
+}
+
+func TestHash(t *testing.T) {
+	h := idempotency.Hash("POST", "/api/users", []byte(`{"a":1}`))
+	assert.Equal(t, h, idempotency.Hash("POST", "/api/users", []byte(`{"a":1}`)))
+	assert.NotEqual(t, h, idempotency.Hash("POST", "/api/users", []byte(`{"a":2}`)))
+	assert.NotEqual(t, h, idempotency.Hash("POST", "/api/users/", []byte(`{"a":1}`)))
+}
@@ -0,0 +1,80 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type entry struct {
+	Record
+	expiresAt time.Time
+}
+
+type memoryStore struct {
+	mu        sync.Mutex
+	entries   map[string]entry
+	lastSweep time.Time
+}
+
+// NewMemoryStore keeps keys in this process only, so a retry reaching
+// another instance is not recognised.
+func NewMemoryStore() Store {
+	return &memoryStore{entries: make(map[string]entry), lastSweep: time.Now()}
+}
+
+func (s *memoryStore) Begin(ctx context.Context, claim Claim) (Record, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	if e, ok := s.entries[claim.Key]; ok && now.Before(e.expiresAt) && (e.Completed || now.Sub(e.ClaimedAt) < claim.Lease) {
+		return e.Record, false, nil
+	}
+
+	rec := Record{ClaimedAt: now, RequestHash: claim.RequestHash}
+	s.entries[claim.Key] = entry{Record: rec, expiresAt: now.Add(claim.TTL)}
+	return rec, true, nil
+}
+
+func (s *memoryStore) Complete(ctx context.Context, key string, claimedAt time.Time, status int, contentType string, body []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[key]
+	if !ok || e.Completed || !e.ClaimedAt.Equal(claimedAt) {
+		return nil
+	}
+	e.Completed = true
+	e.Status = status
+	e.ContentType = contentType
+	e.Body = body
+	s.entries[key] = e
+	return nil
+}
+
+func (s *memoryStore) Release(ctx context.Context, key string, claimedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.entries[key]; ok && !e.Completed && e.ClaimedAt.Equal(claimedAt) {
+		delete(s.entries, key)
+	}
+	return nil
+}
+
+// sweep drops expired keys, at most once a minute.
+func (s *memoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+
+	for key, e := range s.entries {
+		if !now.Before(e.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+}
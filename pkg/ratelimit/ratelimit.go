@@ -0,0 +1,57 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Limit describes a token bucket holding up to Burst tokens and refilled
+// with Requests tokens every Per.
+type Limit struct {
+	Requests int
+	Per      time.Duration
+	Burst    int
+}
+
+// Rate is the refill rate in tokens per second.
+func (l Limit) Rate() float64 {
+	return float64(l.Requests) / l.Per.Seconds()
+}
+
+// Capacity is the most tokens the bucket holds: Burst, or Requests when
+// Burst is unset.
+func (l Limit) Capacity() float64 {
+	if l.Burst > 0 {
+		return float64(l.Burst)
+	}
+	return float64(l.Requests)
+}
+
+// Refill is how long an emptied bucket takes to fill up. A bucket untouched
+// for that long is the same as a new one.
+func (l Limit) Refill() time.Duration {
+	return time.Duration(l.Capacity() / l.Rate() * float64(time.Second))
+}
+
+type Decision struct {
+	Allowed   bool
+	Remaining int
+	// RetryAfter is how long until the next token is available, zero when
+	// the request was allowed.
+	RetryAfter time.Duration
+}
+
+// Store keeps the buckets. Take must be atomic per key.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Decision, error)
+}
+
+// Decide turns the tokens left in a bucket after a take into a Decision.
+func Decide(tokens float64, allowed bool, limit Limit) Decision {
+	d := Decision{Allowed: allowed, Remaining: int(math.Max(0, math.Floor(tokens)))}
+	if !allowed {
+		d.RetryAfter = time.Duration((1 - tokens) / limit.Rate() * float64(time.Second))
+	}
+	return d
+}
@@ -0,0 +1,46 @@
+package ratelimit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dvvnFrtn/capstone-backend/pkg/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore_TokenBucket(t *testing.T) {
+	store := ratelimit.NewMemoryStore()
+	limit := ratelimit.Limit{Requests: 3, Per: time.Hour}
+
+	for i := range 3 {
+		decision, err := store.Take(context.Background(), "ip:10.0.0.1", limit)
+		require.NoError(t, err)
+		assert.True(t, decision.Allowed)
+		assert.Equal(t, 2-i, decision.Remaining)
+	}
+
+	decision, err := store.Take(context.Background(), "ip:10.0.0.1", limit)
+	require.NoError(t, err)
+	assert.False(t, decision.Allowed)
+	assert.InDelta(t, 20*time.Minute, decision.RetryAfter, float64(time.Second))
+
+	decision, err = store.Take(context.Background(), "ip:10.0.0.2", limit)
+	require.NoError(t, err)
+	assert.True(t, decision.Allowed, "keys have their own bucket")
+}
+
+func TestMemoryStore_Refills(t *testing.T) {
+	store := ratelimit.NewMemoryStore()
+	limit := ratelimit.Limit{Requests: 1, Per: 20 * time.Millisecond}
+
+	decision, _ := store.Take(context.Background(), "uid:1", limit)
+	assert.True(t, decision.Allowed)
+	decision, _ = store.Take(context.Background(), "uid:1", limit)
+	assert.False(t, decision.Allowed)
+
+	time.Sleep(25 * time.Millisecond)
+	decision, _ = store.Take(context.Background(), "uid:1", limit)
+	assert.True(t, decision.Allowed)
+}
@@ -0,0 +1,63 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+	limit     Limit
+}
+
+type memoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]bucket
+	lastSweep time.Time
+}
+
+// NewMemoryStore keeps buckets in this process only, so each instance
+// enforces its own limits.
+func NewMemoryStore() Store {
+	return &memoryStore{buckets: make(map[string]bucket), lastSweep: time.Now()}
+}
+
+func (s *memoryStore) Take(ctx context.Context, key string, limit Limit) (Decision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = bucket{tokens: limit.Capacity(), updatedAt: now}
+	}
+
+	tokens := math.Min(limit.Capacity(), b.tokens+now.Sub(b.updatedAt).Seconds()*limit.Rate())
+	allowed := tokens >= 1
+	if allowed {
+		tokens--
+	}
+
+	s.buckets[key] = bucket{tokens: tokens, updatedAt: now, limit: limit}
+	return Decide(tokens, allowed, limit), nil
+}
+
+// sweep drops buckets that have refilled under their own limit, at most once
+// a minute.
+func (s *memoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+
+	for key, b := range s.buckets {
+		if now.Sub(b.updatedAt) >= b.limit.Refill() {
+			delete(s.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package saga
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/dvvnFrtn/capstone-backend/pkg/errs"
+)
+
+type Step struct {
+	Name       string
+	Action     func(ctx context.Context) error
+	Compensate func(ctx context.Context) error
+}
+
+func Run(ctx context.Context, steps ...Step) error {
+	const op errs.Op = "saga.Run"
+
+	for i, step := range steps {
+		if err := step.Action(ctx); err != nil {
+			if compErr := compensate(ctx, steps[:i]); compErr != nil {
+				return errs.New(op, errs.Internal, errors.Join(err, compErr))
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+func compensate(ctx context.Context, done []Step) error {
+	// compensation must still run when the request context was cancelled
+	ctx = context.WithoutCancel(ctx)
+	logger := slog.Default().With("component", "saga")
+
+	var errList []error
+	for i := len(done) - 1; i >= 0; i-- {
+		step := done[i]
+		if step.Compensate == nil {
+			continue
+		}
+		if err := step.Compensate(ctx); err != nil {
+			logger.ErrorContext(ctx, "saga compensation failed", "step", step.Name, "err", err)
+			errList = append(errList, fmt.Errorf("compensate %s: %w", step.Name, err))
+		} else {
+			logger.InfoContext(ctx, "saga step compensated", "step", step.Name)
+		}
+	}
+
+	return errors.Join(errList...)
+}
@@ -0,0 +1,111 @@
+package saga_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dvvnFrtn/capstone-backend/pkg/errs"
+	"github.com/dvvnFrtn/capstone-backend/pkg/saga"
+	"github.com/stretchr/testify/assert"
+)
+
+func recordingStep(name string, journal *[]string, failAction, failCompensate bool) saga.Step {
+	return saga.Step{
+		Name: name,
+		Action: func(ctx context.Context) error {
+			*journal = append(*journal, "do:"+name)
+			if failAction {
+				return errs.New(errs.Op("test."+name), errs.Conflict, "action failed")
+			}
+			return nil
+		},
+		Compensate: func(ctx context.Context) error {
+			*journal = append(*journal, "undo:"+name)
+			if failCompensate {
+				return errors.New("compensate failed")
+			}
+			return nil
+		},
+	}
+}
+
+func TestRun_AllStepsSucceed(t *testing.T) {
+	var journal []string
+
+	err := saga.Run(context.Background(),
+		recordingStep("db", &journal, false, false),
+		recordingStep("auth", &journal, false, false),
+	)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"do:db", "do:auth"}, journal)
+}
+
+func TestRun_FailureAtEachStep(t *testing.T) {
+	tests := []struct {
+		name     string
+		failAt   int
+		expected []string
+	}{
+		{name: "first step", failAt: 0, expected: []string{"do:db"}},
+		{name: "second step", failAt: 1, expected: []string{"do:db", "do:auth", "undo:db"}},
+		{name: "third step", failAt: 2, expected: []string{"do:db", "do:auth", "do:claims", "undo:auth", "undo:db"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var journal []string
+
+			err := saga.Run(context.Background(),
+				recordingStep("db", &journal, tt.failAt == 0, false),
+				recordingStep("auth", &journal, tt.failAt == 1, false),
+				recordingStep("claims", &journal, tt.failAt == 2, false),
+			)
+
+			assert.Error(t, err)
+			assert.True(t, errs.CodeIs(err, errs.Conflict))
+			assert.Equal(t, tt.expected, journal)
+		})
+	}
+}
+
+func TestRun_CompensationFailure(t *testing.T) {
+	var journal []string
+
+	err := saga.Run(context.Background(),
+		recordingStep("db", &journal, false, true),
+		recordingStep("auth", &journal, true, false),
+	)
+
+	assert.Error(t, err)
+	assert.True(t, errs.CodeIs(err, errs.Internal))
+	assert.ErrorContains(t, err, "compensate db")
+	assert.Equal(t, []string{"do:db", "do:auth", "undo:db"}, journal)
+}
+
+func TestRun_CompensatesAfterCancel(t *testing.T) {
+	var journal []string
+	ctx, cancel := context.WithCancel(context.Background())
+
+	err := saga.Run(ctx,
+		saga.Step{
+			Name:   "db",
+			Action: func(ctx context.Context) error { return nil },
+			Compensate: func(ctx context.Context) error {
+				journal = append(journal, "undo:db")
+				return ctx.Err()
+			},
+		},
+		saga.Step{
+			Name: "auth",
+			Action: func(ctx context.Context) error {
+				cancel()
+				return ctx.Err()
+			},
+		},
+	)
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, []string{"undo:db"}, journal)
+}
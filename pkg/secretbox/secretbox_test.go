@@ -0,0 +1,46 @@
+package secretbox_test
+
+import (
+	"testing"
+
+	"github.com/dvvnFrtn/capstone-backend/pkg/secretbox"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBox_SealOpen(t *testing.T) {
+	box, err := secretbox.New("outbox-secret")
+	require.NoError(t, err)
+
+	sealed, err := box.Seal("password123")
+	require.NoError(t, err)
+	assert.NotContains(t, sealed, "password123")
+
+	again, err := box.Seal("password123")
+	require.NoError(t, err)
+	assert.NotEqual(t, sealed, again, "every seal uses a fresh nonce")
+
+	plaintext, err := box.Open(sealed)
+	require.NoError(t, err)
+	assert.Equal(t, "password123", plaintext)
+}
+
+func TestBox_OpenRejectsForeignValues(t *testing.T) {
+	box, err := secretbox.New("outbox-secret")
+	require.NoError(t, err)
+	other, err := secretbox.New("another-secret")
+	require.NoError(t, err)
+
+	sealed, err := other.Seal("password123")
+	require.NoError(t, err)
+
+	for _, value := range []string{sealed, "password123", "", "c2hvcnQ"} {
+		_, err := box.Open(value)
+		assert.ErrorIs(t, err, secretbox.ErrInvalid, value)
+	}
+}
+
+func TestNew_RequiresSecret(t *testing.T) {
+	_, err := secretbox.New("")
+	assert.Error(t, err)
+}
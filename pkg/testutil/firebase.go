@@ -0,0 +1,59 @@
+package testutil
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"testing"
+
+	firebase "firebase.google.com/go/v4"
+	"firebase.google.com/go/v4/auth"
+)
+
+const firebaseEmulatorProject = "demo-capstone"
+
+// NewFirebaseEmulatorAuth returns an auth client for the Firebase Auth
+// emulator named by FIREBASE_AUTH_EMULATOR_HOST, starting from an empty
+// account list. The test is skipped when no emulator is configured.
+func NewFirebaseEmulatorAuth(t *testing.T) *auth.Client {
+	t.Helper()
+
+	host := os.Getenv("FIREBASE_AUTH_EMULATOR_HOST")
+	if host == "" {
+		t.Skip("FIREBASE_AUTH_EMULATOR_HOST is not set")
+	}
+
+	ctx := context.Background()
+	app, err := firebase.NewApp(ctx, &firebase.Config{ProjectID: firebaseEmulatorProject})
+	if err != nil {
+		t.Fatalf("failed to create firebase app: %v", err)
+	}
+	client, err := app.Auth(ctx)
+	if err != nil {
+		t.Fatalf("failed to create firebase auth client: %v", err)
+	}
+
+	clear := func() error {
+		url := fmt.Sprintf("http://%s/emulator/v1/projects/%s/accounts", host, firebaseEmulatorProject)
+		req, err := http.NewRequest(http.MethodDelete, url, nil)
+		if err != nil {
+			return err
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			return fmt.Errorf("response status code %d", res.StatusCode)
+		}
+		return nil
+	}
+	if err := clear(); err != nil {
+		t.Fatalf("failed to clear firebase emulator accounts: %v", err)
+	}
+	t.Cleanup(func() { _ = clear() })
+
+	return client
+}
@@ -0,0 +1,210 @@
+package testutil
+
+import (
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+type GoTrueUser struct {
+	ID          uuid.UUID              `json:"id"`
+	Email       string                 `json:"email"`
+	Phone       string                 `json:"phone"`
+	Password    string                 `json:"-"`
+	AppMetadata map[string]interface{} `json:"app_metadata"`
+}
+
+// GoTrueStub is an in-memory stand-in for the Supabase GoTrue admin API. It
+// implements the endpoints used by the Supabase auth service and verifier.
+type GoTrueStub struct {
+	Server         *httptest.Server
+	ServiceRoleKey string
+
+	mu     sync.Mutex
+	users  map[uuid.UUID]GoTrueUser
+	tokens map[string]uuid.UUID
+}
+
+func NewGoTrueStub(serviceRoleKey string) *GoTrueStub {
+	stub := &GoTrueStub{
+		ServiceRoleKey: serviceRoleKey,
+		users:          make(map[uuid.UUID]GoTrueUser),
+		tokens:         make(map[string]uuid.UUID),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /admin/users", stub.admin(stub.createUser))
+	mux.HandleFunc("GET /admin/users/{id}", stub.admin(stub.getUser))
+	mux.HandleFunc("PUT /admin/users/{id}", stub.admin(stub.updateUser))
+	mux.HandleFunc("DELETE /admin/users/{id}", stub.admin(stub.deleteUser))
+	mux.HandleFunc("GET /user", stub.currentUser)
+	stub.Server = httptest.NewServer(mux)
+
+	return stub
+}
+
+func (s *GoTrueStub) Close() {
+	s.Server.Close()
+}
+
+func (s *GoTrueStub) User(id uuid.UUID) (GoTrueUser, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[id]
+	user.AppMetadata = maps.Clone(user.AppMetadata)
+	return user, ok
+}
+
+func (s *GoTrueStub) IssueAccessToken(id uuid.UUID) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token := uuid.NewString()
+	s.tokens[token] = id
+	return token
+}
+
+func (s *GoTrueStub) admin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+s.ServiceRoleKey {
+			writeGoTrueError(w, http.StatusUnauthorized, "invalid service role key")
+			return
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		next(w, r)
+	}
+}
+
+func (s *GoTrueStub) createUser(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		GoTrueUser
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeGoTrueError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	user := req.GoTrueUser
+	user.Password = req.Password
+	if user.ID == uuid.Nil {
+		user.ID = uuid.New()
+	}
+	if _, ok := s.users[user.ID]; ok || s.taken(user) {
+		writeGoTrueError(w, http.StatusUnprocessableEntity, "A user with this email address or phone has already been registered")
+		return
+	}
+
+	s.users[user.ID] = user
+	writeGoTrueJSON(w, http.StatusOK, user)
+}
+
+func (s *GoTrueStub) getUser(w http.ResponseWriter, r *http.Request) {
+	user, ok := s.users[uuid.MustParse(r.PathValue("id"))]
+	if !ok {
+		writeGoTrueError(w, http.StatusNotFound, "User not found")
+		return
+	}
+	writeGoTrueJSON(w, http.StatusOK, user)
+}
+
+func (s *GoTrueStub) updateUser(w http.ResponseWriter, r *http.Request) {
+	user, ok := s.users[uuid.MustParse(r.PathValue("id"))]
+	if !ok {
+		writeGoTrueError(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	var req struct {
+		Email       string                 `json:"email"`
+		Phone       string                 `json:"phone"`
+		Password    string                 `json:"password"`
+		AppMetadata map[string]interface{} `json:"app_metadata"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeGoTrueError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if req.Email != "" {
+		user.Email = req.Email
+	}
+	if req.Phone != "" {
+		user.Phone = req.Phone
+	}
+	if req.Password != "" {
+		user.Password = req.Password
+	}
+	if req.AppMetadata != nil {
+		if user.AppMetadata == nil {
+			user.AppMetadata = map[string]interface{}{}
+		}
+		maps.Copy(user.AppMetadata, req.AppMetadata)
+	}
+	if s.taken(user) {
+		writeGoTrueError(w, http.StatusUnprocessableEntity, "A user with this email address or phone has already been registered")
+		return
+	}
+
+	s.users[user.ID] = user
+	writeGoTrueJSON(w, http.StatusOK, user)
+}
+
+func (s *GoTrueStub) deleteUser(w http.ResponseWriter, r *http.Request) {
+	id := uuid.MustParse(r.PathValue("id"))
+	if _, ok := s.users[id]; !ok {
+		writeGoTrueError(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	delete(s.users, id)
+	writeGoTrueJSON(w, http.StatusOK, struct{}{})
+}
+
+func (s *GoTrueStub) currentUser(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id, ok := s.tokens[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")]
+	if !ok {
+		writeGoTrueError(w, http.StatusUnauthorized, "invalid JWT")
+		return
+	}
+
+	user, ok := s.users[id]
+	if !ok {
+		writeGoTrueError(w, http.StatusNotFound, "User not found")
+		return
+	}
+	writeGoTrueJSON(w, http.StatusOK, user)
+}
+
+func (s *GoTrueStub) taken(user GoTrueUser) bool {
+	for id, other := range s.users {
+		if id == user.ID {
+			continue
+		}
+		if (user.Email != "" && other.Email == user.Email) || (user.Phone != "" && other.Phone == user.Phone) {
+			return true
+		}
+	}
+	return false
+}
+
+func writeGoTrueJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeGoTrueError(w http.ResponseWriter, status int, msg string) {
+	writeGoTrueJSON(w, status, map[string]interface{}{"code": status, "msg": msg})
+}
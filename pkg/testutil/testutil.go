@@ -2,17 +2,13 @@ package testutil
 
 import (
 	"context"
-	"errors"
-	"fmt"
-	"path/filepath"
-	"runtime"
+	"testing"
 	"time"
 
 	"github.com/docker/go-connections/nat"
 	"github.com/dvvnFrtn/capstone-backend/config"
-	"github.com/golang-migrate/migrate/v4"
-	_ "github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/dvvnFrtn/capstone-backend/infra/db"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/modules/postgres"
 	"github.com/testcontainers/testcontainers-go/wait"
@@ -37,47 +33,68 @@ func NewTestContainer(ctx context.Context, cfg *config.DB) (*postgres.PostgresCo
 }
 
 func MigrateDatabase(dsn string) error {
-	_, path, _, ok := runtime.Caller(0)
-	if !ok {
-		return errors.New("failed to get path caller")
-	}
-
-	dir := filepath.Dir(path)
-	mDir := filepath.Join(dir, "..", "..", "infra/db/migrations")
-	mSrc := fmt.Sprintf("file:%s", mDir)
-
-	m, err := migrate.New(mSrc, dsn)
+	m, err := db.NewMigrator(dsn, time.Minute)
 	if err != nil {
 		return err
 	}
 	defer m.Close()
 
-	err = m.Up()
-	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
-		return err
-	}
-	return nil
+	return m.Up()
 }
 
+// SetupTestDatabase starts a container with cfg's name and credentials, points
+// cfg at it and migrates it. The container is returned even on error so the
+// caller can terminate it.
 func SetupTestDatabase(ctx context.Context, cfg *config.DB) (*postgres.PostgresContainer, error) {
 	container, err := NewTestContainer(ctx, cfg)
 	if err != nil {
-		return nil, err
+		return container, err
 	}
 
+	host, err := container.Host(ctx)
+	if err != nil {
+		return container, err
+	}
 	port, err := container.MappedPort(ctx, nat.Port("5432"))
 	if err != nil {
-		return nil, err
+		return container, err
 	}
-	cfg.Port = port.Port()
+	cfg.Host, cfg.Port = host, port.Port()
 
 	if err := MigrateDatabase(cfg.DSN()); err != nil {
-		return nil, err
+		return container, err
 	}
 
 	if err := container.Snapshot(ctx); err != nil {
-		return nil, err
+		return container, err
 	}
 
 	return container, nil
 }
+
+// NewTestPool starts a migrated database of its own for a package test and
+// tears it down with the test. The test is skipped when no container runtime
+// is available.
+func NewTestPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+	testcontainers.SkipIfProviderIsNotHealthy(t)
+
+	ctx := context.Background()
+	cfg := config.Database()
+	cfg.User, cfg.Pass, cfg.Name = "test", "test", "test"
+
+	container, err := SetupTestDatabase(ctx, &cfg)
+	if container != nil {
+		t.Cleanup(func() { _ = container.Terminate(context.Background()) })
+	}
+	if err != nil {
+		t.Fatalf("failed to set up test database: %v", err)
+	}
+
+	pool, err := db.NewPostgrePool(ctx, &cfg)
+	if err != nil {
+		t.Fatalf("failed to connect to test database: %v", err)
+	}
+	t.Cleanup(pool.Close)
+	return pool
+}